@@ -43,7 +43,7 @@ func Load() *Config {
 
 	return &Config{
 		DatabaseURL: databaseURL,
-		JWTSecret:   getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+		JWTSecret:   os.Getenv("JWT_SECRET"),
 		Port:        getEnv("PORT", "8080"),
 	}
 }
@@ -55,12 +55,35 @@ func ConnectDB() {
 		panic("Failed to connect to database")
 	}
 
+	// Users who signed up before email verification existed count as verified
+	verificationAdded := !db.Migrator().HasColumn(&models.User{}, "email_verified_at")
+
 	db.AutoMigrate(
 		&models.User{},
 		&models.TwitterAccount{},
 		&models.WhatsAppAccount{},
 		&models.ApiCallLog{},
 		&models.MessageLog{}, // New: Message history logging
+		&models.Proxy{},
+		&models.TwitterUser{},
+		&models.TwitterTweet{},
+		&models.TwitterEngagement{},
+		&models.EngagerSetMember{},
+		&models.EngagerCheckpoint{},
+		&models.Giveaway{},
+		&models.GiveawayEntrant{},
+		&models.Plan{},
+		&models.UsageLedgerEntry{},
+		&models.AdminAuditLog{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.ApiKey{},
+		&models.RecoveryCode{},
+		&models.TwitterActionSlot{},
 	)
+
+	if verificationAdded {
+		db.Model(&models.User{}).Where("email_verified_at IS NULL").Update("email_verified_at", gorm.Expr("created_at"))
+	}
 	DB = db
 }
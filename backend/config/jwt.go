@@ -0,0 +1,24 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// minJWTSecretLength is the shortest JWT_SECRET accepted, in bytes
+const minJWTSecretLength = 32
+
+// JWTSecret signs and verifies login, challenge and Twitter token JWTs. It is
+// set from the environment by LoadJWTSecret.
+var JWTSecret []byte
+
+// LoadJWTSecret reads JWT_SECRET. There is no default: anyone who knows the key
+// can mint a JWT for any user and skip the password and 2FA steps.
+func LoadJWTSecret() error {
+	secret := os.Getenv("JWT_SECRET")
+	if len(secret) < minJWTSecretLength {
+		return fmt.Errorf("JWT_SECRET must be set to a random string of at least %d characters", minJWTSecretLength)
+	}
+	JWTSecret = []byte(secret)
+	return nil
+}
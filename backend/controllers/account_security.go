@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_mailer "ripper-backend/utils/mailer"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Purposes of the signed links emailed to users and of login challenges, and
+// how long each link is valid
+const (
+	purposeVerifyEmail    = "verify_email"
+	purposeResetPassword  = "reset_password"
+	purposeLoginChallenge = "login_challenge"
+
+	verifyEmailLinkTTL   = 48 * time.Hour
+	resetPasswordLinkTTL = time.Hour
+)
+
+// appURL is where the links in emails point, the frontend by default
+func appURL() string {
+	if v := os.Getenv("APP_URL"); v != "" {
+		return v
+	}
+	return "http://localhost:8081"
+}
+
+// linkSecret derives the signing key of a link purpose from JWT_SECRET, so a
+// link can never pass as a login JWT or as a link of another purpose
+func linkSecret(purpose string) []byte {
+	mac := hmac.New(sha256.New, config.JWTSecret)
+	mac.Write([]byte("link:" + purpose))
+	return mac.Sum(nil)
+}
+
+// linkStamp ties a link to server-side state of the user that a link's reader
+// can't guess: the verification nonce for verification links, and the password
+// hash for reset links and login challenges, so those stop working once used.
+// It is empty while the user has no such state.
+func linkStamp(purpose string, user *models.User) string {
+	value := user.VerifyNonce
+	if purpose == purposeResetPassword || purpose == purposeLoginChallenge {
+		value = user.Password
+	}
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// signLinkToken signs the token of a link emailed to the user
+func signLinkToken(purpose string, user *models.User, ttl time.Duration) (string, error) {
+	if purpose == purposeVerifyEmail {
+		if err := utils.EnsureVerifyNonce(user); err != nil {
+			return "", err
+		}
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose": purpose,
+		"sub":     user.ID,
+		"stamp":   linkStamp(purpose, user),
+		"exp":     time.Now().Add(ttl).Unix(),
+	}).SignedString(linkSecret(purpose))
+}
+
+// parseLinkToken returns the user a link token was signed for, if it is valid,
+// unexpired and not used yet
+func parseLinkToken(purpose, tokenString string) (*models.User, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return linkSecret(purpose), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid or expired link")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purpose {
+		return nil, fmt.Errorf("invalid or expired link")
+	}
+	userID, _ := claims["sub"].(string)
+
+	var user models.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("invalid or expired link")
+	}
+	if stamp := linkStamp(purpose, &user); stamp == "" || claims["stamp"] != stamp {
+		return nil, fmt.Errorf("this link has already been used")
+	}
+	return &user, nil
+}
+
+// sendVerificationEmail emails the user a link to verify their email
+func sendVerificationEmail(user *models.User) error {
+	token, err := signLinkToken(purposeVerifyEmail, user, verifyEmailLinkTTL)
+	if err != nil {
+		return err
+	}
+	link := appURL() + "/verify-email?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your email address to start using your account:\n\n%s\n\nThe link is valid for %d hours.\n",
+		user.Name, link, int(verifyEmailLinkTTL.Hours()))
+	return utils_mailer.Send(user.Email, "Verify your email", body)
+}
+
+// sendPasswordResetEmail emails the user a link to choose a new password
+func sendPasswordResetEmail(user *models.User) error {
+	token, err := signLinkToken(purposeResetPassword, user, resetPasswordLinkTTL)
+	if err != nil {
+		return err
+	}
+	link := appURL() + "/reset-password?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. Choose a new one here:\n\n%s\n\nThe link is valid for %d minutes and works once. If it wasn't you, ignore this email.\n",
+		user.Name, link, int(resetPasswordLinkTTL.Minutes()))
+	return utils_mailer.Send(user.Email, "Reset your password", body)
+}
+
+// respondLockedOut refuses a login while the user is locked out
+func respondLockedOut(c *gin.Context, lockedUntil time.Time) {
+	retryAfter := int(math.Ceil(time.Until(lockedUntil).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":        "Too many failed logins, try again later or reset your password",
+		"locked_until": lockedUntil,
+	})
+}
+
+// VerifyEmail godoc
+// @Summary      Verify Email
+// @Description  Verify the email of an account with the token of the link emailed at signup
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body schemas.VerifyEmailRequest true "Verification token"
+// @Success      200 {object} schemas.MessageResponse
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var req schemas.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := parseLinkToken(purposeVerifyEmail, req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !user.IsEmailVerified() {
+		if err := config.DB.Model(user).Update("email_verified_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "Email verified, you can log in"})
+}
+
+// ResendVerification godoc
+// @Summary      Resend Verification Email
+// @Description  Email a new verification link to an unverified account. The response is the same whether or not the account exists
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body schemas.EmailRequest true "Account email"
+// @Success      200 {object} schemas.MessageResponse
+// @Failure      400 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /auth/resend-verification [post]
+func ResendVerification(c *gin.Context) {
+	var req schemas.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if config.DB.Where("email = ?", req.Email).First(&user).Error == nil && !user.IsEmailVerified() {
+		if err := sendVerificationEmail(&user); err != nil {
+			log.Printf("❌ Failed to send the verification email: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "If the account exists and is unverified, a verification link has been sent"})
+}
+
+// ForgotPassword godoc
+// @Summary      Forgot Password
+// @Description  Email a password reset link, valid for an hour and usable once. The response is the same whether or not the account exists
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body schemas.EmailRequest true "Account email"
+// @Success      200 {object} schemas.MessageResponse
+// @Failure      400 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /auth/forgot-password [post]
+func ForgotPassword(c *gin.Context) {
+	var req schemas.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if config.DB.Where("email = ?", req.Email).First(&user).Error == nil {
+		if err := sendPasswordResetEmail(&user); err != nil {
+			log.Printf("❌ Failed to send the password reset email: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "If the account exists, a password reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary      Reset Password
+// @Description  Set a new password with the token of a password reset link. This also lifts a login lockout and verifies the email, since the link was received there
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body schemas.ResetPasswordRequest true "Reset token and new password"
+// @Success      200 {object} schemas.MessageResponse
+// @Failure      400 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /auth/reset-password [post]
+func ResetPassword(c *gin.Context) {
+	var req schemas.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := parseLinkToken(purposeResetPassword, req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"password":      string(hashedPassword),
+		"failed_logins": 0,
+		"locked_until":  nil,
+	}
+	if !user.IsEmailVerified() {
+		updates["email_verified_at"] = time.Now()
+	}
+	if err := config.DB.Model(user).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "Password reset, you can log in with your new password"})
+}
+
+// recordFailedLogin counts a failed login and answers it: 429 once the user is
+// locked out, 401 otherwise
+func recordFailedLogin(c *gin.Context, user *models.User) {
+	lockedUntil, err := utils.RecordFailedLogin(user)
+	if err != nil {
+		log.Printf("❌ Failed to record a failed login for %s: %v", user.Email, err)
+	}
+	if lockedUntil != nil {
+		respondLockedOut(c, *lockedUntil)
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"regexp"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	utils_mailer "ripper-backend/utils/mailer"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mailbox is a mailer keeping what it sends, by recipient
+type mailbox struct {
+	mu   sync.Mutex
+	sent map[string][]string // subject and body of the emails to each address
+}
+
+func (m *mailbox) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent[to] = append(m.sent[to], subject+"\n"+body)
+	return nil
+}
+
+// count returns how many emails were sent to an address
+func (m *mailbox) count(to string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent[to])
+}
+
+var linkTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// linkToken returns the token of the link in the last email sent to an address
+func (m *mailbox) linkToken(t *testing.T, to string) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.sent[to]) == 0 {
+		t.Fatalf("no email sent to %s", to)
+	}
+	match := linkTokenPattern.FindStringSubmatch(m.sent[to][len(m.sent[to])-1])
+	if match == nil {
+		t.Fatalf("no link in the email to %s", to)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatalf("invalid link token %q: %v", match[1], err)
+	}
+	return token
+}
+
+// testMailbox sends emails to a mailbox for the rest of the test
+func testMailbox(t *testing.T) *mailbox {
+	m := &mailbox{sent: map[string][]string{}}
+	utils_mailer.Use(m)
+	t.Cleanup(func() { utils_mailer.Use(utils_mailer.LogMailer{}) })
+	return m
+}
+
+// loadUser reloads a user from the database
+func loadUser(t *testing.T, id string) models.User {
+	t.Helper()
+	var user models.User
+	if err := config.DB.Where("id = ?", id).First(&user).Error; err != nil {
+		t.Fatalf("failed to load user %s: %v", id, err)
+	}
+	return user
+}
+
+func TestSignup(t *testing.T) {
+	existing := testUser(t)
+	mail := testMailbox(t)
+	email := "new-" + existing.ID + "@example.com"
+
+	runAPITests(t, http.MethodPost, "/auth/signup", Signup, []apiTest{
+		{
+			name: "short password", path: "/auth/signup",
+			body: map[string]string{"name": "New", "email": email, "password": "12345"}, status: http.StatusBadRequest,
+		},
+		{
+			name: "new account", path: "/auth/signup",
+			body: map[string]string{"name": "New", "email": email, "password": testPassword}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var user models.User
+				if err := config.DB.Where("email = ?", email).First(&user).Error; err != nil {
+					t.Fatalf("user not created: %v", err)
+				}
+				if user.IsEmailVerified() || user.Role != models.RoleMember {
+					t.Errorf("new user is verified %v with role %s, want unverified member", user.IsEmailVerified(), user.Role)
+				}
+				if mail.count(email) != 1 {
+					t.Errorf("%d emails sent, want the verification email", mail.count(email))
+				}
+			},
+		},
+		{
+			name: "email taken", path: "/auth/signup",
+			body: map[string]string{"name": "Again", "email": existing.Email, "password": testPassword}, status: http.StatusConflict,
+		},
+	})
+}
+
+func TestLogin(t *testing.T) {
+	user := testUser(t)
+	unverified := testUser(t, func(u *models.User) { u.EmailVerifiedAt = nil })
+	suspended := testUser(t, func(u *models.User) {
+		now := time.Now()
+		u.SuspendedAt = &now
+	})
+	enrolled := testUser(t)
+	withTwoFactor(t, enrolled)
+	failing := testUser(t, func(u *models.User) { u.FailedLogins = 4 })
+	lockedUntil := time.Now().Add(time.Hour)
+	locked := testUser(t, func(u *models.User) { u.FailedLogins = 7; u.LockedUntil = &lockedUntil })
+	expired := time.Now().Add(-time.Minute)
+	lockExpired := testUser(t, func(u *models.User) { u.FailedLogins = 5; u.LockedUntil = &expired })
+
+	login := func(user *models.User, password string) map[string]string {
+		return map[string]string{"email": user.Email, "password": password}
+	}
+	runAPITests(t, http.MethodPost, "/auth/login", Login, []apiTest{
+		{
+			name: "unknown email", path: "/auth/login",
+			body: map[string]string{"email": "nobody-" + user.ID + "@example.com", "password": testPassword}, status: http.StatusUnauthorized,
+		},
+		{name: "wrong password", path: "/auth/login", body: login(user, "wrong"), status: http.StatusUnauthorized},
+		{name: "unverified email", path: "/auth/login", body: login(unverified, testPassword), status: http.StatusForbidden},
+		{name: "suspended", path: "/auth/login", body: login(suspended, testPassword), status: http.StatusForbidden},
+		{
+			name: "2FA enabled", path: "/auth/login", body: login(enrolled, testPassword), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "two_factor_required", true)
+				if _, issued := res["token"]; issued {
+					t.Errorf("token issued before the second step")
+				}
+				if challenge, _ := res["challenge_token"].(string); challenge == "" {
+					t.Errorf("no challenge token")
+				}
+			},
+		},
+		{name: "fifth failure in a row", path: "/auth/login", body: login(failing, "wrong"), status: http.StatusTooManyRequests},
+		{name: "right password while locked out", path: "/auth/login", body: login(locked, testPassword), status: http.StatusTooManyRequests},
+		{
+			name: "right password after the lockout", path: "/auth/login", body: login(lockExpired, testPassword), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "email", lockExpired.Email)
+				if stored := loadUser(t, lockExpired.ID); stored.FailedLogins != 0 || stored.LockedUntil != nil {
+					t.Errorf("failed logins %d and lockout %v not cleared", stored.FailedLogins, stored.LockedUntil)
+				}
+			},
+		},
+	})
+
+	if stored := loadUser(t, user.ID); stored.FailedLogins != 1 {
+		t.Errorf("failed logins = %d after a wrong password, want 1", stored.FailedLogins)
+	}
+	if stored := loadUser(t, failing.ID); stored.LockedUntil == nil {
+		t.Errorf("user not locked out after the fifth failure")
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	user := testUser(t, func(u *models.User) { u.EmailVerifiedAt = nil })
+	token, _ := signLinkToken(purposeVerifyEmail, user, verifyEmailLinkTTL)
+	resetToken, _ := signLinkToken(purposeResetPassword, user, resetPasswordLinkTTL)
+	expiredToken, _ := signLinkToken(purposeVerifyEmail, user, -time.Minute)
+	// A link stamped like before verification nonces, from the email alone
+	sum := sha256.Sum256([]byte(user.Email))
+	forgedToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose": purposeVerifyEmail,
+		"sub":     user.ID,
+		"stamp":   hex.EncodeToString(sum[:8]),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString(linkSecret(purposeVerifyEmail))
+
+	runAPITests(t, http.MethodPost, "/auth/verify-email", VerifyEmail, []apiTest{
+		{name: "no token", path: "/auth/verify-email", body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "reset link", path: "/auth/verify-email", body: map[string]string{"token": resetToken}, status: http.StatusBadRequest},
+		{name: "expired link", path: "/auth/verify-email", body: map[string]string{"token": expiredToken}, status: http.StatusBadRequest},
+		{name: "stamped with the email", path: "/auth/verify-email", body: map[string]string{"token": forgedToken}, status: http.StatusBadRequest},
+		{
+			name: "verification link", path: "/auth/verify-email", body: map[string]string{"token": token}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				if !loadUser(t, user.ID).IsEmailVerified() {
+					t.Errorf("email not verified")
+				}
+			},
+		},
+		{name: "followed again", path: "/auth/verify-email", body: map[string]string{"token": token}, status: http.StatusOK},
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	unverified := testUser(t, func(u *models.User) { u.EmailVerifiedAt = nil })
+	verified := testUser(t)
+	mail := testMailbox(t)
+
+	runAPITests(t, http.MethodPost, "/auth/resend-verification", ResendVerification, []apiTest{
+		{name: "not an email", path: "/auth/resend-verification", body: map[string]string{"email": "nope"}, status: http.StatusBadRequest},
+		{name: "unknown account", path: "/auth/resend-verification", body: map[string]string{"email": "nobody-" + verified.ID + "@example.com"}, status: http.StatusOK},
+		{name: "verified account", path: "/auth/resend-verification", body: map[string]string{"email": verified.Email}, status: http.StatusOK},
+		{name: "unverified account", path: "/auth/resend-verification", body: map[string]string{"email": unverified.Email}, status: http.StatusOK},
+	})
+
+	if mail.count(verified.Email) != 0 || mail.count(unverified.Email) != 1 {
+		t.Errorf("sent %d emails to the verified and %d to the unverified account, want 0 and 1",
+			mail.count(verified.Email), mail.count(unverified.Email))
+	}
+	token := mail.linkToken(t, unverified.Email)
+	if linked, err := parseLinkToken(purposeVerifyEmail, token); err != nil || linked.ID != unverified.ID {
+		t.Errorf("emailed link is not a verification link of the user: %v", err)
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	user := testUser(t)
+	mail := testMailbox(t)
+
+	runAPITests(t, http.MethodPost, "/auth/forgot-password", ForgotPassword, []apiTest{
+		{name: "unknown account", path: "/auth/forgot-password", body: map[string]string{"email": "nobody-" + user.ID + "@example.com"}, status: http.StatusOK},
+		{name: "account", path: "/auth/forgot-password", body: map[string]string{"email": user.Email}, status: http.StatusOK},
+	})
+
+	token := mail.linkToken(t, user.Email)
+	if linked, err := parseLinkToken(purposeResetPassword, token); err != nil || linked.ID != user.ID {
+		t.Errorf("emailed link is not a reset link of the user: %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Hour)
+	user := testUser(t, func(u *models.User) {
+		u.EmailVerifiedAt = nil
+		u.FailedLogins = 6
+		u.LockedUntil = &lockedUntil
+	})
+	token, _ := signLinkToken(purposeResetPassword, user, resetPasswordLinkTTL)
+	verifyToken, _ := signLinkToken(purposeVerifyEmail, user, verifyEmailLinkTTL)
+
+	reset := func(token, password string) map[string]string {
+		return map[string]string{"token": token, "new_password": password}
+	}
+	runAPITests(t, http.MethodPost, "/auth/reset-password", ResetPassword, []apiTest{
+		{name: "short password", path: "/auth/reset-password", body: reset(token, "12345"), status: http.StatusBadRequest},
+		{name: "verification link", path: "/auth/reset-password", body: reset(verifyToken, "new-password"), status: http.StatusBadRequest},
+		{
+			name: "reset link", path: "/auth/reset-password", body: reset(token, "new-password"), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				stored := loadUser(t, user.ID)
+				if bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("new-password")) != nil {
+					t.Errorf("password not changed")
+				}
+				if stored.IsLocked() || stored.FailedLogins != 0 || !stored.IsEmailVerified() {
+					t.Errorf("locked %v with %d failed logins, verified %v; want unlocked, 0 and verified",
+						stored.IsLocked(), stored.FailedLogins, stored.IsEmailVerified())
+				}
+			},
+		},
+		{
+			name: "reset link reused", path: "/auth/reset-password", body: reset(token, "another-password"), status: http.StatusBadRequest,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "error", "this link has already been used")
+			},
+		},
+	})
+}
@@ -0,0 +1,670 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// isAdminEmail reports whether email is listed in ADMIN_EMAILS (comma
+// separated). Those users get the admin role when they sign up or at startup.
+func isAdminEmail(email string) bool {
+	for _, admin := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// BootstrapAdmins gives the admin role to the existing users listed in ADMIN_EMAILS
+func BootstrapAdmins() {
+	utils.PromoteAdmins(strings.Split(os.Getenv("ADMIN_EMAILS"), ","))
+}
+
+// authenticateAdmin resolves the user of the JWT and requires the admin role.
+// On failure it writes the 401 or 403 response and returns false.
+func authenticateAdmin(c *gin.Context) (*models.User, bool) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	if !user.IsAdmin() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// findUser loads the user of the :id parameter, writing the 404 itself
+func findUser(c *gin.Context) (*models.User, bool) {
+	var user models.User
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// pagination reads the limit and offset query parameters
+func pagination(c *gin.Context, defaultLimit, maxLimit int) (limit, offset int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// ListUsers godoc
+// @Summary      List Users
+// @Description  List and search every user, newest first. q matches the name or email (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q query string false "Part of the name or email"
+// @Param        role query string false "admin or member"
+// @Param        suspended query bool false "Only suspended (true) or active (false) users"
+// @Param        limit query int false "Maximum users (default 50, max 500)"
+// @Param        offset query int false "Users to skip"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users [get]
+func ListUsers(c *gin.Context) {
+	if _, ok := authenticateAdmin(c); !ok {
+		return
+	}
+
+	query := config.DB.Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?", like, like)
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	switch c.Query("suspended") {
+	case "true":
+		query = query.Where("suspended_at IS NOT NULL")
+	case "false":
+		query = query.Where("suspended_at IS NULL")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	limit, offset := pagination(c, 50, 500)
+	var users []models.User
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":  users,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetUser godoc
+// @Summary      Get a User
+// @Description  Get a user with their plan, quota balances and account counts (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id} [get]
+func GetUser(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	quota, err := quotaStatus(*user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plan"})
+		return
+	}
+
+	var twitterAccounts, whatsappAccounts int64
+	config.DB.Model(&models.TwitterAccount{}).Where("user_id = ?", user.ID).Count(&twitterAccounts)
+	config.DB.Model(&models.WhatsAppAccount{}).Where("user_id = ?", user.ID).Count(&whatsappAccounts)
+
+	utils.LogAdminAction(c, admin, "view_user", "user", user.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":              user,
+		"quota":             quota,
+		"twitter_accounts":  twitterAccounts,
+		"whatsapp_accounts": whatsappAccounts,
+	})
+}
+
+// SetUserRole godoc
+// @Summary      Set a User's Role
+// @Description  Make a user an admin or a member. Admins cannot change their own role (requires admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Param        request body schemas.SetUserRoleRequest true "admin or member"
+// @Success      200 {object} models.User
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/role [put]
+func SetUserRole(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	if err := config.DB.Model(user).Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	forgetAPICallers()
+	utils.LogAdminAction(c, admin, "set_role", "user", user.ID, req)
+	log.Printf("👑 %s made user %s %s", admin.Email, user.ID, req.Role)
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser godoc
+// @Summary      Suspend a User
+// @Description  Block a user from logging in and from every endpoint, including with their Twitter tokens. Their pending scheduled messages and active tweet watches are paused (requires admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Param        request body schemas.SuspendUserRequest true "Reason shown to admins"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/suspend [post]
+func SuspendUser(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend yourself"})
+		return
+	}
+
+	if err := utils.SuspendUser(user.ID, req.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+	forgetAPICallers()
+	utils.LogAdminAction(c, admin, "suspend_user", "user", user.ID, req)
+	log.Printf("⛔ %s suspended user %s: %s", admin.Email, user.ID, req.Reason)
+
+	c.JSON(http.StatusOK, gin.H{"message": "User suspended", "user_id": user.ID})
+}
+
+// UnsuspendUser godoc
+// @Summary      Unsuspend a User
+// @Description  Let a suspended user back in. What the suspension paused stays paused until the user resumes it (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/unsuspend [post]
+func UnsuspendUser(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	if err := utils.UnsuspendUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend user"})
+		return
+	}
+	forgetAPICallers()
+	utils.LogAdminAction(c, admin, "unsuspend_user", "user", user.ID, nil)
+	log.Printf("✅ %s unsuspended user %s", admin.Email, user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unsuspended", "user_id": user.ID})
+}
+
+// GetUserAPILogs godoc
+// @Summary      Get a User's API Call Logs
+// @Description  List the API call logs of any user, newest first (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Param        limit query int false "Maximum logs (default 50, max 1000)"
+// @Param        offset query int false "Logs to skip"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/api-logs [get]
+func GetUserAPILogs(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Model(&models.ApiCallLog{}).Where("user_id = ?", user.ID)
+	var total int64
+	query.Count(&total)
+
+	limit, offset := pagination(c, 50, 1000)
+	var logs []models.ApiCallLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch logs"})
+		return
+	}
+	utils.LogAdminAction(c, admin, "view_api_logs", "user", user.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":   logs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetUserMessageLogs godoc
+// @Summary      Get a User's Message Logs
+// @Description  List the WhatsApp message logs of any user, newest first (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Param        status query string false "pending, sent or failed"
+// @Param        batch_id query string false "Only this batch"
+// @Param        limit query int false "Maximum logs (default 100, max 1000)"
+// @Param        offset query int false "Logs to skip"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/message-logs [get]
+func GetUserMessageLogs(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Model(&models.MessageLog{}).Where("user_id = ?", user.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if batchID := c.Query("batch_id"); batchID != "" {
+		query = query.Where("batch_id = ?", batchID)
+	}
+	var total int64
+	query.Count(&total)
+
+	limit, offset := pagination(c, 100, 1000)
+	var logs []models.MessageLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve message logs"})
+		return
+	}
+	utils.LogAdminAction(c, admin, "view_message_logs", "user", user.ID, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":   logs,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// ForceDeleteWhatsAppPod godoc
+// @Summary      Force-delete a WhatsApp Pod
+// @Description  Delete the Kubernetes pod of any WhatsApp session, whoever owns it. The session's account, if any, is kept and marked disconnected so its owner can create a new one (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        session_id path string true "WhatsApp session ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Failure      503 {object} map[string]string
+// @Router       /admin/whatsapp/pods/{session_id} [delete]
+func ForceDeleteWhatsAppPod(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	if k8sManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "K8s manager not available"})
+		return
+	}
+
+	if err := k8sManager.DeleteWhatsAppPod(sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete pod",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	config.DB.Model(&models.WhatsAppAccount{}).Where("session_id = ?", sessionID).Update("status", "disconnected")
+	utils.LogAdminAction(c, admin, "delete_whatsapp_pod", "whatsapp_pod", sessionID, nil)
+	log.Printf("🗑️ %s force-deleted the WhatsApp pod of session %s", admin.Email, sessionID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "WhatsApp pod deleted", "session_id": sessionID})
+}
+
+// GetAdminAuditLog godoc
+// @Summary      Get the Admin Audit Trail
+// @Description  List the actions taken by admins, newest first (requires admin)
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        admin_id query string false "Only actions of this admin"
+// @Param        action query string false "Only this action, e.g. suspend_user"
+// @Param        target_id query string false "Only actions on this user, plan or session"
+// @Param        limit query int false "Maximum entries (default 100, max 1000)"
+// @Param        offset query int false "Entries to skip"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/audit [get]
+func GetAdminAuditLog(c *gin.Context) {
+	if _, ok := authenticateAdmin(c); !ok {
+		return
+	}
+
+	query := config.DB.Model(&models.AdminAuditLog{})
+	if adminID := c.Query("admin_id"); adminID != "" {
+		query = query.Where("admin_id = ?", adminID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if targetID := c.Query("target_id"); targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+	var total int64
+	query.Count(&total)
+
+	limit, offset := pagination(c, 100, 1000)
+	var entries []models.AdminAuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit trail"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// SavePlan godoc
+// @Summary      Create or Update a Plan
+// @Description  Create a plan, or update the allowances, period and rate limit of the plan with the same name. Users on the plan get the new allowances at their next refill and the new rate limit within a minute (requires admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.PlanRequest true "Plan name, period and allowances"
+// @Success      200 {object} models.Plan
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/plans [post]
+func SavePlan(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Period == "" {
+		req.Period = models.PlanPeriodMonthly
+	}
+
+	plan := models.Plan{
+		Name:              req.Name,
+		Period:            req.Period,
+		TwitterReads:      req.TwitterReads,
+		TwitterWrites:     req.TwitterWrites,
+		WhatsAppMessages:  req.WhatsAppMessages,
+		RequestsPerMinute: req.RequestsPerMinute,
+		Burst:             req.Burst,
+	}
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"period", "twitter_reads", "twitter_writes", "whatsapp_messages", "requests_per_minute", "burst", "updated_at"}),
+	}).Create(&plan).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save plan"})
+		return
+	}
+
+	config.DB.Where("name = ?", plan.Name).First(&plan)
+	utils.LogAdminAction(c, admin, "save_plan", "plan", plan.ID, req)
+	log.Printf("📋 %s saved plan %s", admin.Email, plan.Name)
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// SetUserPlan godoc
+// @Summary      Assign a Plan
+// @Description  Move a user to a plan. Their balances are refilled to the plan's allowances right away and the next refill is one period later (requires admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Param        request body schemas.SetUserPlanRequest true "Plan name"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/plan [put]
+func SetUserPlan(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.SetUserPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var plan models.Plan
+	if err := config.DB.Where("name = ?", req.Plan).First(&plan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+		return
+	}
+
+	if err := config.DB.Model(user).Update("plan_id", plan.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign plan"})
+		return
+	}
+	if err := utils.ResetUserQuotas(user.ID, &plan, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refill balances"})
+		return
+	}
+	forgetAPICallers()
+	utils.LogAdminAction(c, admin, "set_plan", "user", user.ID, req)
+	log.Printf("📋 %s moved user %s to plan %s", admin.Email, user.ID, plan.Name)
+
+	adminQuotaResponse(c, user.ID)
+}
+
+// TopUpQuota godoc
+// @Summary      Adjust a Quota
+// @Description  Credit a user's balance of one quota type, or debit it with a negative amount without going below zero. The change is recorded in the usage ledger with the reason and the admin who made it. Credits are kept when the plan refills (requires admin)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "User ID"
+// @Param        request body schemas.QuotaTopUpRequest true "Quota type, amount and reason"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /admin/users/{id}/topup [post]
+func TopUpQuota(c *gin.Context) {
+	admin, ok := authenticateAdmin(c)
+	if !ok {
+		return
+	}
+
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.QuotaTopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kind := models.LedgerTopUp
+	if req.Amount < 0 {
+		kind = models.LedgerAdjust
+	}
+	reason := fmt.Sprintf("%s (by %s)", req.Reason, admin.Email)
+	if err := utils.CreditQuota(user.ID, req.QuotaType, req.Amount, kind, reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust quota"})
+		return
+	}
+	utils.LogAdminAction(c, admin, "adjust_quota", "user", user.ID, req)
+	log.Printf("➕ %s adjusted %s of user %s by %d", admin.Email, req.QuotaType, user.ID, req.Amount)
+
+	adminQuotaResponse(c, user.ID)
+}
+
+// adminQuotaResponse responds with the current quota status of a user
+func adminQuotaResponse(c *gin.Context, userID string) {
+	var user models.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	status, err := quotaStatus(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plan"})
+		return
+	}
+	status["user_id"] = user.ID
+
+	c.JSON(http.StatusOK, status)
+}
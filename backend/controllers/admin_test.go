@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// testPlan creates a monthly plan with a unique name and the same allowance of every quota type
+func testPlan(t *testing.T, allowance int) *models.Plan {
+	t.Helper()
+	testDB(t)
+
+	plan := &models.Plan{
+		Name:             "test-" + uuid.NewString()[:8],
+		Period:           models.PlanPeriodMonthly,
+		TwitterReads:     allowance,
+		TwitterWrites:    allowance,
+		WhatsAppMessages: allowance,
+	}
+	if err := config.DB.Create(plan).Error; err != nil {
+		t.Fatalf("failed to create the test plan: %v", err)
+	}
+	return plan
+}
+
+// wantAudited fails the test unless the admin's last action is action on targetID
+func wantAudited(t *testing.T, admin *models.User, action, targetID string) {
+	t.Helper()
+
+	var entry models.AdminAuditLog
+	if err := config.DB.Where("admin_id = ?", admin.ID).Order("created_at DESC").First(&entry).Error; err != nil {
+		t.Fatalf("no admin action recorded: %v", err)
+	}
+	if entry.Action != action || entry.TargetID != targetID {
+		t.Errorf("recorded %s on %s, want %s on %s", entry.Action, entry.TargetID, action, targetID)
+	}
+}
+
+// wantBalance fails the test unless the user's balance of a quota type in a quota response is balance
+func wantBalance(t *testing.T, res map[string]interface{}, quotaType string, balance int) {
+	t.Helper()
+
+	balances, _ := res["balances"].([]interface{})
+	for _, b := range balances {
+		if b := b.(map[string]interface{}); b["quota_type"] == quotaType {
+			wantField(t, b, "balance", balance)
+			return
+		}
+	}
+	t.Errorf("no %s balance in %v", quotaType, res["balances"])
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	member := userToken(t, testUser(t))
+
+	runAPITests(t, http.MethodGet, "/admin/users", ListUsers, []apiTest{
+		{name: "no token", path: "/admin/users", status: http.StatusUnauthorized},
+		{name: "member", path: "/admin/users", token: member, status: http.StatusForbidden},
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	token := userToken(t, testUser(t, asAdmin))
+	user := testUser(t)
+	suspended := testUser(t, func(u *models.User) {
+		now := time.Now()
+		u.SuspendedAt = &now
+	})
+
+	runAPITests(t, http.MethodGet, "/admin/users", ListUsers, []apiTest{
+		{
+			name: "search by email", path: "/admin/users?q=" + user.Email, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "total", 1)
+				wantField(t, res["users"].([]interface{})[0].(map[string]interface{}), "id", user.ID)
+			},
+		},
+		{
+			name: "search suspended users", path: "/admin/users?suspended=true&q=" + suspended.Email, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "total", 1) },
+		},
+		{
+			name: "search active users", path: "/admin/users?suspended=false&q=" + suspended.Email, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "total", 0) },
+		},
+		{
+			name: "limit above the maximum", path: "/admin/users?limit=5000&offset=-1", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "limit", 500)
+				wantField(t, res, "offset", 0)
+			},
+		},
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+	testTwitterAccount(t, user)
+
+	runAPITests(t, http.MethodGet, "/admin/users/:id", GetUser, []apiTest{
+		{name: "unknown user", path: "/admin/users/" + uuid.NewString(), token: token, status: http.StatusNotFound},
+		{
+			name: "user on the default plan", path: "/admin/users/" + user.ID, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["user"].(map[string]interface{}), "email", user.Email)
+				wantField(t, res, "twitter_accounts", 1)
+				quota := res["quota"].(map[string]interface{})
+				wantField(t, quota["plan"].(map[string]interface{}), "name", models.DefaultPlanName)
+				wantBalance(t, quota, models.QuotaTwitterReads, 100)
+				wantAudited(t, admin, "view_user", user.ID)
+			},
+		},
+	})
+}
+
+func TestSetUserRole(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+	path := "/admin/users/" + user.ID + "/role"
+
+	runAPITests(t, http.MethodPut, "/admin/users/:id/role", SetUserRole, []apiTest{
+		{name: "unknown role", path: path, token: token, body: map[string]string{"role": "owner"}, status: http.StatusBadRequest},
+		{name: "own role", path: "/admin/users/" + admin.ID + "/role", token: token, body: map[string]string{"role": models.RoleMember}, status: http.StatusBadRequest},
+		{
+			name: "promote", path: path, token: token, body: map[string]string{"role": models.RoleAdmin}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				if !loadUser(t, user.ID).IsAdmin() {
+					t.Errorf("user is not an admin")
+				}
+				wantAudited(t, admin, "set_role", user.ID)
+			},
+		},
+	})
+}
+
+func TestSuspendUser(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+	userJWT := userToken(t, user)
+	watch := &models.TweetWatch{
+		UserID: user.ID, TwitterAccountID: testTwitterAccount(t, user).ID, TweetID: "1", IntervalMinutes: 15,
+		EndsAt: time.Now().Add(time.Hour), NextRunAt: time.Now().Add(time.Hour), Status: "active",
+	}
+	if err := config.DB.Create(watch).Error; err != nil {
+		t.Fatalf("failed to create the tweet watch: %v", err)
+	}
+	suspend := map[string]string{"reason": "spam"}
+
+	runAPITests(t, http.MethodPost, "/admin/users/:id/suspend", SuspendUser, []apiTest{
+		{name: "no reason", path: "/admin/users/" + user.ID + "/suspend", token: token, body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "themselves", path: "/admin/users/" + admin.ID + "/suspend", token: token, body: suspend, status: http.StatusBadRequest},
+		{name: "unknown user", path: "/admin/users/" + uuid.NewString() + "/suspend", token: token, body: suspend, status: http.StatusNotFound},
+		{
+			name: "user", path: "/admin/users/" + user.ID + "/suspend", token: token, body: suspend, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				if stored := loadUser(t, user.ID); stored.SuspendedAt == nil || stored.SuspendedReason != "spam" {
+					t.Errorf("user suspended at %v for %q, want suspended for spam", stored.SuspendedAt, stored.SuspendedReason)
+				}
+				var stored models.TweetWatch
+				config.DB.Where("id = ?", watch.ID).First(&stored)
+				if stored.Status != "paused" {
+					t.Errorf("tweet watch is %s, want paused", stored.Status)
+				}
+				wantAudited(t, admin, "suspend_user", user.ID)
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodGet, "/profile", GetProfile, []apiTest{
+		{name: "suspended user", path: "/profile", token: userJWT, status: http.StatusForbidden},
+	})
+
+	runAPITests(t, http.MethodPost, "/admin/users/:id/unsuspend", UnsuspendUser, []apiTest{
+		{
+			name: "unsuspend", path: "/admin/users/" + user.ID + "/unsuspend", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				if stored := loadUser(t, user.ID); stored.SuspendedAt != nil {
+					t.Errorf("user is still suspended")
+				}
+				wantAudited(t, admin, "unsuspend_user", user.ID)
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodGet, "/profile", GetProfile, []apiTest{
+		{name: "unsuspended user", path: "/profile", token: userJWT, status: http.StatusOK},
+	})
+}
+
+func TestGetUserLogs(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+	config.DB.Create(&models.ApiCallLog{UserID: user.ID, TwitterUsername: "someone", Endpoint: "/twitter/user", Method: "GET"})
+	for _, status := range []string{"sent", "sent", "failed"} {
+		config.DB.Create(&models.MessageLog{UserID: user.ID, SessionID: "session", RecipientPhone: "+100000000", Message: "hi", Status: status})
+	}
+
+	runAPITests(t, http.MethodGet, "/admin/users/:id/api-logs", GetUserAPILogs, []apiTest{
+		{name: "unknown user", path: "/admin/users/" + uuid.NewString() + "/api-logs", token: token, status: http.StatusNotFound},
+		{
+			name: "API call logs", path: "/admin/users/" + user.ID + "/api-logs", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "total", 1)
+				wantAudited(t, admin, "view_api_logs", user.ID)
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodGet, "/admin/users/:id/message-logs", GetUserMessageLogs, []apiTest{
+		{
+			name: "message logs", path: "/admin/users/" + user.ID + "/message-logs", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "total", 3) },
+		},
+		{
+			name: "failed messages", path: "/admin/users/" + user.ID + "/message-logs?status=failed", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "total", 1)
+				wantAudited(t, admin, "view_message_logs", user.ID)
+			},
+		},
+	})
+}
+
+func TestGetAdminAuditLog(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+
+	runAPITests(t, http.MethodGet, "/admin/users/:id", GetUser, []apiTest{
+		{name: "viewed user", path: "/admin/users/" + user.ID, token: token, status: http.StatusOK},
+	})
+	runAPITests(t, http.MethodGet, "/admin/audit", GetAdminAuditLog, []apiTest{
+		{name: "member", path: "/admin/audit", token: userToken(t, user), status: http.StatusForbidden},
+		{
+			name: "actions of the admin", path: "/admin/audit?admin_id=" + admin.ID, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "total", 1)
+				entry := res["entries"].([]interface{})[0].(map[string]interface{})
+				wantField(t, entry, "action", "view_user")
+				wantField(t, entry, "target_id", user.ID)
+				wantField(t, entry, "admin_email", admin.Email)
+			},
+		},
+		{
+			name: "other action", path: "/admin/audit?admin_id=" + admin.ID + "&action=suspend_user", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "total", 0) },
+		},
+	})
+}
+
+func TestSavePlan(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	name := "test-" + uuid.NewString()[:8]
+
+	runAPITests(t, http.MethodPost, "/admin/plans", SavePlan, []apiTest{
+		{name: "no name", path: "/admin/plans", token: token, body: map[string]interface{}{"twitter_reads": 10}, status: http.StatusBadRequest},
+		{name: "unknown period", path: "/admin/plans", token: token, body: map[string]interface{}{"name": name, "period": "weekly"}, status: http.StatusBadRequest},
+		{name: "negative allowance", path: "/admin/plans", token: token, body: map[string]interface{}{"name": name, "twitter_reads": -1}, status: http.StatusBadRequest},
+		{
+			name: "new plan", path: "/admin/plans", token: token,
+			body: map[string]interface{}{"name": name, "twitter_reads": 1000, "twitter_writes": 50}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "period", models.PlanPeriodMonthly)
+				wantField(t, res, "twitter_reads", 1000)
+				wantAudited(t, admin, "save_plan", res["id"].(string))
+			},
+		},
+		{
+			name: "same name updates it", path: "/admin/plans", token: token,
+			body: map[string]interface{}{"name": name, "period": "daily", "twitter_reads": 2000}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "period", models.PlanPeriodDaily)
+				wantField(t, res, "twitter_reads", 2000)
+				var count int64
+				config.DB.Model(&models.Plan{}).Where("name = ?", name).Count(&count)
+				if count != 1 {
+					t.Errorf("%d plans named %s, want 1", count, name)
+				}
+			},
+		},
+	})
+}
+
+func TestSetUserPlan(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+	plan := testPlan(t, 500)
+	path := "/admin/users/" + user.ID + "/plan"
+
+	runAPITests(t, http.MethodPut, "/admin/users/:id/plan", SetUserPlan, []apiTest{
+		{name: "no plan", path: path, token: token, body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "unknown plan", path: path, token: token, body: map[string]string{"plan": "no-such-plan-" + user.ID}, status: http.StatusNotFound},
+		{
+			name: "plan", path: path, token: token, body: map[string]string{"plan": plan.Name}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "user_id", user.ID)
+				wantField(t, res["plan"].(map[string]interface{}), "name", plan.Name)
+				wantBalance(t, res, models.QuotaTwitterWrites, 500)
+				if stored := loadUser(t, user.ID); stored.QuotaResetAt == nil || stored.QuotaResetAt.Before(time.Now().AddDate(0, 0, 27)) {
+					t.Errorf("next refill at %v, want in a month", stored.QuotaResetAt)
+				}
+				wantAudited(t, admin, "set_plan", user.ID)
+			},
+		},
+	})
+}
+
+func TestTopUpQuota(t *testing.T) {
+	admin := testUser(t, asAdmin)
+	token := userToken(t, admin)
+	user := testUser(t)
+	path := "/admin/users/" + user.ID + "/topup"
+	topUp := func(amount int) map[string]interface{} {
+		return map[string]interface{}{"quota_type": models.QuotaWhatsApp, "amount": amount, "reason": "support ticket"}
+	}
+
+	runAPITests(t, http.MethodPost, "/admin/users/:id/topup", TopUpQuota, []apiTest{
+		{
+			name: "unknown quota type", path: path, token: token,
+			body: map[string]interface{}{"quota_type": "emails", "amount": 10, "reason": "support ticket"}, status: http.StatusBadRequest,
+		},
+		{name: "no amount", path: path, token: token, body: topUp(0), status: http.StatusBadRequest},
+		{name: "unknown user", path: "/admin/users/" + uuid.NewString() + "/topup", token: token, body: topUp(10), status: http.StatusNotFound},
+		{
+			name: "credit", path: path, token: token, body: topUp(50), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantBalance(t, res, models.QuotaWhatsApp, 150)
+				wantAudited(t, admin, "adjust_quota", user.ID)
+			},
+		},
+		{
+			name: "debit below zero", path: path, token: token, body: topUp(-500), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantBalance(t, res, models.QuotaWhatsApp, 0) },
+		},
+	})
+
+	var entries []models.UsageLedgerEntry
+	config.DB.Where("user_id = ?", user.ID).Order("created_at").Find(&entries)
+	if len(entries) != 2 || entries[0].Kind != models.LedgerTopUp || entries[1].Kind != models.LedgerAdjust {
+		t.Fatalf("ledger has %+v, want a topup then an adjust", entries)
+	}
+	if want := "support ticket (by " + admin.Email + ")"; entries[0].Reason != want {
+		t.Errorf("ledger reason = %q, want %q", entries[0].Reason, want)
+	}
+}
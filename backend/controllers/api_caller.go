@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_ratelimit "ripper-backend/utils/ratelimit"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// apiCallerTTL is how long the caller resolved from a token is reused, so a
+// plan change applies within this time
+const apiCallerTTL = time.Minute
+
+// apiKeyCallerPrefix marks the cache entries of callers using an API key
+const apiKeyCallerPrefix = "apikey:"
+
+// maxAPICallers bounds the resolved callers kept in memory
+const maxAPICallers = 10000
+
+// apiCaller is who sent a request, resolved from its bearer token
+type apiCaller struct {
+	key       string // user:<id>, token:<twitter account id>, or empty for anonymous callers
+	userID    string
+	suspended bool
+	limit     utils_ratelimit.Limit // rate limit of the user's plan
+	expiresAt time.Time
+}
+
+var apiCallers = struct {
+	sync.Mutex
+	m map[string]apiCaller
+}{m: make(map[string]apiCaller)}
+
+// RejectSuspended refuses every request made with the JWT or Twitter token of
+// a suspended user
+func RejectSuspended() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if caller := resolveAPICaller(c); caller.suspended {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// resolveAPICaller returns the caller of the bearer token, cached for
+// apiCallerTTL. Requests made with an API key are cached by key, since the
+// JWT they carry changes every request.
+func resolveAPICaller(c *gin.Context) apiCaller {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if apiKey, ok := requestApiKey(c); ok {
+		token = apiKeyCallerPrefix + apiKey.ID
+	}
+
+	apiCallers.Lock()
+	caller, ok := apiCallers.m[token]
+	apiCallers.Unlock()
+	if ok && time.Now().Before(caller.expiresAt) {
+		return caller
+	}
+
+	caller = lookupAPICaller(token)
+	caller.expiresAt = time.Now().Add(apiCallerTTL)
+
+	apiCallers.Lock()
+	if len(apiCallers.m) >= maxAPICallers {
+		apiCallers.m = make(map[string]apiCaller)
+	}
+	apiCallers.m[token] = caller
+	apiCallers.Unlock()
+
+	return caller
+}
+
+// forgetAPICallers drops the resolved callers, so a suspension or role change
+// applies to the next request
+func forgetAPICallers() {
+	apiCallers.Lock()
+	apiCallers.m = make(map[string]apiCaller)
+	apiCallers.Unlock()
+}
+
+// lookupAPICaller finds the user of a JWT, Twitter token or API key and their
+// plan's limit. Unknown tokens get the default plan's limit.
+func lookupAPICaller(token string) apiCaller {
+	var user models.User
+	var caller apiCaller
+
+	if token != "" {
+		if strings.HasPrefix(token, apiKeyCallerPrefix) {
+			var apiKey models.ApiKey
+			if config.DB.Preload("User").Where("id = ?", strings.TrimPrefix(token, apiKeyCallerPrefix)).First(&apiKey).Error == nil {
+				user = apiKey.User
+				caller.key = "user:" + user.ID
+			}
+		} else if email, ok := jwtEmail(token); ok {
+			if config.DB.Where("email = ?", email).First(&user).Error == nil {
+				caller.key = "user:" + user.ID
+			}
+		} else {
+			var account models.TwitterAccount
+			if config.DB.Preload("User").Where("token = ?", token).First(&account).Error == nil {
+				user = account.User
+				caller.key = "token:" + account.ID
+			}
+		}
+	}
+
+	caller.userID = user.ID
+	caller.suspended = user.IsSuspended()
+
+	plan, err := utils.UserPlan(user)
+	if err != nil {
+		log.Printf("⚠️ Failed to load the plan for rate limiting: %v", err)
+		return caller
+	}
+	caller.limit = utils_ratelimit.Limit{PerMinute: plan.RequestsPerMinute, Burst: plan.Burst}
+
+	return caller
+}
+
+// jwtEmail returns the email claim of a valid JWT
+func jwtEmail(tokenString string) (string, bool) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return config.JWTSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return "", false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", false
+	}
+	email, ok := claims["email"].(string)
+	return email, ok
+}
@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// apiKeyContextKey holds the *models.ApiKey of a request made with an API key
+const apiKeyContextKey = "api_key"
+
+// apiKeyJWTLifetime is how long the JWT a request's API key is swapped for is valid
+const apiKeyJWTLifetime = 5 * time.Minute
+
+// AuthenticateApiKeys lets every route accept a personal API key in place of a
+// JWT. A request with a key is checked against the key's scope for its route,
+// then continues with a short-lived JWT of the key's user, so handlers
+// authenticate it like any other request.
+func AuthenticateApiKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !utils.IsApiKey(key) {
+			c.Next()
+			return
+		}
+
+		apiKey, err := utils.FindApiKey(key)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or revoked API key"})
+			return
+		}
+		if apiKey.User.IsSuspended() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+			return
+		}
+		if apiKeyRefused(c.Request.Method, c.Request.URL.Path) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": apiKeyRefusedMsg})
+			return
+		}
+		if scope := apiKeyScope(c.Request.URL.Path); !apiKey.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API key is missing the %s scope", scope)})
+			return
+		}
+
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"email": apiKey.User.Email,
+			"exp":   time.Now().Add(apiKeyJWTLifetime).Unix(),
+		}).SignedString(config.JWTSecret)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate API key"})
+			return
+		}
+
+		utils.TouchApiKey(apiKey.ID)
+		c.Set(apiKeyContextKey, apiKey)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		c.Next()
+	}
+}
+
+// apiKeyScope returns the scope an API key needs for a route
+func apiKeyScope(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/twitter"):
+		return models.ApiKeyScopeTwitter
+	case strings.HasPrefix(path, "/whatsapp"):
+		return models.ApiKeyScopeWhatsApp
+	default:
+		return models.ApiKeyScopeAccount
+	}
+}
+
+// apiKeyRefused reports whether a route never takes an API key, whatever its
+// scopes: a leaked key must not reach admin endpoints, nor change who belongs to
+// an organization or whether it requires 2FA. Organizations can still be read.
+func apiKeyRefused(method, path string) bool {
+	switch {
+	case path == "/admin" || strings.HasPrefix(path, "/admin/"):
+		return true
+	case path == "/orgs" || strings.HasPrefix(path, "/orgs/"):
+		return method != http.MethodGet
+	default:
+		return false
+	}
+}
+
+// requestApiKey returns the API key a request was made with, if any
+func requestApiKey(c *gin.Context) (*models.ApiKey, bool) {
+	value, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	apiKey, ok := value.(*models.ApiKey)
+	return apiKey, ok
+}
+
+// apiKeyTwitterAccount picks the Twitter account a request made with an API
+// key runs as: the one named in X-Twitter-Account, or else the user's first
+// active account by username. Any account the user can operate, including shared ones,
+// can be named. The key's twitter scope grants every token scope.
+func apiKeyTwitterAccount(c *gin.Context, apiKey *models.ApiKey) (*models.TwitterAccount, error) {
+	query := config.DB.Scopes(utils.Accessible(apiKey.UserID, models.OrgRoleOperator))
+	if username := strings.TrimPrefix(c.GetHeader("X-Twitter-Account"), "@"); username != "" {
+		query = query.Where("username = ?", username)
+	}
+
+	var twitterAccount models.TwitterAccount
+	if err := query.Order(fmt.Sprintf("status = '%s' DESC", models.TwitterAccountActive)).Order("username").First(&twitterAccount).Error; err != nil {
+		return nil, fmt.Errorf("no Twitter account found for API key")
+	}
+
+	twitterAccount.Scopes = strings.Join(models.AllTwitterScopes, ",")
+	return &twitterAccount, nil
+}
+
+// apiKeyRefusedMsg is the error of endpoints that don't take API keys
+const apiKeyRefusedMsg = "This endpoint requires logging in, API keys are not accepted"
+
+// rejectApiKey refuses requests made with an API key on endpoints that a
+// leaked key must not reach, writing the 403 itself
+func rejectApiKey(c *gin.Context) bool {
+	if _, ok := requestApiKey(c); ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": apiKeyRefusedMsg})
+		return true
+	}
+	return false
+}
+
+// apiKeyScopesFromRequest validates the scopes asked for a key and joins them
+// for storage. No scopes means every scope.
+func apiKeyScopesFromRequest(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		scopes = models.AllApiKeyScopes
+	}
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range models.AllApiKeyScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", fmt.Errorf("invalid scope %q (use twitter, whatsapp or account)", scope)
+		}
+	}
+	return strings.Join(scopes, ","), nil
+}
+
+// apiKeyResponse shapes a key for the key endpoints, without its hash
+func apiKeyResponse(apiKey models.ApiKey) gin.H {
+	return gin.H{
+		"id":           apiKey.ID,
+		"name":         apiKey.Name,
+		"prefix":       apiKey.Prefix,
+		"scopes":       strings.Split(apiKey.Scopes, ","),
+		"last_used_at": apiKey.LastUsedAt,
+		"revoked_at":   apiKey.RevokedAt,
+		"created_at":   apiKey.CreatedAt,
+	}
+}
+
+// CreateApiKey godoc
+// @Summary      Create an API Key
+// @Description  Create a named personal API key, optionally limited to some of the twitter, whatsapp and account scopes. The key is returned only once. Send it as "Bearer <key>" on any route in place of a JWT (requires JWT authentication)
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.CreateApiKeyRequest true "Key name and scopes"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api-keys [post]
+func CreateApiKey(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.CreateApiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scopes, err := apiKeyScopesFromRequest(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, prefix, hash, err := utils.GenerateApiKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	apiKey := models.ApiKey{
+		UserID:  user.ID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: hash,
+		Scopes:  scopes,
+	}
+	if err := config.DB.Create(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	response := apiKeyResponse(apiKey)
+	response["key"] = key
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListApiKeys godoc
+// @Summary      List API Keys
+// @Description  List your API keys, including revoked ones, with their scopes and when they were last used. The keys themselves are never returned again (requires JWT authentication)
+// @Tags         api-keys
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api-keys [get]
+func ListApiKeys(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var apiKeys []models.ApiKey
+	if err := config.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	response := make([]gin.H, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		response[i] = apiKeyResponse(apiKey)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": response, "count": len(apiKeys)})
+}
+
+// RevokeApiKey godoc
+// @Summary      Revoke an API Key
+// @Description  Revoke one of your API keys. It stops working right away (requires JWT authentication)
+// @Tags         api-keys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "API key ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /api-keys/{id} [delete]
+func RevokeApiKey(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var apiKey models.ApiKey
+	if err := config.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.Param("id"), user.ID).First(&apiKey).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	if err := config.DB.Model(&apiKey).Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	forgetAPICallers()
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testApiKey creates an API key of the user with the scopes, every scope when
+// none, and returns the key
+func testApiKey(t *testing.T, user *models.User, scopes ...string) (string, *models.ApiKey) {
+	t.Helper()
+
+	if len(scopes) == 0 {
+		scopes = models.AllApiKeyScopes
+	}
+	key, prefix, hash, err := utils.GenerateApiKey()
+	if err != nil {
+		t.Fatalf("failed to generate the API key: %v", err)
+	}
+	apiKey := &models.ApiKey{UserID: user.ID, Name: "test", Prefix: prefix, KeyHash: hash, Scopes: strings.Join(scopes, ",")}
+	if err := config.DB.Create(apiKey).Error; err != nil {
+		t.Fatalf("failed to create the API key: %v", err)
+	}
+	return key, apiKey
+}
+
+func TestCreateApiKey(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+	key, _ := testApiKey(t, user)
+
+	runAPITests(t, http.MethodPost, "/api-keys", CreateApiKey, []apiTest{
+		{name: "no token", path: "/api-keys", body: map[string]interface{}{"name": "ci"}, status: http.StatusUnauthorized},
+		{name: "with an API key", path: "/api-keys", token: key, body: map[string]interface{}{"name": "ci"}, status: http.StatusForbidden},
+		{name: "no name", path: "/api-keys", token: token, body: map[string]interface{}{}, status: http.StatusBadRequest},
+		{
+			name: "unknown scope", path: "/api-keys", token: token,
+			body:   map[string]interface{}{"name": "ci", "scopes": []string{"twitter", "billing"}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "every scope by default", path: "/api-keys", token: token,
+			body: map[string]interface{}{"name": "ci"}, status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "scopes", models.AllApiKeyScopes)
+				if key, _ := res["key"].(string); !strings.HasPrefix(key, res["prefix"].(string)) || !utils.IsApiKey(key) {
+					t.Errorf("key %q does not start with rk_ and its prefix %v", key, res["prefix"])
+				}
+			},
+		},
+		{
+			name: "some scopes", path: "/api-keys", token: token,
+			body: map[string]interface{}{"name": "reports", "scopes": []string{"account"}}, status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "name", "reports")
+				wantField(t, res, "scopes", []string{"account"})
+			},
+		},
+	})
+
+	var count int64
+	config.DB.Model(&models.ApiKey{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 3 {
+		t.Errorf("user has %d API keys, want 3", count)
+	}
+}
+
+func TestListApiKeys(t *testing.T) {
+	user := testUser(t)
+	testApiKey(t, user)
+	_, revoked := testApiKey(t, user, models.ApiKeyScopeAccount)
+	config.DB.Model(revoked).Update("revoked_at", time.Now())
+	testApiKey(t, testUser(t))
+
+	runAPITests(t, http.MethodGet, "/api-keys", ListApiKeys, []apiTest{
+		{name: "no token", path: "/api-keys", status: http.StatusUnauthorized},
+		{
+			name: "own keys, revoked included", path: "/api-keys", token: userToken(t, user), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				wantCount(t, res, "api_keys", 2)
+				for _, k := range res["api_keys"].([]interface{}) {
+					if _, leaked := k.(map[string]interface{})["key"]; leaked {
+						t.Errorf("listed key %v includes the key", k)
+					}
+				}
+			},
+		},
+	})
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+	key, apiKey := testApiKey(t, user)
+	_, otherKey := testApiKey(t, testUser(t))
+
+	runAPITests(t, http.MethodDelete, "/api-keys/:id", RevokeApiKey, []apiTest{
+		{name: "with the API key", path: "/api-keys/" + apiKey.ID, token: key, status: http.StatusForbidden},
+		{name: "key of another user", path: "/api-keys/" + otherKey.ID, token: token, status: http.StatusNotFound},
+		{name: "own key", path: "/api-keys/" + apiKey.ID, token: token, status: http.StatusOK},
+		{name: "already revoked", path: "/api-keys/" + apiKey.ID, token: token, status: http.StatusNotFound},
+		{name: "revoked key is refused", path: "/api-keys/" + apiKey.ID, token: key, status: http.StatusUnauthorized},
+	})
+}
+
+func TestAuthenticateApiKeys(t *testing.T) {
+	user := testUser(t)
+	testTwitterAccount(t, user)
+	accountKey, _ := testApiKey(t, user, models.ApiKeyScopeAccount)
+	twitterKey, _ := testApiKey(t, user, models.ApiKeyScopeTwitter)
+	suspended := testUser(t, func(u *models.User) {
+		now := time.Now()
+		u.SuspendedAt = &now
+	})
+	suspendedKey, _ := testApiKey(t, suspended)
+
+	runAPITests(t, http.MethodGet, "/profile", GetProfile, []apiTest{
+		{
+			name: "key with the account scope", path: "/profile", token: accountKey, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "email", user.Email)
+				wantField(t, res, "twitter_accounts_count", 1)
+			},
+		},
+		{name: "key without the account scope", path: "/profile", token: twitterKey, status: http.StatusForbidden},
+		{name: "unknown key", path: "/profile", token: utils.ApiKeyPrefix + "0000", status: http.StatusUnauthorized},
+		{name: "key of a suspended user", path: "/profile", token: suspendedKey, status: http.StatusForbidden},
+	})
+
+	runAPITests(t, http.MethodGet, "/twitter/pool", GetTwitterAccountPool, []apiTest{
+		{
+			name: "key with the twitter scope", path: "/twitter/pool", token: twitterKey, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 1) },
+		},
+		{name: "key without the twitter scope", path: "/twitter/pool", token: accountKey, status: http.StatusForbidden},
+	})
+
+	adminKey, _ := testApiKey(t, testUser(t, asAdmin))
+	runAPITests(t, http.MethodGet, "/admin/users", ListUsers, []apiTest{
+		{name: "admin's key on an admin route", path: "/admin/users", token: adminKey, status: http.StatusForbidden},
+	})
+
+	f := newOrgFixture(t)
+	ownerKey, _ := testApiKey(t, f.owner)
+	runAPITests(t, http.MethodGet, "/orgs/:id", GetOrganization, []apiTest{
+		{name: "key reading an organization", path: "/orgs/" + f.organization.ID, token: ownerKey, status: http.StatusOK},
+	})
+	runAPITests(t, http.MethodPut, "/orgs/:id/two-factor", SetOrganizationTwoFactor, []apiTest{
+		{
+			name: "key changing the 2FA requirement", path: "/orgs/" + f.organization.ID + "/two-factor", token: ownerKey,
+			body: map[string]bool{"required": false}, status: http.StatusForbidden,
+		},
+	})
+	runAPITests(t, http.MethodPost, "/orgs/:id/members", AddOrganizationMember, []apiTest{
+		{
+			name: "key adding a member", path: "/orgs/" + f.organization.ID + "/members", token: ownerKey,
+			body: map[string]string{"email": f.outsider.Email, "role": models.OrgRoleOwner}, status: http.StatusForbidden,
+		},
+	})
+}
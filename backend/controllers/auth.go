@@ -1,23 +1,22 @@
 package controllers
 
 import (
+	"log"
 	"net/http"
 	"ripper-backend/config"
 	"ripper-backend/models"
 	"ripper-backend/schemas"
+	"ripper-backend/utils"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSecret = []byte("your-secret-key")
-
 // Signup godoc
 // @Summary      User Signup
-// @Description  Register a new user account
+// @Description  Register a new user account. A verification link is emailed, and the account can log in once it is followed
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -39,6 +38,10 @@ func Signup(c *gin.Context) {
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: string(hashedPassword),
+		Role:     models.RoleMember,
+	}
+	if isAdminEmail(req.Email) {
+		user.Role = models.RoleAdmin
 	}
 
 	if err := config.DB.Create(&user).Error; err != nil {
@@ -46,12 +49,16 @@ func Signup(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "successful"})
+	if err := sendVerificationEmail(&user); err != nil {
+		log.Printf("❌ Failed to send the verification email: %v", err)
+	}
+
+	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "successful, check your email to verify your account"})
 }
 
 // Login godoc
 // @Summary      User Login
-// @Description  Authenticate user and return JWT token
+// @Description  Authenticate user and return JWT token. The email must be verified. After 5 failed logins in a row the account is locked out for a minute, doubling with every further failure up to an hour. With two-factor authentication enabled, the response is instead {"two_factor_required": true, "challenge_token": "...", "expires_in": 300}, to complete at /auth/login/2fa
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -59,6 +66,8 @@ func Signup(c *gin.Context) {
 // @Success      200 {object} schemas.LoginResponse
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      429 {object} map[string]string
 // @Router       /auth/login [post]
 func Login(c *gin.Context) {
 	var req schemas.LoginRequest
@@ -73,22 +82,33 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if user.IsLocked() {
+		respondLockedOut(c, *user.LockedUntil)
+		return
+	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		recordFailedLogin(c, &user)
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email": user.Email,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(),
-	})
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return
+	}
 
-	tokenString, _ := token.SignedString(jwtSecret)
+	if !user.IsEmailVerified() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Email not verified, follow the link emailed to you or request a new one"})
+		return
+	}
 
-	c.JSON(http.StatusOK, schemas.LoginResponse{
-		Token: tokenString,
-		Email: user.Email,
-	})
+	// Failed logins are only cleared once the second step succeeds too
+	if user.IsTwoFactorEnabled() {
+		issueLoginChallenge(c, &user)
+		return
+	}
+
+	issueLoginToken(c, &user)
 }
 
 // Dashboard godoc
@@ -110,7 +130,7 @@ func Dashboard(c *gin.Context) {
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -151,7 +171,7 @@ func GetProfile(c *gin.Context) {
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -169,10 +189,10 @@ func GetProfile(c *gin.Context) {
 	}
 
 	var twitterAccountCount int64
-	config.DB.Model(&models.TwitterAccount{}).Where("user_id = ?", user.ID).Count(&twitterAccountCount)
+	config.DB.Model(&models.TwitterAccount{}).Scopes(utils.Accessible(user.ID, models.OrgRoleViewer)).Count(&twitterAccountCount)
 
 	var whatsappAccountCount int64
-	config.DB.Model(&models.WhatsAppAccount{}).Where("user_id = ?", user.ID).Count(&whatsappAccountCount)
+	config.DB.Model(&models.WhatsAppAccount{}).Scopes(utils.Accessible(user.ID, models.OrgRoleViewer)).Count(&whatsappAccountCount)
 
 	c.JSON(http.StatusOK, gin.H{
 		"email":                   user.Email,
@@ -195,8 +215,13 @@ func GetProfile(c *gin.Context) {
 // @Success      200 {object} schemas.MessageResponse
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
 // @Router       /change-password [post]
 func ChangePassword(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
@@ -205,7 +230,7 @@ func ChangePassword(c *gin.Context) {
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -241,3 +266,44 @@ func ChangePassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "Password changed successfully"})
 }
+
+// authenticateUser resolves the user of the JWT in the Authorization header.
+// On failure it writes the 401 response, or 403 for a suspended user, and
+// returns false.
+func authenticateUser(c *gin.Context) (*models.User, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
+		return nil, false
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return config.JWTSecret, nil
+	})
+
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	email, ok := claims["email"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := config.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return nil, false
+	}
+
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return nil, false
+	}
+
+	return &user, true
+}
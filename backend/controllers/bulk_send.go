@@ -8,10 +8,12 @@ import (
 	"log"
 	"net/http"
 	"time"
+	"unicode/utf8"
 
 	"ripper-backend/config"
 	"ripper-backend/models"
 	"ripper-backend/scheduler"
+	"ripper-backend/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +27,7 @@ func SendBulkMessages(c *gin.Context) {
 			Message      string `json:"message" binding:"required"`
 			DelaySeconds int    `json:"delay_seconds"` // Individual delay for this message
 		} `json:"messages" binding:"required"`
+		Channel string `json:"channel"` // Optional: whatsapp (default) or twitter_dm, whose session_name is a Twitter username
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -46,6 +49,26 @@ func SendBulkMessages(c *gin.Context) {
 		return
 	}
 
+	// DMs are always scheduled so they can be paced per account
+	if req.Channel == models.ChannelTwitterDM {
+		dms := make([]scheduler.ScheduledDM, len(req.Messages))
+		for i, msg := range req.Messages {
+			dms[i] = scheduler.ScheduledDM{Recipient: msg.Recipient, Message: msg.Message, DelaySeconds: msg.DelaySeconds}
+		}
+		scheduleBulkDMs(c, userID, req.SessionName, dms)
+		return
+	} else if req.Channel != "" && req.Channel != models.ChannelWhatsApp {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be whatsapp or twitter_dm"})
+		return
+	}
+
+	// The session must be the user's, or shared with an organization where they can operate it
+	var account models.WhatsAppAccount
+	if err := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleOperator)).Where("session_id = ?", req.SessionName).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "WhatsApp session not found or not owned by user"})
+		return
+	}
+
 	// Check if any message has a delay - if so, use scheduling
 	hasDelays := false
 	for _, msg := range req.Messages {
@@ -98,6 +121,7 @@ func SendBulkMessages(c *gin.Context) {
 			BatchID:        batchID,
 			SequenceNumber: i + 1,
 			DelaySeconds:   0,
+			OrganizationID: account.OrganizationID,
 		}
 
 		// Save log entry
@@ -105,8 +129,8 @@ func SendBulkMessages(c *gin.Context) {
 			log.Printf("⚠️ Failed to create message log: %v", err)
 		}
 
-		// Send message
-		err := sendWhatsAppMessageDirect(req.SessionName, msg.Recipient, msg.Message)
+		// Send message, billed to the WhatsApp balance
+		err := sendBilledWhatsAppMessage(userID, req.SessionName, msg.Recipient, msg.Message)
 
 		result := map[string]interface{}{
 			"recipient": msg.Recipient,
@@ -152,6 +176,44 @@ func SendBulkMessages(c *gin.Context) {
 	})
 }
 
+// scheduleBulkDMs schedules a batch of DMs from one of the user's Twitter accounts
+func scheduleBulkDMs(c *gin.Context, userID, username string, dms []scheduler.ScheduledDM) {
+	if len(dms) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "messages is empty"})
+		return
+	}
+
+	var account models.TwitterAccount
+	if err := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleOperator)).Where("username = ?", username).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
+		return
+	}
+
+	for _, dm := range dms {
+		if utf8.RuneCountInString(dm.Message) > maxDMLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("message to %s is longer than %d characters", dm.Recipient, maxDMLength)})
+			return
+		}
+	}
+
+	batchID, items, err := scheduler.GlobalScheduler.ScheduleDMs(userID, &account, dms)
+	if err != nil {
+		log.Printf("❌ Error scheduling DMs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("✅ DMs scheduled successfully: batch=%s, total=%d", batchID, len(items))
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"batch_id":  batchID,
+		"scheduled": true,
+		"total":     len(items),
+		"last_at":   items[len(items)-1].ScheduledAt,
+		"message":   "DMs scheduled successfully",
+	})
+}
+
 // sendImmediateBulk sends messages immediately without scheduling
 func sendImmediateBulk(c *gin.Context, sessionID string, contacts []scheduler.Contact, message string) {
 	successCount := 0
@@ -202,6 +264,23 @@ func sendImmediateBulk(c *gin.Context, sessionID string, contacts []scheduler.Co
 	})
 }
 
+// sendBilledWhatsAppMessage sends a message with sendWhatsAppMessageDirect after
+// reserving it from the user's WhatsApp balance, which is refunded when the send fails
+func sendBilledWhatsAppMessage(userID, sessionID, phone, message string) error {
+	reservation, err := utils.ReserveWhatsAppMessage(userID, "/whatsapp/send-bulk")
+	if err != nil {
+		return err
+	}
+
+	if err := sendWhatsAppMessageDirect(sessionID, phone, message); err != nil {
+		reservation.Refund()
+		return err
+	}
+
+	reservation.Commit(reservation.Amount)
+	return nil
+}
+
 // sendWhatsAppMessageDirect sends a message directly to WhatsApp microservice
 func sendWhatsAppMessageDirect(sessionID, phone, message string) error {
 	jsonData, _ := json.Marshal(map[string]interface{}{
@@ -248,9 +327,10 @@ func GetScheduledMessages(c *gin.Context) {
 		return
 	}
 
-	status := c.Query("status") // Optional filter by status
+	status := c.Query("status")   // Optional filter by status
+	channel := c.Query("channel") // Optional filter by channel: whatsapp or twitter
 
-	messages, err := scheduler.GlobalScheduler.GetScheduledMessages(userID, status)
+	messages, err := scheduler.GlobalScheduler.GetScheduledMessages(userID, status, channel)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -380,6 +460,7 @@ func ResumePausedMessages(c *gin.Context) {
 		OldSessionID string `json:"old_session_id"`
 		NewSessionID string `json:"new_session_id"` // Optional: if provided, changes session
 		BatchID      string `json:"batch_id"`       // Optional: resume specific batch
+		Channel      string `json:"channel"`        // Optional: whatsapp (default), twitter or twitter_dm, whose sessions are usernames
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -392,9 +473,18 @@ func ResumePausedMessages(c *gin.Context) {
 		return
 	}
 
+	if req.Channel == "" {
+		req.Channel = models.ChannelWhatsApp
+	}
+	if req.Channel != models.ChannelWhatsApp && req.Channel != models.ChannelTwitter && req.Channel != models.ChannelTwitterDM {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be whatsapp, twitter or twitter_dm"})
+		return
+	}
+
 	// Build query
 	query := config.DB.Model(&models.ScheduledMessage{}).
-		Where("user_id = ? AND session_id = ? AND status = ?", userID, req.OldSessionID, "paused")
+		Scopes(utils.Accessible(userID, models.OrgRoleOperator)).
+		Where("session_id = ? AND status = ? AND channel = ?", req.OldSessionID, "paused", req.Channel)
 
 	if req.BatchID != "" {
 		query = query.Where("batch_id = ?", req.BatchID)
@@ -407,10 +497,19 @@ func ResumePausedMessages(c *gin.Context) {
 	}
 
 	// Change session if new session provided
-	if req.NewSessionID != "" && req.NewSessionID != req.OldSessionID {
+	if req.Channel != models.ChannelWhatsApp && req.NewSessionID != "" && req.NewSessionID != req.OldSessionID {
+		// Move the tweets or DMs to another of the user's Twitter accounts
+		var newAccount models.TwitterAccount
+		if err := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleOperator)).Where("username = ?", req.NewSessionID).First(&newAccount).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "New Twitter account not found or not owned by user"})
+			return
+		}
+		updates["session_id"] = newAccount.Username
+		updates["twitter_account_id"] = newAccount.ID
+	} else if req.NewSessionID != "" && req.NewSessionID != req.OldSessionID {
 		// Verify new session exists and is active
 		var newAccount models.WhatsAppAccount
-		if err := config.DB.Where("session_id = ? AND user_id = ? AND status = ?", req.NewSessionID, userID, "active").First(&newAccount).Error; err != nil {
+		if err := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleOperator)).Where("session_id = ? AND status = ?", req.NewSessionID, "active").First(&newAccount).Error; err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "New WhatsApp session not found or not active"})
 			return
 		}
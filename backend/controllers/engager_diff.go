@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// engagerSetTypeFromQuery validates the required ?type= of engager set endpoints
+func engagerSetTypeFromQuery(c *gin.Context) (string, bool) {
+	switch t := c.Query("type"); t {
+	case models.EngagementLiked, models.EngagementRetweeted, models.EngagementReplied:
+		return t, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "type must be liked, retweeted or replied"})
+	return "", false
+}
+
+// DiffTweetEngagers godoc
+// @Summary      Diff Tweet Engagers
+// @Description  Scrape the current likers, retweeters or reply authors of a tweet, store them as a new checkpoint and return who was added and removed since the previous checkpoint, or since `since`. The first checkpoint of a tweet is a baseline. When the scrape stops at its page cap the diff is partial and no one is marked removed. Every new engager is sent as a new_engager event over the websocket, and all of them as one new_engagers event to webhook_url (requires Twitter token authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.EngagerDiffRequest true "Tweet URL, engager type and optional checkpoint"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Success      200 {object} utils.EngagerDiff
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/post/engagers/diff [post]
+func DiffTweetEngagers(c *gin.Context) {
+	startTime := time.Now()
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", "/twitter/post/engagers/diff", startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkTwitterScope(twitterAccount, models.TwitterScopeRead); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/engagers/diff", startTime, false, http.StatusForbidden, err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.EngagerDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/engagers/diff", startTime, false, http.StatusBadRequest, err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.WebhookURL != "" {
+		if err := utils.ValidateWebhookURL(req.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tweetID := utils_twitter.ExtractTweetID(req.URL)
+	if tweetID == "" {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/engagers/diff", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
+		return
+	}
+
+	operation, err := utils.EngagerSetOperation(req.Type)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation, err := utils.ReserveTwitterRequests(twitterAccount.UserID, utils.TwitterRequestCost(operation), "/twitter/post/engagers/diff")
+	if err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/engagers/diff", startTime, false, http.StatusTooManyRequests, err.Error())
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
+	var engagers []*utils_twitter.User
+	var pages int
+	var partial bool
+	usedAccount, status, err := runWithTwitterSession(c, twitterAccount, operation, func(session *utils_twitter.Session) error {
+		var err error
+		engagers, err = utils.FetchEngagerSet(session, twitterAccount.UserID, tweetID, req.Type)
+		pages, partial = session.Pages(operation), session.Truncated(operation)
+		return err
+	})
+	if err != nil {
+		reservation.Refund()
+		errMsg := err.Error()
+		if status == http.StatusInternalServerError {
+			errMsg = "Failed to fetch engagers"
+		}
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/post/engagers/diff", startTime, false, status, errMsg)
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	// The scrape succeeded, so it is billed even if storing the diff fails
+	reservation.Commit(utils.TwitterScrapeCost(operation, pages))
+
+	diff, err := utils.DiffEngagers(twitterAccount.UserID, tweetID, req.Type, engagers, partial)
+	if err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/post/engagers/diff", startTime, false, http.StatusInternalServerError, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store engager checkpoint"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("id = ?", twitterAccount.UserID).First(&user).Error; err == nil {
+		go utils.NotifyNewEngagers(user.Email, req.WebhookURL, diff)
+	}
+
+	utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/post/engagers/diff", startTime, true, http.StatusOK, "")
+
+	if req.Since != nil && *req.Since < diff.Since {
+		changes, err := utils.EngagerChangesSince(twitterAccount.UserID, tweetID, req.Type, *req.Since)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch engager changes"})
+			return
+		}
+		c.JSON(http.StatusOK, changes)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetEngagerChanges godoc
+// @Summary      Get Engager Changes Since a Checkpoint
+// @Description  Return who was added to or removed from a tweet's stored engager set after checkpoint `since`, without scraping (requires JWT authentication)
+// @Tags         twitter
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Tweet ID or URL"
+// @Param        type query string true "liked, retweeted or replied"
+// @Param        since query int false "Checkpoint to compare against" default(0)
+// @Success      200 {object} utils.EngagerDiff
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/engagers/{id}/changes [get]
+func GetEngagerChanges(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	tweetID := utils_twitter.NormalizeTweetID(c.Param("id"))
+	if tweetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet ID or URL"})
+		return
+	}
+
+	engagementType, ok := engagerSetTypeFromQuery(c)
+	if !ok {
+		return
+	}
+
+	since, _ := strconv.Atoi(c.Query("since"))
+	changes, err := utils.EngagerChangesSince(user.ID, tweetID, engagementType, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch engager changes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// GetEngagerCheckpoints godoc
+// @Summary      List Engager Checkpoints
+// @Description  List the stored engager checkpoints of a tweet with how many engagers each added and removed (requires JWT authentication)
+// @Tags         twitter
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Tweet ID or URL"
+// @Param        type query string true "liked, retweeted or replied"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/engagers/{id}/checkpoints [get]
+func GetEngagerCheckpoints(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	tweetID := utils_twitter.NormalizeTweetID(c.Param("id"))
+	if tweetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet ID or URL"})
+		return
+	}
+
+	engagementType, ok := engagerSetTypeFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var checkpoints []models.EngagerCheckpoint
+	if err := config.DB.Where("user_id = ? AND tweet_id = ? AND type = ?", user.ID, tweetID, engagementType).
+		Order("seq").Find(&checkpoints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checkpoints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweet_id": tweetID, "type": engagementType, "checkpoints": checkpoints, "count": len(checkpoints)})
+}
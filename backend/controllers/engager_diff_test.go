@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+)
+
+// engagerUsers returns Twitter users with the IDs
+func engagerUsers(ids ...string) []*utils_twitter.User {
+	users := make([]*utils_twitter.User, len(ids))
+	for i, id := range ids {
+		users[i] = &utils_twitter.User{ID: id, Username: "user" + id}
+	}
+	return users
+}
+
+// withTwitterScopes narrows the scopes of an account's token
+func withTwitterScopes(t *testing.T, account *models.TwitterAccount, scopes string) {
+	t.Helper()
+
+	if err := config.DB.Model(account).Update("scopes", scopes).Error; err != nil {
+		t.Fatalf("failed to set the scopes: %v", err)
+	}
+}
+
+// withBalance sets what is left of one of the user's quotas
+func withBalance(t *testing.T, user *models.User, column string, balance int) {
+	t.Helper()
+
+	if err := config.DB.Model(user).Update(column, balance).Error; err != nil {
+		t.Fatalf("failed to set the balance: %v", err)
+	}
+}
+
+func TestDiffTweetEngagers(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	writeOnly := testTwitterAccount(t, user)
+	withTwitterScopes(t, writeOnly, models.TwitterScopeWrite)
+	broke := testUser(t)
+	brokeAccount := testTwitterAccount(t, broke)
+	withBalance(t, broke, "twitter_reqs", 0)
+	url := "https://x.com/someone/status/1234567890"
+	diff := func(url, kind string) map[string]string { return map[string]string{"url": url, "type": kind} }
+
+	runAPITests(t, http.MethodPost, "/twitter/post/engagers/diff", DiffTweetEngagers, []apiTest{
+		{name: "no token", path: "/twitter/post/engagers/diff", body: diff(url, "liked"), status: http.StatusUnauthorized},
+		{name: "token without the read scope", path: "/twitter/post/engagers/diff", token: writeOnly.Token, body: diff(url, "liked"), status: http.StatusForbidden},
+		{name: "unknown type", path: "/twitter/post/engagers/diff", token: account.Token, body: diff(url, "quoted"), status: http.StatusBadRequest},
+		{name: "invalid tweet URL", path: "/twitter/post/engagers/diff", token: account.Token, body: diff("https://x.com/someone", "liked"), status: http.StatusBadRequest},
+		{
+			name: "internal webhook", path: "/twitter/post/engagers/diff", token: account.Token,
+			body: map[string]string{"url": url, "type": "liked", "webhook_url": "http://127.0.0.1/hook"}, status: http.StatusBadRequest,
+		},
+		{name: "no Twitter reads left", path: "/twitter/post/engagers/diff", token: brokeAccount.Token, body: diff(url, "liked"), status: http.StatusTooManyRequests},
+	})
+}
+
+func TestGetEngagerChanges(t *testing.T) {
+	user := testUser(t)
+	tweetID := testTwitterID()
+	for _, set := range [][]string{{"1", "2"}, {"2", "3"}} {
+		if _, err := utils.DiffEngagers(user.ID, tweetID, models.EngagementLiked, engagerUsers(set...), false); err != nil {
+			t.Fatalf("failed to store the checkpoint: %v", err)
+		}
+	}
+	token := userToken(t, user)
+	changes := "/twitter/engagers/" + tweetID + "/changes"
+
+	runAPITests(t, http.MethodGet, "/twitter/engagers/:id/changes", GetEngagerChanges, []apiTest{
+		{name: "invalid tweet ID", path: "/twitter/engagers/nonsense/changes?type=liked", token: token, status: http.StatusBadRequest},
+		{name: "no type", path: changes, token: token, status: http.StatusBadRequest},
+		{
+			name: "since the baseline", path: changes + "?type=liked&since=1", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "checkpoint", 2)
+				wantField(t, res, "total", 2)
+				wantCount(t, res, "added", 1)
+				wantCount(t, res, "removed", 1)
+				wantField(t, res["added"].([]interface{})[0].(map[string]interface{}), "twitter_user_id", "3")
+				wantField(t, res["removed"].([]interface{})[0].(map[string]interface{}), "twitter_user_id", "1")
+			},
+		},
+		{
+			name: "since the start", path: changes + "?type=liked", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantCount(t, res, "added", 2)
+				wantCount(t, res, "removed", 0)
+			},
+		},
+		{
+			name: "other type", path: changes + "?type=retweeted", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "checkpoint", 0)
+				wantField(t, res, "total", 0)
+			},
+		},
+		{
+			name: "another user", path: changes + "?type=liked", token: userToken(t, testUser(t)), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "total", 0) },
+		},
+	})
+}
+
+func TestGetEngagerCheckpoints(t *testing.T) {
+	user := testUser(t)
+	tweetID := testTwitterID()
+	for _, set := range [][]string{{"1", "2"}, {"2", "3", "4"}} {
+		if _, err := utils.DiffEngagers(user.ID, tweetID, models.EngagementRetweeted, engagerUsers(set...), false); err != nil {
+			t.Fatalf("failed to store the checkpoint: %v", err)
+		}
+	}
+	// A truncated scrape that missed 2 and 3 doesn't remove them
+	if _, err := utils.DiffEngagers(user.ID, tweetID, models.EngagementRetweeted, engagerUsers("4", "5"), true); err != nil {
+		t.Fatalf("failed to store the partial checkpoint: %v", err)
+	}
+	checkpoints := "/twitter/engagers/" + tweetID + "/checkpoints"
+
+	runAPITests(t, http.MethodGet, "/twitter/engagers/:id/checkpoints", GetEngagerCheckpoints, []apiTest{
+		{name: "unknown type", path: checkpoints + "?type=quoted", token: userToken(t, user), status: http.StatusBadRequest},
+		{
+			name: "checkpoints", path: checkpoints + "?type=retweeted", token: userToken(t, user), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 3)
+				second := res["checkpoints"].([]interface{})[1].(map[string]interface{})
+				wantField(t, second, "checkpoint", 2)
+				wantField(t, second, "total", 3)
+				wantField(t, second, "added", 2)
+				wantField(t, second, "removed", 1)
+				wantField(t, second, "partial", false)
+				third := res["checkpoints"].([]interface{})[2].(map[string]interface{})
+				wantField(t, third, "total", 4)
+				wantField(t, third, "added", 1)
+				wantField(t, third, "removed", 0)
+				wantField(t, third, "partial", true)
+			},
+		},
+	})
+}
@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findGiveaway loads a giveaway owned by user, writing the 404 itself
+func findGiveaway(c *gin.Context, user *models.User) (*models.Giveaway, bool) {
+	var giveaway models.Giveaway
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&giveaway).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Giveaway not found"})
+		return nil, false
+	}
+	return &giveaway, true
+}
+
+// giveawayWinners returns the winners of a giveaway in the order they were drawn
+func giveawayWinners(giveawayID string) []models.GiveawayEntrant {
+	winners := []models.GiveawayEntrant{}
+	config.DB.Where("giveaway_id = ? AND status = ?", giveawayID, models.EntrantWinner).Order("winner_rank").Find(&winners)
+	return winners
+}
+
+// giveawayView hides the seed of a giveaway that wasn't drawn yet, so nobody
+// can compute the draw before it runs
+func giveawayView(g models.Giveaway) models.Giveaway {
+	if g.Status == models.GiveawayCommitted || g.Status == models.GiveawayDrawing {
+		g.Seed = ""
+	}
+	return g
+}
+
+// giveawayOperations returns the scrapes a giveaway's required engagements take
+func giveawayOperations(g *models.Giveaway) []string {
+	var operations []string
+	for _, required := range []struct {
+		on bool
+		op string
+	}{
+		{g.RequireReply, utils_twitter.OpTweetDetail},
+		{g.RequireRetweet, utils_twitter.OpRetweeters},
+		{g.RequireLike, utils_twitter.OpFavoriters},
+	} {
+		if required.on {
+			operations = append(operations, required.op)
+		}
+	}
+	return operations
+}
+
+// CreateGiveaway godoc
+// @Summary      Pick Giveaway Winners
+// @Description  Set up a giveaway on a tweet's likers, retweeters and/or replies. Without `seed`, the giveaway is only committed. A random seed is stored and its sha256 returned as seed_hash, to be published before drawing with POST /twitter/giveaways/{id}/draw. With `seed`, e.g. a public value fixed before the giveaway ended, winners are drawn right away. Follows and missing profiles are checked only for drawn entrants. The draw and every entrant's outcome are stored for auditing. A draw costs one Twitter request per required engagement type, plus each follow check and profile lookup made while drawing (requires Twitter token authentication)
+// @Tags         giveaway
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.CreateGiveawayRequest true "Tweet URL, winner count and rules"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/giveaways [post]
+func CreateGiveaway(c *gin.Context) {
+	startTime := time.Now()
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", "/twitter/giveaways", startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkTwitterScope(twitterAccount, models.TwitterScopeRead); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/giveaways", startTime, false, http.StatusForbidden, err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.CreateGiveawayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/giveaways", startTime, false, http.StatusBadRequest, err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweetID := utils_twitter.ExtractTweetID(req.URL)
+	if tweetID == "" {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/giveaways", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
+		return
+	}
+
+	giveaway := models.Giveaway{
+		UserID:            twitterAccount.UserID,
+		TwitterAccountID:  twitterAccount.ID,
+		TweetID:           tweetID,
+		TweetURL:          req.URL,
+		RequireLike:       req.RequireLike,
+		RequireRetweet:    req.RequireRetweet,
+		RequireReply:      req.RequireReply || req.ReplyContains != "",
+		ReplyContains:     req.ReplyContains,
+		MustFollow:        strings.TrimPrefix(strings.TrimSpace(req.MustFollow), "@"),
+		MinAccountAgeDays: req.MinAccountAgeDays,
+		MinFollowers:      req.MinFollowers,
+		ExcludeBots:       req.ExcludeBots,
+		WinnerCount:       req.Winners,
+		Status:            models.GiveawayDrawing,
+		Seed:              req.Seed,
+	}
+	if len(giveawayOperations(&giveaway)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one of require_like, require_retweet or require_reply is needed"})
+		return
+	}
+
+	// A seed picked by the server is committed before anyone can see the entrants
+	if giveaway.Seed == "" {
+		giveaway.Seed = utils.NewGiveawaySeed()
+		giveaway.SeedHash = utils.GiveawaySeedHash(giveaway.Seed)
+		giveaway.Status = models.GiveawayCommitted
+		if err := config.DB.Create(&giveaway).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store giveaway"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"giveaway": giveawayView(giveaway),
+			"message":  "Publish seed_hash, then draw the winners with POST /twitter/giveaways/" + giveaway.ID + "/draw",
+		})
+		return
+	}
+
+	giveaway.SeedHash = utils.GiveawaySeedHash(giveaway.Seed)
+	drawGiveaway(c, twitterAccount, &giveaway, "/twitter/giveaways", startTime, http.StatusCreated)
+}
+
+// DrawGiveaway godoc
+// @Summary      Draw Giveaway Winners
+// @Description  Scrape the engagers of a committed giveaway, apply its rules and draw its winners with the committed seed, which is revealed with the draw. A giveaway is drawn once. Costs one Twitter request per required engagement type, plus each follow check and profile lookup made while drawing (requires Twitter token authentication)
+// @Tags         giveaway
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Giveaway ID"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/giveaways/{id}/draw [post]
+func DrawGiveaway(c *gin.Context) {
+	startTime := time.Now()
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", "/twitter/giveaways/draw", startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkTwitterScope(twitterAccount, models.TwitterScopeRead); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/giveaways/draw", startTime, false, http.StatusForbidden, err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var giveaway models.Giveaway
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), twitterAccount.UserID).First(&giveaway).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Giveaway not found"})
+		return
+	}
+
+	// Only one draw may claim the giveaway
+	result := config.DB.Model(&models.Giveaway{}).
+		Where("id = ? AND status = ?", giveaway.ID, models.GiveawayCommitted).
+		Update("status", models.GiveawayDrawing)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start the draw"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Giveaway was already drawn"})
+		return
+	}
+	giveaway.Status = models.GiveawayDrawing
+
+	if !drawGiveaway(c, twitterAccount, &giveaway, "/twitter/giveaways/draw", startTime, http.StatusOK) {
+		// The seed is still secret, so the giveaway can be drawn again
+		config.DB.Model(&models.Giveaway{}).Where("id = ?", giveaway.ID).Update("status", models.GiveawayCommitted)
+	}
+}
+
+// drawGiveaway runs the draw of a giveaway whose seed is fixed, stores it with
+// its entrants and responds with okStatus. It reports whether the draw was stored.
+func drawGiveaway(c *gin.Context, twitterAccount *models.TwitterAccount, giveaway *models.Giveaway, endpoint string, startTime time.Time, okStatus int) bool {
+	// Each required engagement is a scrape of its own
+	operations := giveawayOperations(giveaway)
+	cost := 0
+	for _, op := range operations {
+		cost += utils.TwitterRequestCost(op)
+	}
+	// Every winner takes at least one follow check; other lookups are billed when made
+	if giveaway.MustFollow != "" {
+		cost += giveaway.WinnerCount * utils.TwitterRequestCost(utils_twitter.OpFriendshipShow)
+	}
+
+	reservation, err := utils.ReserveTwitterRequests(twitterAccount.UserID, cost, endpoint)
+	if err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusTooManyRequests, err.Error())
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return false
+	}
+
+	var entrants []models.GiveawayEntrant
+	usedAccount, status, err := runWithTwitterSession(c, twitterAccount, operations[len(operations)-1], func(session *utils_twitter.Session) error {
+		var err error
+		entrants, err = utils.RunGiveaway(session, giveaway)
+		cost = 0
+		for _, op := range operations {
+			cost += utils.TwitterScrapeCost(op, session.Pages(op))
+		}
+		for _, op := range []string{utils_twitter.OpUserShow, utils_twitter.OpFriendshipShow} {
+			cost += session.Pages(op) * utils.TwitterRequestCost(op)
+		}
+		return err
+	})
+	if err != nil {
+		reservation.Refund()
+		errMsg := err.Error()
+		if status == http.StatusInternalServerError {
+			errMsg = "Failed to run giveaway"
+		}
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, endpoint, startTime, false, status, errMsg)
+		c.JSON(status, gin.H{"error": errMsg})
+		return false
+	}
+	giveaway.TwitterAccountID = usedAccount.ID
+	giveaway.Status = models.GiveawayDrawn
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(giveaway).Error; err != nil {
+			return err
+		}
+		for i := range entrants {
+			entrants[i].GiveawayID = giveaway.ID
+		}
+		if len(entrants) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&entrants, 500).Error
+	})
+	if err != nil {
+		reservation.Refund()
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, endpoint, startTime, false, http.StatusInternalServerError, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store giveaway"})
+		return false
+	}
+
+	reservation.Commit(cost)
+	utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, endpoint, startTime, true, http.StatusOK, "")
+
+	winners := []models.GiveawayEntrant{}
+	for _, e := range entrants {
+		if e.Status == models.EntrantWinner {
+			winners = append(winners, e)
+		}
+	}
+
+	c.JSON(okStatus, gin.H{
+		"giveaway": giveaway,
+		"winners":  winners,
+		"count":    len(winners),
+	})
+	return true
+}
+
+// ListGiveaways godoc
+// @Summary      List Giveaways
+// @Description  List your giveaway draws, newest first (requires JWT authentication)
+// @Tags         giveaway
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/giveaways [get]
+func ListGiveaways(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var giveaways []models.Giveaway
+	if err := config.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Find(&giveaways).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch giveaways"})
+		return
+	}
+
+	for i := range giveaways {
+		giveaways[i] = giveawayView(giveaways[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"giveaways": giveaways, "count": len(giveaways)})
+}
+
+// GetGiveaway godoc
+// @Summary      Get Giveaway
+// @Description  Get the audit record of a giveaway: its rules, seed, winners and, optionally, every entrant with its outcome (requires JWT authentication)
+// @Tags         giveaway
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Giveaway ID"
+// @Param        status query string false "List entrants with this status: winner, eligible, skipped or ineligible"
+// @Param        limit query int false "Maximum number of entrants" default(500)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /twitter/giveaways/{id} [get]
+func GetGiveaway(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	giveaway, ok := findGiveaway(c, user)
+	if !ok {
+		return
+	}
+
+	var counts []struct {
+		Status string
+		Count  int
+	}
+	config.DB.Model(&models.GiveawayEntrant{}).
+		Select("status, COUNT(*) AS count").
+		Where("giveaway_id = ?", giveaway.ID).
+		Group("status").
+		Scan(&counts)
+	statusCounts := map[string]int{}
+	for _, r := range counts {
+		statusCounts[r.Status] = r.Count
+	}
+
+	response := gin.H{
+		"giveaway":      giveawayView(*giveaway),
+		"winners":       giveawayWinners(giveaway.ID),
+		"status_counts": statusCounts,
+	}
+
+	if status := c.Query("status"); status != "" {
+		var entrants []models.GiveawayEntrant
+		config.DB.Where("giveaway_id = ? AND status = ?", giveaway.ID, status).
+			Order("position NULLS LAST, username").
+			Limit(queryInt(c, "limit", 500, 10000)).
+			Find(&entrants)
+		response["entrants"] = entrants
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyGiveaway godoc
+// @Summary      Verify Giveaway Draw
+// @Description  Recompute a giveaway's draw order from its seed and eligible entrants and check it against the stored winners (requires JWT authentication)
+// @Tags         giveaway
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Giveaway ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/giveaways/{id}/verify [get]
+func VerifyGiveaway(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	giveaway, ok := findGiveaway(c, user)
+	if !ok {
+		return
+	}
+
+	if giveaway.Status != models.GiveawayDrawn {
+		c.JSON(http.StatusConflict, gin.H{"error": "Giveaway was not drawn yet"})
+		return
+	}
+
+	var entrants []models.GiveawayEntrant
+	if err := config.DB.Where("giveaway_id = ?", giveaway.ID).Find(&entrants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch entrants"})
+		return
+	}
+
+	problems := utils.VerifyGiveaway(giveaway, entrants)
+	c.JSON(http.StatusOK, gin.H{
+		"valid":         len(problems) == 0,
+		"problems":      problems,
+		"seed":          giveaway.Seed,
+		"seed_hash":     giveaway.SeedHash,
+		"eligible_hash": giveaway.EligibleHash,
+		"winners":       giveawayWinners(giveaway.ID),
+	})
+}
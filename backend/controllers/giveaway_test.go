@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"testing"
+)
+
+// testGiveaway stores a draw of 2 winners over three eligible entrants and an
+// ineligible one, the way RunGiveaway stores it
+func testGiveaway(t *testing.T, user *models.User) *models.Giveaway {
+	t.Helper()
+
+	eligible := []string{testTwitterID(), testTwitterID(), testTwitterID()}
+	seed := utils.NewGiveawaySeed()
+	giveaway := &models.Giveaway{
+		UserID: user.ID, TweetID: testTwitterID(), RequireLike: true, WinnerCount: 2, Status: models.GiveawayDrawn,
+		Seed: seed, SeedHash: utils.GiveawaySeedHash(seed), EligibleHash: utils.GiveawayEligibleHash(eligible), EntrantCount: 4, EligibleCount: 3,
+	}
+	if err := config.DB.Create(giveaway).Error; err != nil {
+		t.Fatalf("failed to store the giveaway: %v", err)
+	}
+
+	entrants := []models.GiveawayEntrant{{GiveawayID: giveaway.ID, TwitterUserID: testTwitterID(), Username: "bot", Status: models.EntrantIneligible, Reason: "not liked"}}
+	for i, id := range utils.GiveawayDrawOrder(giveaway.Seed, giveaway.TweetID, eligible) {
+		position := i + 1
+		entrant := models.GiveawayEntrant{GiveawayID: giveaway.ID, TwitterUserID: id, Username: "entrant" + id, Liked: true, Status: models.EntrantEligible, Position: &position}
+		if i < giveaway.WinnerCount {
+			entrant.Status, entrant.WinnerRank = models.EntrantWinner, i+1
+		}
+		entrants = append(entrants, entrant)
+	}
+	if err := config.DB.Create(&entrants).Error; err != nil {
+		t.Fatalf("failed to store the entrants: %v", err)
+	}
+	return giveaway
+}
+
+// testCommittedGiveaway stores a giveaway whose seed is committed but not drawn
+func testCommittedGiveaway(t *testing.T, account *models.TwitterAccount) *models.Giveaway {
+	t.Helper()
+
+	seed := utils.NewGiveawaySeed()
+	giveaway := &models.Giveaway{
+		UserID: account.UserID, TwitterAccountID: account.ID, TweetID: testTwitterID(), RequireLike: true, WinnerCount: 1,
+		Status: models.GiveawayCommitted, Seed: seed, SeedHash: utils.GiveawaySeedHash(seed),
+	}
+	if err := config.DB.Create(giveaway).Error; err != nil {
+		t.Fatalf("failed to store the giveaway: %v", err)
+	}
+	return giveaway
+}
+
+func TestCreateGiveaway(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	writeOnly := testTwitterAccount(t, user)
+	withTwitterScopes(t, writeOnly, models.TwitterScopeWrite)
+	broke := testUser(t)
+	brokeAccount := testTwitterAccount(t, broke)
+	withBalance(t, broke, "twitter_reqs", 0)
+	url := "https://x.com/someone/status/1234567890"
+	draw := map[string]interface{}{"url": url, "winners": 1, "require_like": true, "seed": "block 900000"}
+	commit := map[string]interface{}{"url": url, "winners": 1, "require_like": true}
+
+	runAPITests(t, http.MethodPost, "/twitter/giveaways", CreateGiveaway, []apiTest{
+		{name: "no token", path: "/twitter/giveaways", body: draw, status: http.StatusUnauthorized},
+		{name: "token without the read scope", path: "/twitter/giveaways", token: writeOnly.Token, body: draw, status: http.StatusForbidden},
+		{name: "no winners", path: "/twitter/giveaways", token: account.Token, body: map[string]interface{}{"url": url, "require_like": true}, status: http.StatusBadRequest},
+		{
+			name: "invalid tweet URL", path: "/twitter/giveaways", token: account.Token,
+			body: map[string]interface{}{"url": "https://x.com/someone", "winners": 1, "require_like": true}, status: http.StatusBadRequest,
+		},
+		{name: "no required engagement", path: "/twitter/giveaways", token: account.Token, body: map[string]interface{}{"url": url, "winners": 1}, status: http.StatusBadRequest},
+		{name: "no Twitter reads left", path: "/twitter/giveaways", token: brokeAccount.Token, body: draw, status: http.StatusTooManyRequests},
+		{
+			name: "committed without a seed", path: "/twitter/giveaways", token: brokeAccount.Token, body: commit, status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				giveaway := res["giveaway"].(map[string]interface{})
+				wantField(t, giveaway, "status", models.GiveawayCommitted)
+				wantField(t, giveaway, "seed", "")
+				var stored models.Giveaway
+				if err := config.DB.Where("id = ?", giveaway["id"]).First(&stored).Error; err != nil {
+					t.Fatalf("giveaway not stored: %v", err)
+				}
+				wantField(t, giveaway, "seed_hash", utils.GiveawaySeedHash(stored.Seed))
+			},
+		},
+	})
+}
+
+func TestDrawGiveaway(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	committed := testCommittedGiveaway(t, account)
+	drawn := testGiveaway(t, user)
+	other := testCommittedGiveaway(t, testTwitterAccount(t, testUser(t)))
+	draw := func(giveaway *models.Giveaway) string { return "/twitter/giveaways/" + giveaway.ID + "/draw" }
+
+	runAPITests(t, http.MethodPost, "/twitter/giveaways/:id/draw", DrawGiveaway, []apiTest{
+		{name: "no token", path: draw(committed), status: http.StatusUnauthorized},
+		{name: "giveaway of another user", path: draw(other), token: account.Token, status: http.StatusNotFound},
+		{name: "already drawn", path: draw(drawn), token: account.Token, status: http.StatusConflict},
+		{
+			// The test account has no session, so the draw fails and can be retried
+			name: "draw that failed", path: draw(committed), token: account.Token, status: http.StatusUnauthorized,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var stored models.Giveaway
+				config.DB.Where("id = ?", committed.ID).First(&stored)
+				if stored.Status != models.GiveawayCommitted {
+					t.Errorf("giveaway is %s after a failed draw, want %s", stored.Status, models.GiveawayCommitted)
+				}
+			},
+		},
+	})
+}
+
+func TestListAndGetGiveaways(t *testing.T) {
+	user := testUser(t)
+	giveaway := testGiveaway(t, user)
+	testGiveaway(t, user)
+	token := userToken(t, user)
+
+	runAPITests(t, http.MethodGet, "/twitter/giveaways", ListGiveaways, []apiTest{
+		{
+			name: "own giveaways", path: "/twitter/giveaways", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 2) },
+		},
+		{
+			name: "giveaways of another user", path: "/twitter/giveaways", token: userToken(t, testUser(t)), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 0) },
+		},
+	})
+
+	runAPITests(t, http.MethodGet, "/twitter/giveaways/:id", GetGiveaway, []apiTest{
+		{name: "giveaway of another user", path: "/twitter/giveaways/" + giveaway.ID, token: userToken(t, testUser(t)), status: http.StatusNotFound},
+		{
+			name: "giveaway", path: "/twitter/giveaways/" + giveaway.ID, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["giveaway"].(map[string]interface{}), "seed", giveaway.Seed)
+				wantField(t, res["giveaway"].(map[string]interface{}), "seed_hash", giveaway.SeedHash)
+				wantCount(t, res, "winners", 2)
+				wantField(t, res["winners"].([]interface{})[0].(map[string]interface{}), "winner_rank", 1)
+				wantField(t, res, "status_counts", map[string]int{models.EntrantWinner: 2, models.EntrantEligible: 1, models.EntrantIneligible: 1})
+				if _, listed := res["entrants"]; listed {
+					t.Errorf("entrants listed without a status")
+				}
+			},
+		},
+		{
+			name: "ineligible entrants", path: "/twitter/giveaways/" + giveaway.ID + "?status=ineligible", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantCount(t, res, "entrants", 1)
+				wantField(t, res["entrants"].([]interface{})[0].(map[string]interface{}), "reason", "not liked")
+			},
+		},
+	})
+}
+
+func TestVerifyGiveaway(t *testing.T) {
+	user := testUser(t)
+	giveaway := testGiveaway(t, user)
+	tampered := testGiveaway(t, user)
+	config.DB.Model(&models.GiveawayEntrant{}).
+		Where("giveaway_id = ? AND status = ?", tampered.ID, models.EntrantIneligible).
+		Update("status", models.EntrantEligible)
+	committed := testCommittedGiveaway(t, testTwitterAccount(t, user))
+	token := userToken(t, user)
+	verify := func(giveaway *models.Giveaway) string { return "/twitter/giveaways/" + giveaway.ID + "/verify" }
+
+	runAPITests(t, http.MethodGet, "/twitter/giveaways/:id/verify", VerifyGiveaway, []apiTest{
+		{name: "giveaway of another user", path: verify(giveaway), token: userToken(t, testUser(t)), status: http.StatusNotFound},
+		{name: "not drawn yet", path: verify(committed), token: token, status: http.StatusConflict},
+		{
+			name: "untouched draw", path: verify(giveaway), token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "valid", true)
+				wantCount(t, res, "problems", 0)
+				wantField(t, res, "eligible_hash", giveaway.EligibleHash)
+			},
+		},
+		{
+			name: "entrant made eligible after the draw", path: verify(tampered), token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "valid", false) },
+		},
+	})
+}
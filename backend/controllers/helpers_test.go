@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_testdb "ripper-backend/utils/testdb"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testPassword is the password of every test user
+const testPassword = "password123"
+
+// testDB connects to the test database like utils_testdb.Connect, which also
+// creates the default plan like at startup. Test users get unique emails.
+func testDB(t *testing.T) {
+	t.Helper()
+	utils_testdb.Connect(t, func() error {
+		gin.SetMode(gin.TestMode)
+		return utils.EnsureDefaultPlan()
+	})
+}
+
+// testUser creates a verified user with testPassword, changed by the options
+func testUser(t *testing.T, options ...func(*models.User)) *models.User {
+	t.Helper()
+	testDB(t)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash the password: %v", err)
+	}
+	now := time.Now()
+	user := &models.User{
+		Name:            "Test User",
+		Email:           "test-" + uuid.NewString() + "@example.com",
+		Password:        string(hash),
+		Role:            models.RoleMember,
+		EmailVerifiedAt: &now,
+	}
+	for _, option := range options {
+		option(user)
+	}
+	if err := config.DB.Create(user).Error; err != nil {
+		t.Fatalf("failed to create the test user: %v", err)
+	}
+	return user
+}
+
+// asAdmin is a testUser option giving the admin role
+func asAdmin(user *models.User) {
+	user.Role = models.RoleAdmin
+}
+
+// testTwitterAccount creates an active Twitter account of the user, with every scope
+func testTwitterAccount(t *testing.T, user *models.User) *models.TwitterAccount {
+	t.Helper()
+
+	account := &models.TwitterAccount{
+		Username: "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:10],
+		Password: "not used",
+		Token:    uuid.NewString(),
+		UserID:   user.ID,
+		Scopes:   strings.Join(models.AllTwitterScopes, ","),
+		Status:   models.TwitterAccountActive,
+	}
+	if err := config.DB.Create(account).Error; err != nil {
+		t.Fatalf("failed to create the test Twitter account: %v", err)
+	}
+	return account
+}
+
+// testOrganization creates an organization owned by owner, changed by the options
+func testOrganization(t *testing.T, owner *models.User, options ...func(*models.Organization)) *models.Organization {
+	t.Helper()
+
+	organization := &models.Organization{Name: "Test Org " + uuid.NewString()[:8]}
+	for _, option := range options {
+		option(organization)
+	}
+	if err := config.DB.Create(organization).Error; err != nil {
+		t.Fatalf("failed to create the test organization: %v", err)
+	}
+	testMember(t, organization, owner, models.OrgRoleOwner)
+	return organization
+}
+
+// requiringTwoFactor is a testOrganization option requiring 2FA of its members
+func requiringTwoFactor(organization *models.Organization) {
+	organization.RequireTwoFactor = true
+}
+
+// testMember adds a user to an organization with a role
+func testMember(t *testing.T, organization *models.Organization, user *models.User, role string) {
+	t.Helper()
+
+	member := &models.OrganizationMember{OrganizationID: organization.ID, UserID: user.ID, Role: role}
+	if err := config.DB.Create(member).Error; err != nil {
+		t.Fatalf("failed to add the test member: %v", err)
+	}
+}
+
+// userToken returns the JWT of a user, as issued at login
+func userToken(t *testing.T, user *models.User) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": user.Email,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString(config.JWTSecret)
+	if err != nil {
+		t.Fatalf("failed to sign the token: %v", err)
+	}
+	return token
+}
+
+// apiTest is a request to a route and the response it should get
+type apiTest struct {
+	name   string
+	method string // the route's method when empty
+	path   string
+	token  string      // sent as a bearer token when set
+	header http.Header // extra request headers
+	body   interface{} // sent as JSON when set
+	status int
+	check  func(t *testing.T, res map[string]interface{}) // of a JSON object response, optional
+}
+
+// runAPITests serves each test's request with a router holding the global
+// middleware of main.go and one route, then checks the response. The tests
+// run in order, so later ones can rely on what earlier ones changed.
+func runAPITests(t *testing.T, method, route string, handler gin.HandlerFunc, tests []apiTest) {
+	t.Helper()
+
+	router := gin.New()
+	router.Use(AuthenticateApiKeys(), RejectSuspended())
+	router.Handle(method, route, handler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Callers are cached, forget them so changes to users apply
+			forgetAPICallers()
+
+			var body bytes.Buffer
+			if tt.body != nil {
+				if err := json.NewEncoder(&body).Encode(tt.body); err != nil {
+					t.Fatalf("failed to encode the body: %v", err)
+				}
+			}
+			requestMethod := tt.method
+			if requestMethod == "" {
+				requestMethod = method
+			}
+			req := httptest.NewRequest(requestMethod, tt.path, &body)
+			req.Header.Set("Content-Type", "application/json")
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("%s %s = %d, want %d: %s", requestMethod, tt.path, w.Code, tt.status, w.Body.String())
+			}
+			if tt.check != nil {
+				var res map[string]interface{}
+				if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+					t.Fatalf("response is not a JSON object: %s", w.Body.String())
+				}
+				tt.check(t, res)
+			}
+		})
+	}
+}
+
+// wantField fails the test unless res[key] encodes to the same JSON as want
+func wantField(t *testing.T, res map[string]interface{}, key string, want interface{}) {
+	t.Helper()
+	if got, _ := json.Marshal(res[key]); string(got) != mustJSON(want) {
+		t.Errorf("%s = %s, want %s", key, got, mustJSON(want))
+	}
+}
+
+// wantCount fails the test unless res[key] is a list of n entries
+func wantCount(t *testing.T, res map[string]interface{}, key string, n int) {
+	t.Helper()
+	list, ok := res[key].([]interface{})
+	if !ok {
+		t.Fatalf("%s = %v, want a list", key, res[key])
+	}
+	if len(list) != n {
+		t.Errorf("%s has %d entries, want %d", key, len(list), n)
+	}
+}
+
+func mustJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
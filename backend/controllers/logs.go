@@ -35,7 +35,7 @@ func GetAPILogs(c *gin.Context) {
 	// Parse and validate token
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -95,7 +95,7 @@ func GetAPIStats(c *gin.Context) {
 	// Parse and validate token
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"ripper-backend/config"
 	"ripper-backend/models"
+	"ripper-backend/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -34,7 +35,7 @@ func GetMessageLogs(c *gin.Context) {
 	offset, _ := strconv.Atoi(offsetStr)
 
 	// Build query
-	query := config.DB.Where("user_id = ?", userID)
+	query := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleViewer))
 
 	if status != "" {
 		query = query.Where("status = ?", status)
@@ -93,11 +94,12 @@ func GetMessageLogStats(c *gin.Context) {
 		Paused  int64 `json:"paused"`
 	}
 
-	config.DB.Model(&models.MessageLog{}).Where("user_id = ?", userID).Count(&stats.Total)
-	config.DB.Model(&models.MessageLog{}).Where("user_id = ? AND status = ?", userID, "pending").Count(&stats.Pending)
-	config.DB.Model(&models.MessageLog{}).Where("user_id = ? AND status = ?", userID, "sent").Count(&stats.Sent)
-	config.DB.Model(&models.MessageLog{}).Where("user_id = ? AND status = ?", userID, "failed").Count(&stats.Failed)
-	config.DB.Model(&models.MessageLog{}).Where("user_id = ? AND status = ?", userID, "paused").Count(&stats.Paused)
+	accessible := utils.Accessible(userID, models.OrgRoleViewer)
+	config.DB.Model(&models.MessageLog{}).Scopes(accessible).Count(&stats.Total)
+	config.DB.Model(&models.MessageLog{}).Scopes(accessible).Where("status = ?", "pending").Count(&stats.Pending)
+	config.DB.Model(&models.MessageLog{}).Scopes(accessible).Where("status = ?", "sent").Count(&stats.Sent)
+	config.DB.Model(&models.MessageLog{}).Scopes(accessible).Where("status = ?", "failed").Count(&stats.Failed)
+	config.DB.Model(&models.MessageLog{}).Scopes(accessible).Where("status = ?", "paused").Count(&stats.Paused)
 
 	c.JSON(http.StatusOK, stats)
 }
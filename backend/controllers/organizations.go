@@ -0,0 +1,668 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findOrganization loads the organization of the :id parameter and the user's
+// role in it. Non-members get 404 and members below minRole get 403. Beyond
+// viewing and leaving, an organization requiring 2FA also answers 403 to
+// members who haven't enabled it.
+func findOrganization(c *gin.Context, user *models.User, minRole string) (*models.Organization, string, bool) {
+	role, ok := utils.OrganizationRole(c.Param("id"), user.ID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return nil, "", false
+	}
+	if !models.OrgRoleAtLeast(role, minRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this organization does not allow this"})
+		return nil, "", false
+	}
+
+	var organization models.Organization
+	if err := config.DB.Where("id = ?", c.Param("id")).First(&organization).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return nil, "", false
+	}
+	if organization.RequireTwoFactor && !user.IsTwoFactorEnabled() && minRole != models.OrgRoleViewer {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This organization requires two-factor authentication, enable it on your account first"})
+		return nil, "", false
+	}
+	return &organization, role, true
+}
+
+// findOrganizationMember loads the member of the :user_id parameter, writing the 404 itself
+func findOrganizationMember(c *gin.Context, organizationID string) (*models.OrganizationMember, bool) {
+	var member models.OrganizationMember
+	if err := config.DB.Where("organization_id = ? AND user_id = ?", organizationID, c.Param("user_id")).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return nil, false
+	}
+	return &member, true
+}
+
+// isLastOwner reports whether member is the only owner of their organization
+func isLastOwner(member *models.OrganizationMember) bool {
+	if member.Role != models.OrgRoleOwner {
+		return false
+	}
+	var owners int64
+	config.DB.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", member.OrganizationID, models.OrgRoleOwner).
+		Count(&owners)
+	return owners <= 1
+}
+
+// unshareMemberAccounts makes the accounts a member shared with an organization
+// personal again, with their batches and logs
+func unshareMemberAccounts(organizationID, userID string) {
+	var twitterAccounts []models.TwitterAccount
+	config.DB.Where("organization_id = ? AND user_id = ?", organizationID, userID).Find(&twitterAccounts)
+	for _, account := range twitterAccounts {
+		if err := utils.ShareTwitterAccount(account, nil); err != nil {
+			log.Printf("⚠️ Failed to unshare Twitter account @%s: %v", account.Username, err)
+		}
+	}
+
+	var whatsappAccounts []models.WhatsAppAccount
+	config.DB.Where("organization_id = ? AND user_id = ?", organizationID, userID).Find(&whatsappAccounts)
+	for _, account := range whatsappAccounts {
+		if err := utils.ShareWhatsAppAccount(account, nil); err != nil {
+			log.Printf("⚠️ Failed to unshare WhatsApp session %s: %v", account.SessionID, err)
+		}
+	}
+}
+
+// CreateOrganization godoc
+// @Summary      Create an Organization
+// @Description  Create an organization with you as its owner (requires JWT authentication)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.OrganizationRequest true "Organization name"
+// @Success      201 {object} models.Organization
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs [post]
+func CreateOrganization(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	organization := models.Organization{Name: req.Name}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{OrganizationID: organization.ID, UserID: user.ID, Role: models.OrgRoleOwner}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, organization)
+}
+
+// ListOrganizations godoc
+// @Summary      List Organizations
+// @Description  List the organizations you are a member of, with your role in each (requires JWT authentication)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs [get]
+func ListOrganizations(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var memberships []models.OrganizationMember
+	if err := config.DB.Where("user_id = ?", user.ID).Find(&memberships).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+	roles := make(map[string]string, len(memberships))
+	ids := make([]string, len(memberships))
+	for i, membership := range memberships {
+		roles[membership.OrganizationID] = membership.Role
+		ids[i] = membership.OrganizationID
+	}
+
+	var rows []models.Organization
+	if len(ids) > 0 {
+		if err := config.DB.Where("id IN ?", ids).Order("name").Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+			return
+		}
+	}
+
+	organizations := make([]gin.H, len(rows))
+	for i, row := range rows {
+		organizations[i] = gin.H{
+			"id":                 row.ID,
+			"name":               row.Name,
+			"role":               roles[row.ID],
+			"require_two_factor": row.RequireTwoFactor,
+			"created_at":         row.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations, "count": len(organizations)})
+}
+
+// GetOrganization godoc
+// @Summary      Get an Organization
+// @Description  Get an organization with its members and the number of accounts shared with it (requires JWT authentication and membership)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /orgs/{id} [get]
+func GetOrganization(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, role, ok := findOrganization(c, user, models.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	var members []models.OrganizationMember
+	config.DB.Preload("User").Where("organization_id = ?", organization.ID).Order("created_at").Find(&members)
+
+	memberList := make([]gin.H, len(members))
+	for i, member := range members {
+		memberList[i] = gin.H{
+			"user_id":            member.UserID,
+			"name":               member.User.Name,
+			"email":              member.User.Email,
+			"role":               member.Role,
+			"joined_at":          member.CreatedAt,
+			"two_factor_enabled": member.User.IsTwoFactorEnabled(),
+		}
+	}
+
+	var twitterAccounts, whatsappAccounts int64
+	config.DB.Model(&models.TwitterAccount{}).Where("organization_id = ?", organization.ID).Count(&twitterAccounts)
+	config.DB.Model(&models.WhatsAppAccount{}).Where("organization_id = ?", organization.ID).Count(&whatsappAccounts)
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                 organization.ID,
+		"name":               organization.Name,
+		"role":               role,
+		"require_two_factor": organization.RequireTwoFactor,
+		"members":            memberList,
+		"twitter_accounts":   twitterAccounts,
+		"whatsapp_accounts":  whatsappAccounts,
+		"created_at":         organization.CreatedAt,
+	})
+}
+
+// UpdateOrganization godoc
+// @Summary      Rename an Organization
+// @Description  Rename an organization (requires JWT authentication and the admin or owner role)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        request body schemas.OrganizationRequest true "New name"
+// @Success      200 {object} models.Organization
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id} [put]
+func UpdateOrganization(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, _, ok := findOrganization(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req schemas.OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := config.DB.Model(organization).Update("name", req.Name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename organization"})
+		return
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// DeleteOrganization godoc
+// @Summary      Delete an Organization
+// @Description  Delete an organization. The accounts, batches and logs shared with it become personal again, owned by whoever added them (requires JWT authentication and the owner role)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id} [delete]
+func DeleteOrganization(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, _, ok := findOrganization(c, user, models.OrgRoleOwner)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := utils.UnshareOrganization(tx, organization.ID); err != nil {
+			return err
+		}
+		if err := tx.Where("organization_id = ?", organization.ID).Delete(&models.OrganizationMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(organization).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete organization"})
+		return
+	}
+	log.Printf("🗑️ %s deleted organization %s", user.Email, organization.Name)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organization deleted successfully"})
+}
+
+// AddOrganizationMember godoc
+// @Summary      Add an Organization Member
+// @Description  Add a signed-up user to an organization with a role: owner, admin, operator or viewer. Only owners can add owners (requires JWT authentication and the admin or owner role)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        request body schemas.AddOrganizationMemberRequest true "User email and role"
+// @Success      201 {object} models.OrganizationMember
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id}/members [post]
+func AddOrganizationMember(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, role, ok := findOrganization(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req schemas.AddOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Role == models.OrgRoleOwner && role != models.OrgRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can add owners"})
+		return
+	}
+
+	var newMember models.User
+	if err := config.DB.Where("email = ?", req.Email).First(&newMember).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if _, exists := utils.OrganizationRole(organization.ID, newMember.ID); exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member"})
+		return
+	}
+
+	member := models.OrganizationMember{OrganizationID: organization.ID, UserID: newMember.ID, Role: req.Role}
+	if err := config.DB.Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+	member.User = newMember
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// SetOrganizationMemberRole godoc
+// @Summary      Change an Organization Member's Role
+// @Description  Change the role of a member. Only owners can make or unmake owners, and the last owner keeps their role (requires JWT authentication and the admin or owner role)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        user_id path string true "User ID of the member"
+// @Param        request body schemas.SetOrganizationMemberRoleRequest true "New role"
+// @Success      200 {object} models.OrganizationMember
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id}/members/{user_id} [put]
+func SetOrganizationMemberRole(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, role, ok := findOrganization(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	member, ok := findOrganizationMember(c, organization.ID)
+	if !ok {
+		return
+	}
+
+	var req schemas.SetOrganizationMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (req.Role == models.OrgRoleOwner || member.Role == models.OrgRoleOwner) && role != models.OrgRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can make or unmake owners"})
+		return
+	}
+	if req.Role != models.OrgRoleOwner && isLastOwner(member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An organization needs at least one owner"})
+		return
+	}
+
+	if err := config.DB.Model(member).Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveOrganizationMember godoc
+// @Summary      Remove an Organization Member
+// @Description  Remove a member, or leave an organization with your own user ID. The accounts the member shared with the organization become personal again, with their batches and logs. Only owners can remove owners, and the last owner cannot leave (requires JWT authentication and the admin or owner role, except to leave)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        user_id path string true "User ID of the member"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id}/members/{user_id} [delete]
+func RemoveOrganizationMember(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	minRole := models.OrgRoleAdmin
+	if c.Param("user_id") == user.ID {
+		minRole = models.OrgRoleViewer
+	}
+	organization, role, ok := findOrganization(c, user, minRole)
+	if !ok {
+		return
+	}
+
+	member, ok := findOrganizationMember(c, organization.ID)
+	if !ok {
+		return
+	}
+
+	if member.Role == models.OrgRoleOwner && role != models.OrgRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can remove owners"})
+		return
+	}
+	if isLastOwner(member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An organization needs at least one owner"})
+		return
+	}
+
+	unshareMemberAccounts(organization.ID, member.UserID)
+	if err := config.DB.Delete(member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// ShareAccount godoc
+// @Summary      Share an Account with an Organization
+// @Description  Share one of your Twitter or WhatsApp accounts with an organization, with its batches and logs. Members can then use it according to their role. An account is shared with at most one organization (requires JWT authentication and the admin or owner role)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        request body schemas.ShareAccountRequest true "Account type and ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id}/accounts [post]
+func ShareAccount(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, _, ok := findOrganization(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req schemas.ShareAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var sharedWith *string
+	var err error
+	if req.Type == "twitter" {
+		var account models.TwitterAccount
+		if config.DB.Where("id = ? AND user_id = ?", req.AccountID, user.ID).First(&account).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
+			return
+		}
+		if sharedWith = account.OrganizationID; sharedWith == nil {
+			err = utils.ShareTwitterAccount(account, &organization.ID)
+		}
+	} else {
+		var account models.WhatsAppAccount
+		if config.DB.Where("id = ? AND user_id = ?", req.AccountID, user.ID).First(&account).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "WhatsApp account not found or not owned by user"})
+			return
+		}
+		if sharedWith = account.OrganizationID; sharedWith == nil {
+			err = utils.ShareWhatsAppAccount(account, &organization.ID)
+		}
+	}
+
+	if sharedWith != nil && *sharedWith != organization.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is already shared with another organization"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Account shared successfully",
+		"type":            req.Type,
+		"account_id":      req.AccountID,
+		"organization_id": organization.ID,
+	})
+}
+
+// UnshareAccount godoc
+// @Summary      Unshare an Account
+// @Description  Make an account shared with an organization personal again, with its batches and logs (requires JWT authentication and the admin or owner role, or owning the account)
+// @Tags         organizations
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        type path string true "twitter or whatsapp"
+// @Param        account_id path string true "Account ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id}/accounts/{type}/{account_id} [delete]
+func UnshareAccount(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, role, ok := findOrganization(c, user, models.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	var ownerID string
+	var unshare func() error
+	switch c.Param("type") {
+	case "twitter":
+		var account models.TwitterAccount
+		if config.DB.Where("id = ? AND organization_id = ?", c.Param("account_id"), organization.ID).First(&account).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found in organization"})
+			return
+		}
+		ownerID = account.UserID
+		unshare = func() error { return utils.ShareTwitterAccount(account, nil) }
+	case "whatsapp":
+		var account models.WhatsAppAccount
+		if config.DB.Where("id = ? AND organization_id = ?", c.Param("account_id"), organization.ID).First(&account).Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "WhatsApp account not found in organization"})
+			return
+		}
+		ownerID = account.UserID
+		unshare = func() error { return utils.ShareWhatsAppAccount(account, nil) }
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be twitter or whatsapp"})
+		return
+	}
+
+	if ownerID != user.ID && !models.OrgRoleAtLeast(role, models.OrgRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this organization does not allow this"})
+		return
+	}
+
+	if err := unshare(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unshare account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unshared successfully"})
+}
+
+// SetOrganizationTwoFactor godoc
+// @Summary      Require 2FA in an Organization
+// @Description  Require members to enable two-factor authentication, or stop requiring it. While required, members without 2FA can view and leave the organization but not use what is shared with it. You need 2FA yourself to turn it on (requires JWT authentication and the admin or owner role)
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Organization ID"
+// @Param        request body schemas.SetOrganizationTwoFactorRequest true "Whether 2FA is required"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /orgs/{id}/two-factor [put]
+func SetOrganizationTwoFactor(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	organization, _, ok := findOrganization(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req schemas.SetOrganizationTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *req.Required && !user.IsTwoFactorEnabled() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Enable two-factor authentication on your account first"})
+		return
+	}
+
+	if err := config.DB.Model(organization).Update("require_two_factor", *req.Required).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update organization"})
+		return
+	}
+
+	var withoutTwoFactor int64
+	config.DB.Model(&models.OrganizationMember{}).
+		Joins("JOIN users ON users.id = organization_members.user_id").
+		Where("organization_members.organization_id = ? AND users.totp_enabled_at IS NULL", organization.ID).
+		Count(&withoutTwoFactor)
+
+	c.JSON(http.StatusOK, gin.H{
+		"require_two_factor":         *req.Required,
+		"members_without_two_factor": withoutTwoFactor,
+	})
+}
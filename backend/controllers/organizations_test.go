@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"testing"
+)
+
+// orgFixture is an organization with a member of every role, and a user outside it
+type orgFixture struct {
+	organization                             *models.Organization
+	owner, admin, operator, viewer, outsider *models.User
+	ownerToken, adminToken, operatorToken    string
+	viewerToken, outsiderToken               string
+}
+
+func newOrgFixture(t *testing.T, options ...func(*models.Organization)) orgFixture {
+	t.Helper()
+
+	f := orgFixture{owner: testUser(t), admin: testUser(t), operator: testUser(t), viewer: testUser(t), outsider: testUser(t)}
+	f.organization = testOrganization(t, f.owner, options...)
+	testMember(t, f.organization, f.admin, models.OrgRoleAdmin)
+	testMember(t, f.organization, f.operator, models.OrgRoleOperator)
+	testMember(t, f.organization, f.viewer, models.OrgRoleViewer)
+	f.ownerToken = userToken(t, f.owner)
+	f.adminToken = userToken(t, f.admin)
+	f.operatorToken = userToken(t, f.operator)
+	f.viewerToken = userToken(t, f.viewer)
+	f.outsiderToken = userToken(t, f.outsider)
+	return f
+}
+
+// sharedTwitterAccount creates a Twitter account of the user shared with the organization
+func sharedTwitterAccount(t *testing.T, user *models.User, organization *models.Organization) *models.TwitterAccount {
+	t.Helper()
+
+	account := testTwitterAccount(t, user)
+	if err := utils.ShareTwitterAccount(*account, &organization.ID); err != nil {
+		t.Fatalf("failed to share the account: %v", err)
+	}
+	account.OrganizationID = &organization.ID
+	return account
+}
+
+// wantSharedWith fails the test unless the account is shared with organizationID, or personal when nil
+func wantSharedWith(t *testing.T, accountID string, organizationID *string) {
+	t.Helper()
+
+	var account models.TwitterAccount
+	config.DB.Where("id = ?", accountID).First(&account)
+	if (account.OrganizationID == nil) != (organizationID == nil) ||
+		(organizationID != nil && *account.OrganizationID != *organizationID) {
+		t.Errorf("account shared with %v, want %v", account.OrganizationID, organizationID)
+	}
+}
+
+func TestCreateOrganization(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+
+	runAPITests(t, http.MethodPost, "/orgs", CreateOrganization, []apiTest{
+		{name: "no token", path: "/orgs", body: map[string]string{"name": "Acme"}, status: http.StatusUnauthorized},
+		{name: "no name", path: "/orgs", token: token, body: map[string]string{}, status: http.StatusBadRequest},
+		{
+			name: "creator becomes owner", path: "/orgs", token: token, body: map[string]string{"name": "Acme"}, status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "name", "Acme")
+				if role, ok := utils.OrganizationRole(res["id"].(string), user.ID); !ok || role != models.OrgRoleOwner {
+					t.Errorf("creator has role %q, want owner", role)
+				}
+			},
+		},
+	})
+}
+
+func TestListOrganizations(t *testing.T) {
+	f := newOrgFixture(t)
+
+	runAPITests(t, http.MethodGet, "/orgs", ListOrganizations, []apiTest{
+		{
+			name: "member", path: "/orgs", token: f.viewerToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 1)
+				organization := res["organizations"].([]interface{})[0].(map[string]interface{})
+				wantField(t, organization, "id", f.organization.ID)
+				wantField(t, organization, "role", models.OrgRoleViewer)
+			},
+		},
+		{
+			name: "not a member", path: "/orgs", token: f.outsiderToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 0) },
+		},
+	})
+}
+
+func TestGetOrganization(t *testing.T) {
+	f := newOrgFixture(t)
+	sharedTwitterAccount(t, f.operator, f.organization)
+	path := "/orgs/" + f.organization.ID
+
+	runAPITests(t, http.MethodGet, "/orgs/:id", GetOrganization, []apiTest{
+		{name: "not a member", path: path, token: f.outsiderToken, status: http.StatusNotFound},
+		{name: "unknown organization", path: "/orgs/" + f.outsider.ID, token: f.ownerToken, status: http.StatusNotFound},
+		{
+			name: "viewer", path: path, token: f.viewerToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "role", models.OrgRoleViewer)
+				wantCount(t, res, "members", 4)
+				wantField(t, res, "twitter_accounts", 1)
+			},
+		},
+	})
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	f := newOrgFixture(t)
+	path := "/orgs/" + f.organization.ID
+	rename := map[string]string{"name": "Renamed"}
+
+	runAPITests(t, http.MethodPut, "/orgs/:id", UpdateOrganization, []apiTest{
+		{name: "not a member", path: path, token: f.outsiderToken, body: rename, status: http.StatusNotFound},
+		{name: "operator", path: path, token: f.operatorToken, body: rename, status: http.StatusForbidden},
+		{name: "no name", path: path, token: f.adminToken, body: map[string]string{}, status: http.StatusBadRequest},
+		{
+			name: "admin", path: path, token: f.adminToken, body: rename, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "name", "Renamed") },
+		},
+	})
+}
+
+func TestDeleteOrganization(t *testing.T) {
+	f := newOrgFixture(t)
+	account := sharedTwitterAccount(t, f.admin, f.organization)
+	path := "/orgs/" + f.organization.ID
+
+	runAPITests(t, http.MethodDelete, "/orgs/:id", DeleteOrganization, []apiTest{
+		{name: "admin", path: path, token: f.adminToken, status: http.StatusForbidden},
+		{
+			name: "owner", path: path, token: f.ownerToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantSharedWith(t, account.ID, nil)
+				var members int64
+				config.DB.Model(&models.OrganizationMember{}).Where("organization_id = ?", f.organization.ID).Count(&members)
+				if members != 0 {
+					t.Errorf("%d members left", members)
+				}
+			},
+		},
+		{name: "deleted", path: path, token: f.ownerToken, status: http.StatusNotFound},
+	})
+}
+
+func TestAddOrganizationMember(t *testing.T) {
+	f := newOrgFixture(t)
+	newcomer := testUser(t)
+	path := "/orgs/" + f.organization.ID + "/members"
+	add := func(email, role string) map[string]string {
+		return map[string]string{"email": email, "role": role}
+	}
+
+	runAPITests(t, http.MethodPost, "/orgs/:id/members", AddOrganizationMember, []apiTest{
+		{name: "operator", path: path, token: f.operatorToken, body: add(newcomer.Email, models.OrgRoleViewer), status: http.StatusForbidden},
+		{name: "unknown role", path: path, token: f.adminToken, body: add(newcomer.Email, "guest"), status: http.StatusBadRequest},
+		{name: "admin adding an owner", path: path, token: f.adminToken, body: add(newcomer.Email, models.OrgRoleOwner), status: http.StatusForbidden},
+		{name: "not signed up", path: path, token: f.adminToken, body: add("nobody-"+newcomer.ID+"@example.com", models.OrgRoleViewer), status: http.StatusNotFound},
+		{name: "already a member", path: path, token: f.adminToken, body: add(f.viewer.Email, models.OrgRoleOperator), status: http.StatusConflict},
+		{
+			name: "admin adding a viewer", path: path, token: f.adminToken, body: add(newcomer.Email, models.OrgRoleViewer), status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				if role, _ := utils.OrganizationRole(f.organization.ID, newcomer.ID); role != models.OrgRoleViewer {
+					t.Errorf("newcomer has role %q, want viewer", role)
+				}
+			},
+		},
+	})
+}
+
+func TestSetOrganizationMemberRole(t *testing.T) {
+	f := newOrgFixture(t)
+	member := func(user *models.User) string {
+		return "/orgs/" + f.organization.ID + "/members/" + user.ID
+	}
+	role := func(role string) map[string]string { return map[string]string{"role": role} }
+
+	runAPITests(t, http.MethodPut, "/orgs/:id/members/:user_id", SetOrganizationMemberRole, []apiTest{
+		{name: "viewer", path: member(f.operator), token: f.viewerToken, body: role(models.OrgRoleViewer), status: http.StatusForbidden},
+		{name: "not a member", path: member(f.outsider), token: f.adminToken, body: role(models.OrgRoleViewer), status: http.StatusNotFound},
+		{name: "admin making an owner", path: member(f.operator), token: f.adminToken, body: role(models.OrgRoleOwner), status: http.StatusForbidden},
+		{name: "admin demoting the owner", path: member(f.owner), token: f.adminToken, body: role(models.OrgRoleAdmin), status: http.StatusForbidden},
+		{name: "last owner stepping down", path: member(f.owner), token: f.ownerToken, body: role(models.OrgRoleAdmin), status: http.StatusBadRequest},
+		{
+			name: "admin promoting a viewer", path: member(f.viewer), token: f.adminToken, body: role(models.OrgRoleOperator), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "role", models.OrgRoleOperator) },
+		},
+		{name: "owner making an owner", path: member(f.admin), token: f.ownerToken, body: role(models.OrgRoleOwner), status: http.StatusOK},
+		{name: "owner stepping down with another owner", path: member(f.owner), token: f.ownerToken, body: role(models.OrgRoleAdmin), status: http.StatusOK},
+	})
+}
+
+func TestRemoveOrganizationMember(t *testing.T) {
+	f := newOrgFixture(t)
+	account := sharedTwitterAccount(t, f.operator, f.organization)
+	member := func(user *models.User) string {
+		return "/orgs/" + f.organization.ID + "/members/" + user.ID
+	}
+
+	runAPITests(t, http.MethodDelete, "/orgs/:id/members/:user_id", RemoveOrganizationMember, []apiTest{
+		{name: "viewer removing another member", path: member(f.operator), token: f.viewerToken, status: http.StatusForbidden},
+		{name: "admin removing the owner", path: member(f.owner), token: f.adminToken, status: http.StatusForbidden},
+		{name: "last owner leaving", path: member(f.owner), token: f.ownerToken, status: http.StatusBadRequest},
+		{name: "viewer leaving", path: member(f.viewer), token: f.viewerToken, status: http.StatusOK},
+		{
+			name: "admin removing an operator", path: member(f.operator), token: f.adminToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantSharedWith(t, account.ID, nil) },
+		},
+		{name: "removed already", path: member(f.operator), token: f.adminToken, status: http.StatusNotFound},
+	})
+}
+
+func TestShareAccount(t *testing.T) {
+	f := newOrgFixture(t)
+	account := testTwitterAccount(t, f.admin)
+	operatorAccount := testTwitterAccount(t, f.operator)
+	elsewhere := testOrganization(t, f.admin)
+	sharedElsewhere := sharedTwitterAccount(t, f.admin, elsewhere)
+	path := "/orgs/" + f.organization.ID + "/accounts"
+	share := func(id string) map[string]string { return map[string]string{"type": "twitter", "account_id": id} }
+
+	runAPITests(t, http.MethodPost, "/orgs/:id/accounts", ShareAccount, []apiTest{
+		{name: "operator", path: path, token: f.operatorToken, body: share(operatorAccount.ID), status: http.StatusForbidden},
+		{name: "unknown type", path: path, token: f.adminToken, body: map[string]string{"type": "telegram", "account_id": account.ID}, status: http.StatusBadRequest},
+		{name: "account of another user", path: path, token: f.adminToken, body: share(operatorAccount.ID), status: http.StatusNotFound},
+		{name: "shared with another organization", path: path, token: f.adminToken, body: share(sharedElsewhere.ID), status: http.StatusConflict},
+		{
+			name: "own account", path: path, token: f.adminToken, body: share(account.ID), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantSharedWith(t, account.ID, &f.organization.ID) },
+		},
+		{name: "shared again", path: path, token: f.adminToken, body: share(account.ID), status: http.StatusOK},
+	})
+}
+
+func TestUnshareAccount(t *testing.T) {
+	f := newOrgFixture(t)
+	adminAccount := sharedTwitterAccount(t, f.admin, f.organization)
+	operatorAccount := sharedTwitterAccount(t, f.operator, f.organization)
+	personal := testTwitterAccount(t, f.admin)
+	account := func(kind, id string) string {
+		return "/orgs/" + f.organization.ID + "/accounts/" + kind + "/" + id
+	}
+
+	runAPITests(t, http.MethodDelete, "/orgs/:id/accounts/:type/:account_id", UnshareAccount, []apiTest{
+		{name: "unknown type", path: account("telegram", adminAccount.ID), token: f.adminToken, status: http.StatusBadRequest},
+		{name: "not shared", path: account("twitter", personal.ID), token: f.adminToken, status: http.StatusNotFound},
+		{name: "operator unsharing another's account", path: account("twitter", adminAccount.ID), token: f.operatorToken, status: http.StatusForbidden},
+		{
+			name: "operator unsharing their own account", path: account("twitter", operatorAccount.ID), token: f.operatorToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantSharedWith(t, operatorAccount.ID, nil) },
+		},
+		{
+			name: "owner unsharing an admin's account", path: account("twitter", adminAccount.ID), token: f.ownerToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantSharedWith(t, adminAccount.ID, nil) },
+		},
+	})
+}
+
+func TestSetOrganizationTwoFactor(t *testing.T) {
+	f := newOrgFixture(t)
+	withTwoFactor(t, f.admin)
+	path := "/orgs/" + f.organization.ID + "/two-factor"
+	required := func(on bool) map[string]bool { return map[string]bool{"required": on} }
+
+	runAPITests(t, http.MethodPut, "/orgs/:id/two-factor", SetOrganizationTwoFactor, []apiTest{
+		{name: "operator", path: path, token: f.operatorToken, body: required(true), status: http.StatusForbidden},
+		{name: "no setting", path: path, token: f.adminToken, body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "owner without 2FA", path: path, token: f.ownerToken, body: required(true), status: http.StatusBadRequest},
+		{
+			name: "admin with 2FA", path: path, token: f.adminToken, body: required(true), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "require_two_factor", true)
+				wantField(t, res, "members_without_two_factor", 3)
+			},
+		},
+		{name: "owner without 2FA once required", path: path, token: f.ownerToken, body: required(false), status: http.StatusForbidden},
+		{
+			name: "admin lifting it", path: path, token: f.adminToken, body: required(false), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "require_two_factor", false) },
+		},
+	})
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quotaBalance is a user's balance of one quota type against their plan's allowance
+type quotaBalance struct {
+	QuotaType string `json:"quota_type"`
+	Balance   int    `json:"balance"`
+	Allowance int    `json:"allowance"`
+}
+
+// quotaStatus describes a user's plan, balances and next refill
+func quotaStatus(user models.User) (gin.H, error) {
+	plan, err := utils.UserPlan(user)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]quotaBalance, 0, len(models.AllQuotaTypes))
+	for _, quotaType := range models.AllQuotaTypes {
+		balances = append(balances, quotaBalance{
+			QuotaType: quotaType,
+			Balance:   utils.QuotaBalance(user, quotaType),
+			Allowance: plan.Allowance(quotaType),
+		})
+	}
+
+	return gin.H{
+		"plan":          plan,
+		"balances":      balances,
+		"next_reset_at": user.QuotaResetAt,
+	}, nil
+}
+
+// GetQuota godoc
+// @Summary      Get Quota Balances
+// @Description  Get your plan, the remaining balance of Twitter reads, Twitter writes and WhatsApp messages, and when they are next refilled. next_reset_at is null until the first refill is scheduled (requires JWT authentication)
+// @Tags         quota
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /quota [get]
+func GetQuota(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := quotaStatus(*user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetUsageLedger godoc
+// @Summary      Get Usage Ledger
+// @Description  List the debits and credits of your quota balances, newest first. Every entry has a kind (reserve, commit, refund, reset, topup), a reason such as the endpoint that was billed, and the balance it left (requires JWT authentication)
+// @Tags         quota
+// @Produce      json
+// @Security     BearerAuth
+// @Param        quota_type query string false "twitter_reads, twitter_writes or whatsapp_messages"
+// @Param        since query string false "Only entries after this time (RFC 3339)"
+// @Param        limit query int false "Maximum entries (default 100, max 1000)"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /quota/ledger [get]
+func GetUsageLedger(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", user.ID)
+
+	if quotaType := c.Query("quota_type"); quotaType != "" {
+		if !utils.IsQuotaType(quotaType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "quota_type must be twitter_reads, twitter_writes or whatsapp_messages"})
+			return
+		}
+		query = query.Where("quota_type = ?", quotaType)
+	}
+
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 time"})
+			return
+		}
+		query = query.Where("created_at > ?", t)
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	var entries []models.UsageLedgerEntry
+	if err := query.Order("created_at DESC").Limit(limit).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usage ledger"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "count": len(entries)})
+}
+
+// ListPlans godoc
+// @Summary      List Plans
+// @Description  List the subscription plans with the allowance each refills every period (requires JWT authentication)
+// @Tags         quota
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /plans [get]
+func ListPlans(c *gin.Context) {
+	if _, ok := authenticateUser(c); !ok {
+		return
+	}
+
+	var plans []models.Plan
+	if err := config.DB.Order("name ASC").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"plans": plans, "count": len(plans)})
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"testing"
+	"time"
+)
+
+func TestGetQuota(t *testing.T) {
+	user := testUser(t)
+	plan := testPlan(t, 300)
+	onPlan := testUser(t, func(u *models.User) { u.PlanID = &plan.ID })
+
+	runAPITests(t, http.MethodGet, "/quota", GetQuota, []apiTest{
+		{name: "no token", path: "/quota", status: http.StatusUnauthorized},
+		{
+			name: "default plan", path: "/quota", token: userToken(t, user), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["plan"].(map[string]interface{}), "name", models.DefaultPlanName)
+				wantCount(t, res, "balances", len(models.AllQuotaTypes))
+				wantBalance(t, res, models.QuotaTwitterReads, 100)
+				wantField(t, res, "next_reset_at", nil)
+			},
+		},
+		{
+			name: "assigned plan", path: "/quota", token: userToken(t, onPlan), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["plan"].(map[string]interface{}), "name", plan.Name)
+				balance := res["balances"].([]interface{})[0].(map[string]interface{})
+				wantField(t, balance, "allowance", 300)
+			},
+		},
+	})
+}
+
+func TestGetUsageLedger(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+	reservation, err := utils.ReserveQuota(user.ID, models.QuotaTwitterReads, 5, "GET /twitter/user")
+	if err != nil {
+		t.Fatalf("failed to reserve: %v", err)
+	}
+	reservation.Commit(3)
+	utils.CreditQuota(user.ID, models.QuotaWhatsApp, 10, models.LedgerTopUp, "support ticket")
+	testUser(t) // whose ledger is not listed
+	since := url.QueryEscape(time.Now().Add(time.Hour).Format(time.RFC3339))
+
+	runAPITests(t, http.MethodGet, "/quota/ledger", GetUsageLedger, []apiTest{
+		{name: "unknown quota type", path: "/quota/ledger?quota_type=emails", token: token, status: http.StatusBadRequest},
+		{name: "since is not a time", path: "/quota/ledger?since=yesterday", token: token, status: http.StatusBadRequest},
+		{
+			name: "every entry", path: "/quota/ledger", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 3) },
+		},
+		{
+			name: "one quota type", path: "/quota/ledger?quota_type=" + models.QuotaTwitterReads, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				commit := res["entries"].([]interface{})[0].(map[string]interface{})
+				wantField(t, commit, "kind", models.LedgerCommit)
+				wantField(t, commit, "amount", 2)
+				wantField(t, commit, "balance", 97)
+			},
+		},
+		{
+			name: "limit", path: "/quota/ledger?limit=1", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 1) },
+		},
+		{
+			name: "since the future", path: "/quota/ledger?since=" + since, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 0) },
+		},
+	})
+}
+
+func TestListPlans(t *testing.T) {
+	plan := testPlan(t, 10)
+
+	runAPITests(t, http.MethodGet, "/plans", ListPlans, []apiTest{
+		{name: "no token", path: "/plans", status: http.StatusUnauthorized},
+		{
+			name: "plans", path: "/plans", token: userToken(t, testUser(t)), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var count int64
+				config.DB.Model(&models.Plan{}).Count(&count)
+				wantField(t, res, "count", count)
+				found := false
+				for _, p := range res["plans"].([]interface{}) {
+					found = found || p.(map[string]interface{})["name"] == plan.Name
+				}
+				if !found {
+					t.Errorf("plan %s not listed", plan.Name)
+				}
+			},
+		},
+	})
+}
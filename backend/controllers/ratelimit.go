@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	utils_ratelimit "ripper-backend/utils/ratelimit"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits the requests a caller makes to a route group with a token
+// bucket sized by their plan. Callers are told apart by the JWT or Twitter
+// token they send, and anonymous callers by IP with the default plan's limit.
+// Every response carries the X-RateLimit-* headers, and a request over the
+// limit gets 429 with Retry-After.
+func RateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		caller := resolveAPICaller(c)
+		key := caller.key
+		if key == "" {
+			key = "ip:" + c.ClientIP()
+		}
+
+		result := utils_ratelimit.Take(group+":"+key, caller.limit)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded, slow down"})
+			return
+		}
+
+		c.Next()
+	}
+}
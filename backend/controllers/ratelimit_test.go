@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ripper-backend/config"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRateLimit(t *testing.T) {
+	user := testUser(t)
+	plan := testPlan(t, 100)
+	if err := config.DB.Model(plan).Updates(map[string]interface{}{"requests_per_minute": 1, "burst": 2}).Error; err != nil {
+		t.Fatalf("failed to set the plan's rate limit: %v", err)
+	}
+	if err := config.DB.Model(user).Update("plan_id", plan.ID).Error; err != nil {
+		t.Fatalf("failed to move the user to the plan: %v", err)
+	}
+	token := userToken(t, user)
+	account := testTwitterAccount(t, user)
+	forgetAPICallers()
+
+	// A group of its own, so buckets of other test runs don't count
+	router := gin.New()
+	router.Use(AuthenticateApiKeys(), RejectSuspended())
+	router.Group("/limited", RateLimit("test-"+uuid.NewString())).Any("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"pong": true})
+	})
+
+	tests := []struct {
+		name      string
+		method    string
+		token     string
+		status    int
+		limit     string // want X-RateLimit-Limit, any when empty
+		remaining string // want X-RateLimit-Remaining, any when empty
+	}{
+		{name: "first of the burst", token: token, status: http.StatusOK, limit: "1", remaining: "1"},
+		{name: "last of the burst", token: token, status: http.StatusOK, limit: "1", remaining: "0"},
+		{name: "over the limit", token: token, status: http.StatusTooManyRequests, limit: "1", remaining: "0"},
+		{name: "preflight", method: http.MethodOptions, token: token, status: http.StatusOK},
+		{name: "Twitter token of the user", token: account.Token, status: http.StatusOK, limit: "1", remaining: "1"},
+		{name: "another user", token: userToken(t, testUser(t)), status: http.StatusOK},
+		{name: "anonymous", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/limited/ping", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if method == http.MethodOptions {
+				if got := w.Header().Get("X-RateLimit-Limit"); got != "" {
+					t.Errorf("preflight limited to %s", got)
+				}
+				return
+			}
+			if tt.limit != "" && w.Header().Get("X-RateLimit-Limit") != tt.limit {
+				t.Errorf("X-RateLimit-Limit = %q, want %q", w.Header().Get("X-RateLimit-Limit"), tt.limit)
+			}
+			if tt.remaining != "" && w.Header().Get("X-RateLimit-Remaining") != tt.remaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", w.Header().Get("X-RateLimit-Remaining"), tt.remaining)
+			}
+			retryAfter := w.Header().Get("Retry-After")
+			if tt.status == http.StatusTooManyRequests && retryAfter != "60" {
+				t.Errorf("Retry-After = %q, want 60", retryAfter)
+			}
+			if tt.status == http.StatusOK && retryAfter != "" {
+				t.Errorf("Retry-After = %q on an allowed request", retryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/scheduler"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxThreadLength is the most tweets a scheduled thread may have
+const maxThreadLength = 25
+
+// ScheduleTweets godoc
+// @Summary      Schedule Tweets or a Thread
+// @Description  Schedule tweets to be published from one of your Twitter accounts, each at scheduled_at plus its delay_seconds and an optional random delay. With thread, the tweets are published in order and each replies to the previous one. Scheduled tweets share the WhatsApp scheduled-items listing, batch status, cancel and resume endpoints with channel "twitter". Each published tweet costs one Twitter request and counts toward the account's tweet or reply quota; a tweet over quota is retried later, and an expired session pauses the account's pending tweets (requires JWT authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.ScheduleTweetsRequest true "Tweets and schedule"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/scheduled [post]
+func ScheduleTweets(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.ScheduleTweetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Thread && len(req.Tweets) > maxThreadLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("a thread can have at most %d tweets", maxThreadLength)})
+		return
+	}
+
+	tweets := make([]scheduler.ScheduledTweet, 0, len(req.Tweets))
+	for i, t := range req.Tweets {
+		text := strings.TrimSpace(t.Text)
+		if text == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("tweet %d is empty", i+1)})
+			return
+		}
+		if utf8.RuneCountInString(text) > maxTweetLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("tweet %d is longer than %d characters", i+1, maxTweetLength)})
+			return
+		}
+		tweets = append(tweets, scheduler.ScheduledTweet{Text: text, DelaySeconds: t.DelaySeconds})
+	}
+
+	opts := scheduler.TweetScheduleOptions{
+		Thread:         req.Thread,
+		RandomDelayMin: req.RandomDelayMin,
+		RandomDelayMax: req.RandomDelayMax,
+	}
+	if req.ScheduledAt != nil {
+		opts.StartAt = *req.ScheduledAt
+	}
+	if req.ReplyTo != "" {
+		opts.InReplyToTweetID = utils_twitter.ExtractTweetID(req.ReplyTo)
+		if opts.InReplyToTweetID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reply_to tweet URL"})
+			return
+		}
+	}
+
+	var account models.TwitterAccount
+	query := config.DB.Scopes(utils.Accessible(user.ID, models.OrgRoleOperator))
+	if req.Username != "" {
+		query = query.Where("username = ?", strings.TrimPrefix(req.Username, "@"))
+	}
+	if err := query.Order("username").First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
+		return
+	}
+
+	batchID, items, err := scheduler.GlobalScheduler.ScheduleTweets(user.ID, &account, tweets, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	firstAt := time.Time{}
+	for _, item := range items {
+		if firstAt.IsZero() || item.ScheduledAt.Before(firstAt) {
+			firstAt = item.ScheduledAt
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"batch_id": batchID,
+		"username": account.Username,
+		"thread":   req.Thread,
+		"total":    len(items),
+		"first_at": firstAt,
+		"tweets":   items,
+		"message":  "Tweets scheduled successfully",
+	})
+}
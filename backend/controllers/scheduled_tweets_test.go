@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/scheduler"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var setUpTestScheduler sync.Once
+
+// testScheduler points scheduler.GlobalScheduler at the test database without
+// starting its worker, so scheduled items stay pending
+func testScheduler(t *testing.T) {
+	t.Helper()
+
+	setUpTestScheduler.Do(func() {
+		if err := config.DB.AutoMigrate(&models.ScheduledMessage{}); err != nil {
+			t.Fatalf("failed to migrate the scheduled messages: %v", err)
+		}
+		scheduler.GlobalScheduler = scheduler.NewMessageScheduler(config.DB)
+	})
+}
+
+// scheduledBatch returns the stored items of a batch in order
+func scheduledBatch(t *testing.T, batchID string) []models.ScheduledMessage {
+	t.Helper()
+
+	var items []models.ScheduledMessage
+	if err := config.DB.Where("batch_id = ?", batchID).Order("sequence_number").Find(&items).Error; err != nil {
+		t.Fatalf("failed to fetch the batch: %v", err)
+	}
+	return items
+}
+
+func TestScheduleTweets(t *testing.T) {
+	f := newOrgFixture(t)
+	testScheduler(t)
+	account := sharedTwitterAccount(t, f.owner, f.organization)
+	tweets := func(texts ...string) []map[string]interface{} {
+		list := make([]map[string]interface{}, len(texts))
+		for i, text := range texts {
+			list[i] = map[string]interface{}{"text": text, "delay_seconds": 60 * i}
+		}
+		return list
+	}
+	tooLong := make([]string, maxThreadLength+1)
+	for i := range tooLong {
+		tooLong[i] = "tweet"
+	}
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	runAPITests(t, http.MethodPost, "/twitter/scheduled", ScheduleTweets, []apiTest{
+		{name: "no tweets", path: "/twitter/scheduled", token: f.operatorToken, body: map[string]interface{}{"username": account.Username}, status: http.StatusBadRequest},
+		{
+			name: "empty tweet", path: "/twitter/scheduled", token: f.operatorToken,
+			body: map[string]interface{}{"username": account.Username, "tweets": tweets("gm", " ")}, status: http.StatusBadRequest,
+		},
+		{
+			name: "tweet too long", path: "/twitter/scheduled", token: f.operatorToken,
+			body: map[string]interface{}{"username": account.Username, "tweets": tweets(strings.Repeat("a", maxTweetLength+1))}, status: http.StatusBadRequest,
+		},
+		{
+			name: "thread too long", path: "/twitter/scheduled", token: f.operatorToken,
+			body: map[string]interface{}{"username": account.Username, "thread": true, "tweets": tweets(tooLong...)}, status: http.StatusBadRequest,
+		},
+		{
+			name: "invalid reply_to", path: "/twitter/scheduled", token: f.operatorToken,
+			body: map[string]interface{}{"username": account.Username, "reply_to": "https://x.com/someone", "tweets": tweets("gm")}, status: http.StatusBadRequest,
+		},
+		{
+			name: "viewer of the organization", path: "/twitter/scheduled", token: f.viewerToken,
+			body: map[string]interface{}{"username": account.Username, "tweets": tweets("gm")}, status: http.StatusNotFound,
+		},
+		{
+			name: "thread by an operator of the organization", path: "/twitter/scheduled", token: f.operatorToken,
+			body: map[string]interface{}{
+				"username": "@" + account.Username, "thread": true, "reply_to": "https://x.com/someone/status/1234567890",
+				"scheduled_at": startAt, "tweets": tweets(" first ", "second"),
+			},
+			status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "username", account.Username)
+				wantField(t, res, "total", 2)
+				wantField(t, res, "first_at", startAt)
+				items := scheduledBatch(t, res["batch_id"].(string))
+				if len(items) != 2 {
+					t.Fatalf("stored %d tweets, want 2", len(items))
+				}
+				first, second := items[0], items[1]
+				if first.Message != "first" || first.InReplyToTweetID != "1234567890" || !first.IsThread {
+					t.Errorf("first tweet %q replies to %q in a thread %v", first.Message, first.InReplyToTweetID, first.IsThread)
+				}
+				if second.InReplyToTweetID != "" || !second.ScheduledAt.Equal(startAt.Add(time.Minute)) {
+					t.Errorf("second tweet replies to %q at %v", second.InReplyToTweetID, second.ScheduledAt)
+				}
+				for _, item := range items {
+					if item.Channel != models.ChannelTwitter || item.Status != "pending" || item.TwitterAccountID == nil || *item.TwitterAccountID != account.ID {
+						t.Errorf("tweet %d stored on %s as %s", item.SequenceNumber, item.Channel, item.Status)
+					}
+					if item.OrganizationID == nil || *item.OrganizationID != f.organization.ID {
+						t.Errorf("tweet %d not shared with the organization", item.SequenceNumber)
+					}
+				}
+			},
+		},
+	})
+}
+
+func TestScheduleTweetsDefaultAccount(t *testing.T) {
+	user := testUser(t)
+	testScheduler(t)
+	first, second := testTwitterAccount(t, user), testTwitterAccount(t, user)
+	if second.Username < first.Username {
+		first = second
+	}
+
+	runAPITests(t, http.MethodPost, "/twitter/scheduled", ScheduleTweets, []apiTest{
+		{
+			name: "no account", path: "/twitter/scheduled", token: userToken(t, testUser(t)),
+			body: map[string]interface{}{"tweets": []map[string]string{{"text": "gm"}}}, status: http.StatusNotFound,
+		},
+		{
+			name: "first account by username", path: "/twitter/scheduled", token: userToken(t, user),
+			body: map[string]interface{}{"tweets": []map[string]string{{"text": "gm"}}}, status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "username", first.Username) },
+		},
+	})
+}
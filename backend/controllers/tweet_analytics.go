@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_cache "ripper-backend/utils/cache"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tweetAnalyticsOp is the cache operation of computed reply and quote analytics
+const tweetAnalyticsOp = "TweetAnalytics"
+
+// analyticsSources maps each analytics source to the operation that scrapes it
+var analyticsSources = map[string]string{
+	"replies": utils_twitter.OpTweetDetail,
+	"quotes":  utils_twitter.OpSearch,
+}
+
+// GetTweetAnalytics godoc
+// @Summary      Analyze Replies and Quotes
+// @Description  Compute top keywords, hashtags, mentioned accounts, emoji, languages and a lexicon-based sentiment score over a tweet's replies and/or quotes, offline. Scrapes are shared with /twitter/post/comments and /twitter/post/quotes through the response cache, and the analytics themselves are cached too. Costs one Twitter request per scraped source, or the cache-hit cost when cached (requires Twitter token authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetAnalyticsRequest true "Tweet URL, sources and list size"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
+// @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/post/analytics [post]
+func GetTweetAnalytics(c *gin.Context) {
+	startTime := time.Now()
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", "/twitter/post/analytics", startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkTwitterScope(twitterAccount, models.TwitterScopeRead); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/analytics", startTime, false, http.StatusForbidden, err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.TweetAnalyticsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/analytics", startTime, false, http.StatusBadRequest, err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweetID := utils_twitter.ExtractTweetID(req.URL)
+	if tweetID == "" {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/analytics", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
+		return
+	}
+
+	// Normalise sources to a fixed order so equal requests share a cache entry
+	sources := []string{}
+	for _, source := range []string{"replies", "quotes"} {
+		wanted := len(req.Sources) == 0
+		for _, s := range req.Sources {
+			wanted = wanted || s == source
+		}
+		if wanted {
+			sources = append(sources, source)
+		}
+	}
+	top := req.Top
+	if top <= 0 {
+		top = 20
+	}
+	if top > 100 {
+		top = 100
+	}
+
+	// Computed analytics are cached per user, tweet, sources and list size
+	analyticsKey := utils_cache.Key(twitterAccount.UserID, tweetAnalyticsOp, tweetID, strings.Join(sources, ",")+":"+strconv.Itoa(top))
+	var analytics utils.TweetAnalytics
+	cached := !wantsFreshData(c) && twitterCacheTTLs[tweetAnalyticsOp] > 0 && utils_cache.Get(analyticsKey, &analytics)
+	cost := setCacheHeader(c, tweetAnalyticsOp, cached)
+
+	// Otherwise each source is a cache hit or a scrape of its own
+	scraped := map[string][]*utils_twitter.Tweet{}
+	if !cached {
+		cost = 0
+		for _, source := range sources {
+			var tweets []*utils_twitter.Tweet
+			if getCachedTwitterData(c, twitterAccount.UserID, analyticsSources[source], tweetID, "", &tweets) {
+				scraped[source] = tweets
+				cost += utils.TwitterCacheHitCost
+			} else {
+				cost += utils.TwitterRequestCost(analyticsSources[source])
+			}
+		}
+	}
+
+	reservation, err := utils.ReserveTwitterRequests(twitterAccount.UserID, cost, "/twitter/post/analytics")
+	if err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/analytics", startTime, false, http.StatusTooManyRequests, err.Error())
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
+	usedAccount := twitterAccount
+	if !cached {
+		for _, source := range sources {
+			if _, ok := scraped[source]; ok {
+				continue
+			}
+
+			var tweets []*utils_twitter.Tweet
+			var status, pages int
+			usedAccount, status, err = runWithTwitterSession(c, twitterAccount, analyticsSources[source], func(session *utils_twitter.Session) error {
+				var err error
+				if source == "replies" {
+					tweets, err = session.GetAllTweetReplies(tweetID)
+				} else {
+					tweets, err = session.SearchQuotedTweets(tweetID)
+				}
+				pages = session.Pages(analyticsSources[source])
+				return err
+			})
+			if err != nil {
+				reservation.Refund()
+				errMsg := err.Error()
+				if status == http.StatusInternalServerError {
+					errMsg = "Failed to fetch " + source
+				}
+				utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/post/analytics", startTime, false, status, errMsg)
+				c.JSON(status, gin.H{"error": errMsg})
+				return
+			}
+
+			// Pages after the first cost extra on top of the reserved estimate
+			cost += utils.TwitterScrapeCost(analyticsSources[source], pages) - utils.TwitterRequestCost(analyticsSources[source])
+			setCachedTwitterData(twitterAccount.UserID, analyticsSources[source], tweetID, "", tweets)
+			engagementType := models.EngagementReplied
+			if source == "quotes" {
+				engagementType = models.EngagementQuoted
+			}
+			go utils.SaveEngagementTweets(twitterAccount.UserID, tweetID, engagementType, tweets)
+			scraped[source] = tweets
+		}
+
+		var all []*utils_twitter.Tweet
+		for _, source := range sources {
+			all = append(all, scraped[source]...)
+		}
+		analytics = utils.AnalyzeTweets(all, top)
+		utils_cache.Set(analyticsKey, analytics, twitterCacheTTLs[tweetAnalyticsOp])
+	}
+
+	reservation.Commit(cost)
+	utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/post/analytics", startTime, true, http.StatusOK, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"tweet_id":  tweetID,
+		"sources":   sources,
+		"analytics": analytics,
+	})
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+)
+
+func TestGetTweetAnalytics(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	writeOnly := testTwitterAccount(t, user)
+	withTwitterScopes(t, writeOnly, models.TwitterScopeWrite)
+	broke := testUser(t)
+	brokeAccount := testTwitterAccount(t, broke)
+	withBalance(t, broke, "twitter_reqs", 0)
+	reads := loadUser(t, user.ID).TwitterReqs
+
+	// The replies are cached, so the analytics are computed without reaching X
+	tweetID := testTwitterID()
+	setCachedTwitterData(user.ID, utils_twitter.OpTweetDetail, tweetID, "", []*utils_twitter.Tweet{
+		{ID: testTwitterID(), Text: "@author Love the new #Feature", Lang: "en"},
+		{ID: testTwitterID(), Text: "@author #feature is not good", Lang: "en"},
+	})
+	url := "https://x.com/author/status/" + tweetID
+	replies := map[string]interface{}{"url": url, "sources": []string{"replies"}}
+
+	runAPITests(t, http.MethodPost, "/twitter/post/analytics", GetTweetAnalytics, []apiTest{
+		{name: "no token", path: "/twitter/post/analytics", body: replies, status: http.StatusUnauthorized},
+		{name: "token without the read scope", path: "/twitter/post/analytics", token: writeOnly.Token, body: replies, status: http.StatusForbidden},
+		{
+			name: "unknown source", path: "/twitter/post/analytics", token: account.Token,
+			body: map[string]interface{}{"url": url, "sources": []string{"likes"}}, status: http.StatusBadRequest,
+		},
+		{name: "invalid tweet URL", path: "/twitter/post/analytics", token: account.Token, body: map[string]string{"url": "https://x.com/author"}, status: http.StatusBadRequest},
+		{name: "no Twitter reads left", path: "/twitter/post/analytics", token: brokeAccount.Token, body: replies, status: http.StatusTooManyRequests},
+		{
+			name: "cached replies", path: "/twitter/post/analytics", token: account.Token, body: replies, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "tweet_id", tweetID)
+				wantField(t, res, "sources", []string{"replies"})
+				analytics := res["analytics"].(map[string]interface{})
+				wantField(t, analytics, "tweet_count", 2)
+				wantField(t, analytics, "hashtags", []utils.TermCount{{Term: "#feature", Count: 2}})
+				if left := loadUser(t, user.ID).TwitterReqs; left != reads-utils.TwitterCacheHitCost {
+					t.Errorf("%d Twitter reads left, want %d", left, reads-utils.TwitterCacheHitCost)
+				}
+			},
+		},
+		{
+			name: "cached analytics", path: "/twitter/post/analytics", token: account.Token, body: replies, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["analytics"].(map[string]interface{}), "tweet_count", 2)
+				if left := loadUser(t, user.ID).TwitterReqs; left != reads-2*utils.TwitterCacheHitCost {
+					t.Errorf("%d Twitter reads left, want %d", left, reads-2*utils.TwitterCacheHitCost)
+				}
+			},
+		},
+	})
+}
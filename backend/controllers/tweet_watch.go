@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tweetWatchMetrics are the counts stored in every snapshot
+var tweetWatchMetrics = []string{"likes", "retweets", "replies", "quotes", "views"}
+
+// tweetWatchPoint is one point of a growth curve
+type tweetWatchPoint struct {
+	models.TweetSnapshot
+	Delta   map[string]int     `json:"delta"`    // change since the previous snapshot
+	PerHour map[string]float64 `json:"per_hour"` // change per hour since the previous snapshot
+}
+
+// findTweetWatch loads a watch owned by user, writing the 404 itself
+func findTweetWatch(c *gin.Context, user *models.User) (*models.TweetWatch, bool) {
+	var watch models.TweetWatch
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&watch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet watch not found"})
+		return nil, false
+	}
+	return &watch, true
+}
+
+// CreateTweetWatch godoc
+// @Summary      Watch a Tweet
+// @Description  Snapshot a tweet's like, retweet, reply, quote and view counts every interval_minutes for duration_hours. Alerts are sent over the websocket once a metric reaches its threshold. The engager sets listed in engagers are diffed on every snapshot and new engagers are sent as new_engager events. Each snapshot and each engager diff costs one Twitter request (requires JWT authentication)
+// @Tags         watch
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.CreateTweetWatchRequest true "Tweet URL, interval, duration and alerts"
+// @Success      201 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/watches [post]
+func CreateTweetWatch(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.CreateTweetWatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweetID := utils_twitter.NormalizeTweetID(req.URL)
+	if tweetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
+		return
+	}
+
+	if req.IntervalMinutes == 0 {
+		req.IntervalMinutes = 60
+	}
+	if req.DurationHours == 0 {
+		req.DurationHours = 24
+	}
+	if req.IntervalMinutes < 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval_minutes must be at least 5"})
+		return
+	}
+	if req.DurationHours < 1 || req.DurationHours > 720 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration_hours must be between 1 and 720"})
+		return
+	}
+
+	if req.WebhookURL != "" {
+		if err := utils.ValidateWebhookURL(req.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var account models.TwitterAccount
+	query := config.DB.Scopes(utils.Accessible(user.ID, models.OrgRoleOperator))
+	if req.Username != "" {
+		query = query.Where("username = ?", req.Username)
+	}
+	if err := query.Order("username").First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
+		return
+	}
+
+	now := time.Now()
+	watch := models.TweetWatch{
+		UserID:           user.ID,
+		TwitterAccountID: account.ID,
+		TweetID:          tweetID,
+		TweetURL:         req.URL,
+		IntervalMinutes:  req.IntervalMinutes,
+		EndsAt:           now.Add(time.Duration(req.DurationHours) * time.Hour),
+		NextRunAt:        now,
+		Status:           "active",
+		Engagers:         strings.Join(req.Engagers, ","),
+		WebhookURL:       req.WebhookURL,
+	}
+	if err := config.DB.Create(&watch).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tweet watch"})
+		return
+	}
+
+	alerts := make([]models.TweetWatchAlert, 0, len(req.Alerts))
+	for _, a := range req.Alerts {
+		alerts = append(alerts, models.TweetWatchAlert{WatchID: watch.ID, Metric: a.Metric, Threshold: a.Threshold})
+	}
+	if len(alerts) > 0 {
+		if err := config.DB.Create(&alerts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alerts"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"watch":    watch,
+		"alerts":   alerts,
+		"username": account.Username,
+		"message":  "Tweet watch created",
+	})
+}
+
+// ListTweetWatches godoc
+// @Summary      List Tweet Watches
+// @Description  List your tweet watches, newest first (requires JWT authentication)
+// @Tags         watch
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status query string false "active, paused, completed or cancelled"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/watches [get]
+func ListTweetWatches(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", user.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var watches []models.TweetWatch
+	if err := query.Order("created_at DESC").Find(&watches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tweet watches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watches": watches, "count": len(watches)})
+}
+
+// GetTweetWatch godoc
+// @Summary      Get Tweet Watch
+// @Description  Get a tweet watch with its alerts and latest snapshot (requires JWT authentication)
+// @Tags         watch
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Watch ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /twitter/watches/{id} [get]
+func GetTweetWatch(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := findTweetWatch(c, user)
+	if !ok {
+		return
+	}
+
+	var alerts []models.TweetWatchAlert
+	config.DB.Where("watch_id = ?", watch.ID).Order("created_at").Find(&alerts)
+
+	var latest *models.TweetSnapshot
+	var snap models.TweetSnapshot
+	if err := config.DB.Where("watch_id = ?", watch.ID).Order("captured_at DESC").First(&snap).Error; err == nil {
+		latest = &snap
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watch": watch, "alerts": alerts, "latest": latest})
+}
+
+// GetTweetWatchSeries godoc
+// @Summary      Get Tweet Growth Curve
+// @Description  Get the snapshots of a tweet watch in time order, with the change and hourly rate of every metric since the previous snapshot (requires JWT authentication)
+// @Tags         watch
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Watch ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/watches/{id}/series [get]
+func GetTweetWatchSeries(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := findTweetWatch(c, user)
+	if !ok {
+		return
+	}
+
+	var snapshots []models.TweetSnapshot
+	if err := config.DB.Where("watch_id = ?", watch.ID).Order("captured_at ASC").Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch snapshots"})
+		return
+	}
+
+	points := make([]tweetWatchPoint, 0, len(snapshots))
+	for i, snap := range snapshots {
+		point := tweetWatchPoint{TweetSnapshot: snap, Delta: map[string]int{}, PerHour: map[string]float64{}}
+		if i > 0 {
+			prev := snapshots[i-1]
+			hours := snap.CapturedAt.Sub(prev.CapturedAt).Hours()
+			for _, metric := range tweetWatchMetrics {
+				delta := snap.Value(metric) - prev.Value(metric)
+				point.Delta[metric] = delta
+				if hours > 0 {
+					point.PerHour[metric] = float64(delta) / hours
+				}
+			}
+		}
+		points = append(points, point)
+	}
+
+	growth := map[string]int{}
+	if len(snapshots) > 1 {
+		first, last := snapshots[0], snapshots[len(snapshots)-1]
+		for _, metric := range tweetWatchMetrics {
+			growth[metric] = last.Value(metric) - first.Value(metric)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"watch":  watch,
+		"points": points,
+		"growth": growth,
+		"count":  len(points),
+	})
+}
+
+// AddTweetWatchAlert godoc
+// @Summary      Add Tweet Watch Alert
+// @Description  Add a threshold alert to a tweet watch (requires JWT authentication)
+// @Tags         watch
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Watch ID"
+// @Param        request body schemas.TweetWatchAlertRequest true "Metric and threshold"
+// @Success      201 {object} models.TweetWatchAlert
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/watches/{id}/alerts [post]
+func AddTweetWatchAlert(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := findTweetWatch(c, user)
+	if !ok {
+		return
+	}
+
+	var req schemas.TweetWatchAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alert := models.TweetWatchAlert{WatchID: watch.ID, Metric: req.Metric, Threshold: req.Threshold}
+	if err := config.DB.Create(&alert).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create alert"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, alert)
+}
+
+// ResumeTweetWatch godoc
+// @Summary      Resume Tweet Watch
+// @Description  Resume a tweet watch that was paused, e.g. after running out of Twitter requests (requires JWT authentication)
+// @Tags         watch
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Watch ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /twitter/watches/{id}/resume [post]
+func ResumeTweetWatch(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := findTweetWatch(c, user)
+	if !ok {
+		return
+	}
+
+	if watch.Status != "paused" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only paused watches can be resumed"})
+		return
+	}
+	if time.Now().After(watch.EndsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tweet watch has already ended"})
+		return
+	}
+
+	config.DB.Model(watch).Updates(map[string]interface{}{"status": "active", "next_run_at": time.Now(), "last_error": ""})
+	c.JSON(http.StatusOK, gin.H{"message": "Tweet watch resumed"})
+}
+
+// CancelTweetWatch godoc
+// @Summary      Cancel Tweet Watch
+// @Description  Stop taking snapshots of a tweet. Snapshots taken so far are kept (requires JWT authentication)
+// @Tags         watch
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Watch ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /twitter/watches/{id} [delete]
+func CancelTweetWatch(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := findTweetWatch(c, user)
+	if !ok {
+		return
+	}
+
+	config.DB.Model(watch).Update("status", "cancelled")
+	c.JSON(http.StatusOK, gin.H{"message": "Tweet watch cancelled"})
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"testing"
+	"time"
+)
+
+// testTweetWatch creates a watch of the user's account with a status, ending at endsAt
+func testTweetWatch(t *testing.T, user *models.User, account *models.TwitterAccount, status string, endsAt time.Time) *models.TweetWatch {
+	t.Helper()
+
+	watch := &models.TweetWatch{
+		UserID: user.ID, TwitterAccountID: account.ID, TweetID: "1", IntervalMinutes: 60,
+		EndsAt: endsAt, NextRunAt: time.Now(), Status: status,
+	}
+	if err := config.DB.Create(watch).Error; err != nil {
+		t.Fatalf("failed to create the tweet watch: %v", err)
+	}
+	return watch
+}
+
+func TestCreateTweetWatch(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	token := userToken(t, user)
+	url := "https://x.com/someone/status/1234567890"
+
+	runAPITests(t, http.MethodPost, "/twitter/watches", CreateTweetWatch, []apiTest{
+		{name: "no account", path: "/twitter/watches", token: userToken(t, testUser(t)), body: map[string]string{"url": url}, status: http.StatusNotFound},
+		{name: "invalid tweet URL", path: "/twitter/watches", token: token, body: map[string]string{"url": "https://x.com/someone"}, status: http.StatusBadRequest},
+		{
+			name: "interval too short", path: "/twitter/watches", token: token,
+			body: map[string]interface{}{"url": url, "interval_minutes": 1}, status: http.StatusBadRequest,
+		},
+		{
+			name: "duration too long", path: "/twitter/watches", token: token,
+			body: map[string]interface{}{"url": url, "duration_hours": 1000}, status: http.StatusBadRequest,
+		},
+		{
+			name: "unknown alert metric", path: "/twitter/watches", token: token,
+			body: map[string]interface{}{"url": url, "alerts": []map[string]interface{}{{"metric": "bookmarks", "threshold": 10}}}, status: http.StatusBadRequest,
+		},
+		{
+			name: "internal webhook", path: "/twitter/watches", token: token,
+			body: map[string]interface{}{"url": url, "webhook_url": "http://127.0.0.1/hook"}, status: http.StatusBadRequest,
+		},
+		{
+			name: "watch with defaults", path: "/twitter/watches", token: token,
+			body: map[string]interface{}{
+				"url": url, "engagers": []string{"liked", "replied"},
+				"alerts": []map[string]interface{}{{"metric": "likes", "threshold": 100}},
+			},
+			status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				watch := res["watch"].(map[string]interface{})
+				wantField(t, watch, "tweet_id", "1234567890")
+				wantField(t, watch, "interval_minutes", 60)
+				wantField(t, watch, "engagers", "liked,replied")
+				wantField(t, watch, "twitter_account_id", account.ID)
+				wantCount(t, res, "alerts", 1)
+			},
+		},
+	})
+}
+
+func TestListTweetWatches(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	testTweetWatch(t, user, account, "active", time.Now().Add(time.Hour))
+	testTweetWatch(t, user, account, "cancelled", time.Now().Add(time.Hour))
+	token := userToken(t, user)
+
+	runAPITests(t, http.MethodGet, "/twitter/watches", ListTweetWatches, []apiTest{
+		{
+			name: "every watch", path: "/twitter/watches", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 2) },
+		},
+		{
+			name: "active watches", path: "/twitter/watches?status=active", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 1) },
+		},
+		{
+			name: "watches of another user", path: "/twitter/watches", token: userToken(t, testUser(t)), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 0) },
+		},
+	})
+}
+
+func TestGetTweetWatch(t *testing.T) {
+	user := testUser(t)
+	watch := testTweetWatch(t, user, testTwitterAccount(t, user), "active", time.Now().Add(time.Hour))
+	start := time.Now().Add(-2 * time.Hour)
+	config.DB.Create(&[]models.TweetSnapshot{
+		{WatchID: watch.ID, TweetID: "1", Likes: 10, Views: 100, CapturedAt: start},
+		{WatchID: watch.ID, TweetID: "1", Likes: 30, Views: 400, CapturedAt: start.Add(2 * time.Hour)},
+	})
+	config.DB.Create(&models.TweetWatchAlert{WatchID: watch.ID, Metric: "likes", Threshold: 20})
+	token := userToken(t, user)
+
+	runAPITests(t, http.MethodGet, "/twitter/watches/:id", GetTweetWatch, []apiTest{
+		{name: "watch of another user", path: "/twitter/watches/" + watch.ID, token: userToken(t, testUser(t)), status: http.StatusNotFound},
+		{
+			name: "watch", path: "/twitter/watches/" + watch.ID, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantCount(t, res, "alerts", 1)
+				wantField(t, res["latest"].(map[string]interface{}), "likes", 30)
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodGet, "/twitter/watches/:id/series", GetTweetWatchSeries, []apiTest{
+		{
+			name: "growth curve", path: "/twitter/watches/" + watch.ID + "/series", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				wantField(t, res["growth"].(map[string]interface{}), "likes", 20)
+				second := res["points"].([]interface{})[1].(map[string]interface{})
+				wantField(t, second["delta"].(map[string]interface{}), "views", 300)
+				wantField(t, second["per_hour"].(map[string]interface{}), "views", 150)
+			},
+		},
+	})
+}
+
+func TestAddTweetWatchAlert(t *testing.T) {
+	user := testUser(t)
+	watch := testTweetWatch(t, user, testTwitterAccount(t, user), "active", time.Now().Add(time.Hour))
+	path := "/twitter/watches/" + watch.ID + "/alerts"
+	token := userToken(t, user)
+
+	runAPITests(t, http.MethodPost, "/twitter/watches/:id/alerts", AddTweetWatchAlert, []apiTest{
+		{name: "no threshold", path: path, token: token, body: map[string]interface{}{"metric": "likes"}, status: http.StatusBadRequest},
+		{
+			name: "alert", path: path, token: token, body: map[string]interface{}{"metric": "views", "threshold": 1000}, status: http.StatusCreated,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "watch_id", watch.ID)
+				wantField(t, res, "threshold", 1000)
+			},
+		},
+	})
+}
+
+func TestResumeAndCancelTweetWatch(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	paused := testTweetWatch(t, user, account, "paused", time.Now().Add(time.Hour))
+	ended := testTweetWatch(t, user, account, "paused", time.Now().Add(-time.Hour))
+	active := testTweetWatch(t, user, account, "active", time.Now().Add(time.Hour))
+	token := userToken(t, user)
+	resume := func(watch *models.TweetWatch) string { return "/twitter/watches/" + watch.ID + "/resume" }
+
+	runAPITests(t, http.MethodPost, "/twitter/watches/:id/resume", ResumeTweetWatch, []apiTest{
+		{name: "active", path: resume(active), token: token, status: http.StatusBadRequest},
+		{name: "ended", path: resume(ended), token: token, status: http.StatusBadRequest},
+		{
+			name: "paused", path: resume(paused), token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var stored models.TweetWatch
+				config.DB.Where("id = ?", paused.ID).First(&stored)
+				if stored.Status != "active" {
+					t.Errorf("watch is %s, want active", stored.Status)
+				}
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodDelete, "/twitter/watches/:id", CancelTweetWatch, []apiTest{
+		{name: "watch of another user", path: "/twitter/watches/" + active.ID, token: userToken(t, testUser(t)), status: http.StatusNotFound},
+		{
+			name: "cancel", path: "/twitter/watches/" + active.ID, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var stored models.TweetWatch
+				config.DB.Where("id = ?", active.ID).First(&stored)
+				if stored.Status != "cancelled" {
+					t.Errorf("watch is %s, want cancelled", stored.Status)
+				}
+			},
+		},
+	})
+}
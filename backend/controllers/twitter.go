@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"ripper-backend/config"
@@ -9,6 +10,7 @@ import (
 	"ripper-backend/utils"
 	utils_twitter "ripper-backend/utils/twitter"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +18,10 @@ import (
 )
 
 func authenticateTwitterToken(c *gin.Context) (*models.TwitterAccount, error) {
+	if apiKey, ok := requestApiKey(c); ok {
+		return apiKeyTwitterAccount(c, apiKey)
+	}
+
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 		return nil, fmt.Errorf("missing or invalid token")
@@ -30,70 +36,33 @@ func authenticateTwitterToken(c *gin.Context) (*models.TwitterAccount, error) {
 	return &twitterAccount, nil
 }
 
-// Helper function to handle Twitter API calls with logging
-func handleTwitterAPICall(
-	c *gin.Context,
-	endpoint string,
-	requestHandler func(*models.TwitterAccount, schemas.GetTweetsRequest) (interface{}, error),
-) {
-	startTime := time.Now()
-
-	twitterAccount, err := authenticateTwitterToken(c)
-	if err != nil {
-		utils.LogTwitterAPICall(c, "", "", endpoint, startTime, false, http.StatusUnauthorized, err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check rate limit
-	if err := utils.CheckTwitterRateLimit(twitterAccount.UserID); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusTooManyRequests, err.Error())
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
-		return
-	}
-
-	var req schemas.GetTweetsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusBadRequest, err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err = utils_twitter.LoadTokensForAccount(twitterAccount.UserID, twitterAccount.ID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusUnauthorized, "No valid Twitter session found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid Twitter session found"})
-		return
-	}
-
-	if !utils_twitter.ValidateSession() {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusUnauthorized, "Twitter session expired")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Twitter session expired"})
-		return
-	}
-
-	tweetID := utils_twitter.ExtractTweetID(req.URL)
-	if tweetID == "" {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusBadRequest, "Invalid tweet URL")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
-		return
+// checkTwitterScope returns an error when the account's token lacks scope
+func checkTwitterScope(twitterAccount *models.TwitterAccount, scope string) error {
+	if !twitterAccount.HasScope(scope) {
+		return fmt.Errorf("Twitter token is missing the %s scope", scope)
 	}
+	return nil
+}
 
-	// Call the specific request handler
-	result, err := requestHandler(twitterAccount, req)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusInternalServerError, err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// twitterScopesFromRequest validates the scopes asked for a token and joins
+// them for storage. No scopes means every scope.
+func twitterScopesFromRequest(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		scopes = models.AllTwitterScopes
+	}
+	for _, scope := range scopes {
+		valid := false
+		for _, known := range models.AllTwitterScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", fmt.Errorf("invalid scope %q (use read, write or dm)", scope)
+		}
 	}
-
-	// Deduct requests after successful API call
-	utils.DeductTwitterRequests(twitterAccount.UserID)
-
-	// Log successful call
-	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, true, http.StatusOK, "")
-
-	c.JSON(http.StatusOK, result)
+	return strings.Join(scopes, ","), nil
 }
 
 // AddTwitterAccount godoc
@@ -120,7 +89,7 @@ func AddTwitterAccount(c *gin.Context) {
 	// Parse and validate token
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -146,19 +115,38 @@ func AddTwitterAccount(c *gin.Context) {
 		return
 	}
 
+	// Use the requested proxy, or pick one from the user's proxy list
+	proxyURL := req.ProxyURL
+	if proxyURL != "" {
+		if err := utils.ValidateProxyURL(proxyURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		proxyURL = pickProxyForUser(user.ID)
+	}
+
+	scopes, err := twitterScopesFromRequest(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Create Twitter JWT token (infinite duration)
 	twitterToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"username": req.Username,
 		"user_id":  user.ID,
 	})
-	twitterTokenString, _ := twitterToken.SignedString(jwtSecret)
+	twitterTokenString, _ := twitterToken.SignedString(config.JWTSecret)
 
 	// Create Twitter account
 	twitterAccount := models.TwitterAccount{
 		Username: req.Username,
 		Password: req.Password,
 		Token:    twitterTokenString,
+		ProxyURL: proxyURL,
 		UserID:   user.ID,
+		Scopes:   scopes,
 	}
 
 	if err := config.DB.Create(&twitterAccount).Error; err != nil {
@@ -167,7 +155,7 @@ func AddTwitterAccount(c *gin.Context) {
 	}
 
 	// Start Twitter login in background
-	utils_twitter.StartLoginAsync(twitterAccount.Username, twitterAccount.Password, user.ID, twitterAccount.ID)
+	utils.StartTwitterLogin(twitterAccount)
 
 	// Return the created account data with token
 	c.JSON(http.StatusOK, gin.H{
@@ -175,6 +163,8 @@ func AddTwitterAccount(c *gin.Context) {
 		"username": twitterAccount.Username,
 		"token":    twitterTokenString,
 		"user_id":  twitterAccount.UserID,
+		"proxy":    utils_twitter.RedactProxyURL(twitterAccount.ProxyURL),
+		"scopes":   strings.Split(scopes, ","),
 		"message":  "Twitter account created and login started in background",
 	})
 }
@@ -187,142 +177,281 @@ func AddTwitterAccount(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body schemas.GetTweetsRequest true "Tweet URL"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
 // @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      429 {object} map[string]string
 // @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
 // @Router       /twitter/post [post]
 func GetTweets(c *gin.Context) {
+	serveTwitterEndpoint(c, tweetEndpoint)
+}
+
+const (
+	// maxBatchTweets caps how many tweets a single batch lookup may request
+	maxBatchTweets = 100
+	// batchTweetConcurrency bounds the number of parallel lookups per batch
+	batchTweetConcurrency = 5
+)
+
+// isAccountError reports whether a lookup failed because of the account rather
+// than the tweet, so the rest of a batch should move to another account
+func isAccountError(err error) bool {
+	return errors.Is(err, utils_twitter.ErrRateLimited) || errors.Is(err, utils_twitter.ErrUnauthorized) ||
+		errors.Is(err, utils_twitter.ErrSuspended)
+}
+
+type batchTweetResult struct {
+	Input   string                        `json:"input"`
+	TweetID string                        `json:"tweet_id,omitempty"`
+	Success bool                          `json:"success"`
+	Tweet   *utils_twitter.TweetWithMedia `json:"tweet,omitempty"`
+	Cached  bool                          `json:"cached"`
+	Error   string                        `json:"error,omitempty"`
+}
+
+// GetTweetsBatch godoc
+// @Summary      Get Tweet Data in Batch
+// @Description  Fetch data for multiple tweets by ID or URL in one call; quota is charged per successful item (requires Twitter token authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.GetTweetsBatchRequest true "Tweet IDs or URLs"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
+// @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/posts/batch [post]
+func GetTweetsBatch(c *gin.Context) {
 	startTime := time.Now()
 	twitterAccount, err := authenticateTwitterToken(c)
 	if err != nil {
-		utils.LogTwitterAPICall(c, "", "", "/twitter/post", startTime, false, http.StatusUnauthorized, err.Error())
+		utils.LogTwitterAPICall(c, "", "", "/twitter/posts/batch", startTime, false, http.StatusUnauthorized, err.Error())
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Check rate limit
-	if err := utils.CheckTwitterRateLimit(twitterAccount.UserID); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, false, http.StatusTooManyRequests, err.Error())
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	if err := checkTwitterScope(twitterAccount, models.TwitterScopeRead); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/posts/batch", startTime, false, http.StatusForbidden, err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
 
-	var req schemas.GetTweetsRequest
+	var req schemas.GetTweetsBatchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, false, http.StatusBadRequest, err.Error())
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/posts/batch", startTime, false, http.StatusBadRequest, err.Error())
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err = utils_twitter.LoadTokensForAccount(twitterAccount.UserID, twitterAccount.ID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, false, http.StatusUnauthorized, "No valid Twitter session found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid Twitter session found"})
+	if len(req.Tweets) > maxBatchTweets {
+		errMsg := fmt.Sprintf("A batch may contain at most %d tweets", maxBatchTweets)
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/posts/batch", startTime, false, http.StatusBadRequest, errMsg)
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
 		return
 	}
 
-	if !utils_twitter.ValidateSession() {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, false, http.StatusUnauthorized, "Twitter session expired")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Twitter session expired"})
-		return
+	// Resolve every input to a tweet ID; duplicates are fetched (and charged) once
+	results := make([]batchTweetResult, len(req.Tweets))
+	uniqueIDs := []string{}
+	seen := make(map[string]bool)
+	for i, input := range req.Tweets {
+		results[i].Input = input
+		tweetID := utils_twitter.NormalizeTweetID(input)
+		if tweetID == "" {
+			results[i].Error = "Invalid tweet ID or URL"
+			continue
+		}
+		results[i].TweetID = tweetID
+		if !seen[tweetID] {
+			seen[tweetID] = true
+			uniqueIDs = append(uniqueIDs, tweetID)
+		}
 	}
 
-	tweetID := utils_twitter.ExtractTweetID(req.URL)
-	if tweetID == "" {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
+	if len(uniqueIDs) == 0 {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/posts/batch", startTime, false, http.StatusBadRequest, "No valid tweet IDs or URLs")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid tweet IDs or URLs", "results": results})
 		return
 	}
 
-	tweetWithMedia, err := utils_twitter.GetTweetWithMedia(tweetID)
+	// Serve what we can from the cache; only the rest is scraped
+	type fetchResult struct {
+		tweet  *utils_twitter.TweetWithMedia
+		cached bool
+		err    error
+	}
+	fetched := make(map[string]fetchResult, len(uniqueIDs))
+	toFetch := []string{}
+	for _, tweetID := range uniqueIDs {
+		var tweet *utils_twitter.TweetWithMedia
+		if getCachedTwitterData(c, twitterAccount.UserID, utils_twitter.OpTweetResult, tweetID, "", &tweet) {
+			fetched[tweetID] = fetchResult{tweet: tweet, cached: true}
+			continue
+		}
+		toFetch = append(toFetch, tweetID)
+	}
+	hitCount := len(uniqueIDs) - len(toFetch)
+	setCacheHeader(c, utils_twitter.OpTweetResult, len(toFetch) == 0)
+
+	// Reserve the whole batch before starting
+	batchCost := hitCount*utils.TwitterCacheHitCost + len(toFetch)*utils.TwitterRequestCost(utils_twitter.OpTweetResult)
+	reservation, err := utils.ReserveTwitterRequests(twitterAccount.UserID, batchCost, "/twitter/posts/batch")
 	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, false, http.StatusInternalServerError, "Failed to fetch tweet data")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tweet data"})
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/posts/batch", startTime, false, http.StatusTooManyRequests, err.Error())
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Fetch the remaining tweets with bounded parallelism
+	usedAccount := twitterAccount
+	var status int
+	if len(toFetch) > 0 {
+		usedAccount, status, err = runWithTwitterSession(c, twitterAccount, utils_twitter.OpTweetResult, func(session *utils_twitter.Session) error {
+			// Tweets that failed because of the previous account are retried on this one
+			pending := []string{}
+			for _, tweetID := range toFetch {
+				if res, ok := fetched[tweetID]; !ok || isAccountError(res.err) {
+					pending = append(pending, tweetID)
+				}
+			}
+
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			var accountErr error
+			sem := make(chan struct{}, batchTweetConcurrency)
+
+			for _, tweetID := range pending {
+				sem <- struct{}{}
+				mu.Lock()
+				stop := accountErr != nil
+				mu.Unlock()
+				if stop {
+					<-sem
+					break
+				}
+
+				wg.Add(1)
+				go func(tweetID string) {
+					defer wg.Done()
+					defer func() { <-sem }()
+
+					tweet, err := session.GetTweetWithMedia(tweetID)
+					if err == nil && (tweet == nil || tweet.Tweet == nil || tweet.Tweet.ID == "") {
+						err = fmt.Errorf("tweet not found")
+					}
+					if err == nil {
+						setCachedTwitterData(twitterAccount.UserID, utils_twitter.OpTweetResult, tweetID, "", tweet)
+						utils.SaveScrapedTweet(tweet.Tweet)
+					}
+
+					mu.Lock()
+					fetched[tweetID] = fetchResult{tweet: tweet, err: err}
+					if isAccountError(err) && accountErr == nil {
+						accountErr = err
+					}
+					mu.Unlock()
+				}(tweetID)
+			}
+			wg.Wait()
+
+			// Failing the attempt lets the pool cool the account down or move on
+			// to the next one; the tweets fetched so far are kept
+			return accountErr
+		})
+		if err != nil {
+			// Tweets the last account didn't get to fail with its error
+			for _, tweetID := range toFetch {
+				if _, ok := fetched[tweetID]; !ok {
+					fetched[tweetID] = fetchResult{err: err}
+				}
+			}
+		}
+	}
+
+	successCount := 0
+	for _, tweetID := range toFetch {
+		if fetched[tweetID].err == nil {
+			successCount++
+		}
+	}
+
+	// When the accounts failed before fetching anything, e.g. because every one
+	// is rate limited, the batch fails as a whole
+	if err != nil && successCount == 0 {
+		reservation.Refund()
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/posts/batch", startTime, false, status, err.Error())
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Deduct requests after successful API call
-	utils.DeductTwitterRequests(twitterAccount.UserID)
+	failCount := 0
+	for i := range results {
+		if results[i].TweetID == "" {
+			failCount++
+			continue
+		}
+		res := fetched[results[i].TweetID]
+		if res.err != nil {
+			results[i].Error = res.err.Error()
+			failCount++
+			continue
+		}
+		results[i].Success = true
+		results[i].Tweet = res.tweet
+		results[i].Cached = res.cached
+	}
 
-	// Log successful call
-	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post", startTime, true, http.StatusOK, "")
+	// Charge only for the tweets that were actually fetched, plus the reduced cost of cache hits
+	chargedCost := hitCount*utils.TwitterCacheHitCost + successCount*utils.TwitterRequestCost(utils_twitter.OpTweetResult)
+	reservation.Commit(chargedCost)
 
-	c.JSON(http.StatusOK, tweetWithMedia)
+	utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, "/twitter/posts/batch", startTime, successCount+hitCount > 0, http.StatusOK, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":       results,
+		"count":         len(results),
+		"success_count": len(results) - failCount,
+		"fail_count":    failCount,
+		"cached_count":  hitCount,
+	})
 }
 
 // GetLikes godoc
 // @Summary      Get Tweet Likes
-// @Description  Fetch users who liked a tweet (requires Twitter token authentication)
+// @Description  Fetch users who liked a tweet, each with a quality_score and the reasons it was lowered (requires Twitter token authentication)
 // @Tags         twitter
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body schemas.GetLikesRequest true "Tweet URL"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
+// @Param        min_quality query int false "Only return users with at least this quality score (0-100)"
+// @Param        exclude_bots query bool false "Drop users flagged as likely bots"
 // @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      429 {object} map[string]string
 // @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
 // @Router       /twitter/post/likes [post]
 func GetLikes(c *gin.Context) {
-	startTime := time.Now()
-	twitterAccount, err := authenticateTwitterToken(c)
-	if err != nil {
-		utils.LogTwitterAPICall(c, "", "", "/twitter/post/likes", startTime, false, http.StatusUnauthorized, err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check rate limit
-	if err := utils.CheckTwitterRateLimit(twitterAccount.UserID); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, false, http.StatusTooManyRequests, err.Error())
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
-		return
-	}
-
-	var req schemas.GetLikesRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, false, http.StatusBadRequest, err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err = utils_twitter.LoadTokensForAccount(twitterAccount.UserID, twitterAccount.ID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, false, http.StatusUnauthorized, "No valid Twitter session found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid Twitter session found"})
-		return
-	}
-
-	if !utils_twitter.ValidateSession() {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, false, http.StatusUnauthorized, "Twitter session expired")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Twitter session expired"})
-		return
-	}
-
-	tweetID := utils_twitter.ExtractTweetID(req.URL)
-	if tweetID == "" {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
-		return
-	}
-
-	likers, err := utils_twitter.GetLikers(tweetID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, false, http.StatusInternalServerError, "Failed to fetch likers data")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch likers data"})
-		return
-	}
-
-	// Deduct requests after successful API call
-	utils.DeductTwitterRequests(twitterAccount.UserID)
-
-	// Log successful call
-	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/likes", startTime, true, http.StatusOK, "")
-
-	c.JSON(http.StatusOK, gin.H{"likers": likers, "count": len(likers)})
+	serveTwitterEndpoint(c, likesEndpoint)
 }
 
 // GetQuotes godoc
@@ -333,69 +462,20 @@ func GetLikes(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body schemas.GetQuotesRequest true "Tweet URL"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
 // @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      429 {object} map[string]string
 // @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
 // @Router       /twitter/post/quotes [post]
 func GetQuotes(c *gin.Context) {
-	startTime := time.Now()
-	twitterAccount, err := authenticateTwitterToken(c)
-	if err != nil {
-		utils.LogTwitterAPICall(c, "", "", "/twitter/post/quotes", startTime, false, http.StatusUnauthorized, err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check rate limit
-	if err := utils.CheckTwitterRateLimit(twitterAccount.UserID); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, false, http.StatusTooManyRequests, err.Error())
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
-		return
-	}
-
-	var req schemas.GetQuotesRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, false, http.StatusBadRequest, err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err = utils_twitter.LoadTokensForAccount(twitterAccount.UserID, twitterAccount.ID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, false, http.StatusUnauthorized, "No valid Twitter session found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid Twitter session found"})
-		return
-	}
-
-	if !utils_twitter.ValidateSession() {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, false, http.StatusUnauthorized, "Twitter session expired")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Twitter session expired"})
-		return
-	}
-
-	tweetID := utils_twitter.ExtractTweetID(req.URL)
-	if tweetID == "" {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
-		return
-	}
-
-	quotes, err := utils_twitter.SearchQuotedTweets(tweetID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, false, http.StatusInternalServerError, "Failed to fetch quotes data")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quotes data"})
-		return
-	}
-
-	// Deduct requests after successful API call
-	utils.DeductTwitterRequests(twitterAccount.UserID)
-
-	// Log successful call
-	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/quotes", startTime, true, http.StatusOK, "")
-
-	c.JSON(http.StatusOK, gin.H{"quotes": quotes, "count": len(quotes)})
+	serveTwitterEndpoint(c, quotesEndpoint)
 }
 
 // GetComments godoc
@@ -406,147 +486,51 @@ func GetQuotes(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body schemas.GetCommentsRequest true "Tweet URL"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
 // @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      429 {object} map[string]string
 // @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
 // @Router       /twitter/post/comments [post]
 func GetComments(c *gin.Context) {
-	startTime := time.Now()
-	twitterAccount, err := authenticateTwitterToken(c)
-	if err != nil {
-		utils.LogTwitterAPICall(c, "", "", "/twitter/post/comments", startTime, false, http.StatusUnauthorized, err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check rate limit
-	if err := utils.CheckTwitterRateLimit(twitterAccount.UserID); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, false, http.StatusTooManyRequests, err.Error())
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
-		return
-	}
-
-	var req schemas.GetCommentsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, false, http.StatusBadRequest, err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err = utils_twitter.LoadTokensForAccount(twitterAccount.UserID, twitterAccount.ID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, false, http.StatusUnauthorized, "No valid Twitter session found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid Twitter session found"})
-		return
-	}
-
-	if !utils_twitter.ValidateSession() {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, false, http.StatusUnauthorized, "Twitter session expired")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Twitter session expired"})
-		return
-	}
-
-	tweetID := utils_twitter.ExtractTweetID(req.URL)
-	if tweetID == "" {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
-		return
-	}
-
-	comments, err := utils_twitter.GetAllTweetReplies(tweetID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, false, http.StatusInternalServerError, "Failed to fetch comments data")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments data"})
-		return
-	}
-
-	// Deduct requests after successful API call
-	utils.DeductTwitterRequests(twitterAccount.UserID)
-
-	// Log successful call
-	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/comments", startTime, true, http.StatusOK, "")
-
-	c.JSON(http.StatusOK, gin.H{"comments": comments, "count": len(comments)})
+	serveTwitterEndpoint(c, commentsEndpoint)
 }
 
 // GetReposts godoc
 // @Summary      Get Tweet Reposts
-// @Description  Fetch users who reposted a tweet (requires Twitter token authentication)
+// @Description  Fetch users who reposted a tweet, each with a quality_score and the reasons it was lowered (requires Twitter token authentication)
 // @Tags         twitter
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        request body schemas.GetRepostsRequest true "Tweet URL"
+// @Param        pool query string false "Serve from any of the owner's accounts (round_robin or lru)"
+// @Param        fresh query bool false "Bypass the response cache"
+// @Param        min_quality query int false "Only return users with at least this quality score (0-100)"
+// @Param        exclude_bots query bool false "Drop users flagged as likely bots"
 // @Success      200 {object} map[string]interface{}
+// @Header       200 {string} X-Cache "HIT, MISS or BYPASS"
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
 // @Failure      429 {object} map[string]string
 // @Failure      500 {object} map[string]string
+// @Failure      502 {object} map[string]string
 // @Router       /twitter/post/reposts [post]
 func GetReposts(c *gin.Context) {
-	startTime := time.Now()
-	twitterAccount, err := authenticateTwitterToken(c)
-	if err != nil {
-		utils.LogTwitterAPICall(c, "", "", "/twitter/post/reposts", startTime, false, http.StatusUnauthorized, err.Error())
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Check rate limit
-	if err := utils.CheckTwitterRateLimit(twitterAccount.UserID); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, false, http.StatusTooManyRequests, err.Error())
-		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
-		return
-	}
-
-	var req schemas.GetRepostsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, false, http.StatusBadRequest, err.Error())
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	err = utils_twitter.LoadTokensForAccount(twitterAccount.UserID, twitterAccount.ID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, false, http.StatusUnauthorized, "No valid Twitter session found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "No valid Twitter session found"})
-		return
-	}
-
-	if !utils_twitter.ValidateSession() {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, false, http.StatusUnauthorized, "Twitter session expired")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Twitter session expired"})
-		return
-	}
-
-	tweetID := utils_twitter.ExtractTweetID(req.URL)
-	if tweetID == "" {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, false, http.StatusBadRequest, "Invalid tweet URL")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
-		return
-	}
-
-	retweeters, err := utils_twitter.GetRetweeters(tweetID)
-	if err != nil {
-		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, false, http.StatusInternalServerError, "Failed to fetch reposts data")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reposts data"})
-		return
-	}
-
-	// Deduct requests after successful API call
-	utils.DeductTwitterRequests(twitterAccount.UserID)
-
-	// Log successful call
-	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, "/twitter/post/reposts", startTime, true, http.StatusOK, "")
-
-	c.JSON(http.StatusOK, gin.H{"reposts": retweeters, "count": len(retweeters)})
+	serveTwitterEndpoint(c, repostsEndpoint)
 }
 
 // GetTwitterAccounts godoc
 // @Summary      Get Twitter Accounts
-// @Description  Get all Twitter accounts connected to the authenticated user (requires JWT authentication)
+// @Description  Get all Twitter accounts connected to the authenticated user, with the health of their sessions (requires JWT authentication)
 // @Tags         twitter
 // @Accept       json
 // @Produce      json
@@ -563,7 +547,7 @@ func GetTwitterAccounts(c *gin.Context) {
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -581,18 +565,14 @@ func GetTwitterAccounts(c *gin.Context) {
 	}
 
 	var accounts []models.TwitterAccount
-	if err := config.DB.Where("user_id = ?", user.ID).Find(&accounts).Error; err != nil {
+	if err := config.DB.Scopes(utils.Accessible(user.ID, models.OrgRoleViewer)).Find(&accounts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
 		return
 	}
 
 	response := make([]gin.H, len(accounts))
 	for i, account := range accounts {
-		response[i] = gin.H{
-			"id":       account.ID,
-			"username": account.Username,
-			"token":    account.Token,
-		}
+		response[i] = twitterAccountResponse(account, user.ID)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"accounts": response, "count": len(accounts)})
@@ -600,12 +580,12 @@ func GetTwitterAccounts(c *gin.Context) {
 
 // RegenerateTwitterToken godoc
 // @Summary      Regenerate Twitter Token
-// @Description  Generate a new authentication token for a Twitter account (requires JWT authentication)
+// @Description  Generate a new authentication token for a Twitter account, optionally limited to some of the read, write and dm scopes (requires JWT authentication)
 // @Tags         twitter
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        request body schemas.TwitterLoginRequest true "Twitter username"
+// @Param        request body schemas.RegenerateTwitterTokenRequest true "Twitter username and scopes"
 // @Success      200 {object} map[string]interface{}
 // @Failure      400 {object} map[string]string
 // @Failure      401 {object} map[string]string
@@ -622,7 +602,7 @@ func RegenerateTwitterToken(c *gin.Context) {
 	// Parse and validate main JWT token
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !token.Valid {
@@ -648,9 +628,15 @@ func RegenerateTwitterToken(c *gin.Context) {
 		return
 	}
 
-	// Verify Twitter account belongs to authenticated user
+	scopes, err := twitterScopesFromRequest(req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify Twitter account belongs to authenticated user, or to an organization they administer
 	var twitterAccount models.TwitterAccount
-	if err := config.DB.Where("username = ? AND user_id = ?", req.Username, user.ID).First(&twitterAccount).Error; err != nil {
+	if err := config.DB.Scopes(utils.Accessible(user.ID, models.OrgRoleAdmin)).Where("username = ?", req.Username).First(&twitterAccount).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
 		return
 	}
@@ -658,18 +644,19 @@ func RegenerateTwitterToken(c *gin.Context) {
 	// Create new Twitter JWT token
 	twitterToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"username": req.Username,
-		"user_id":  user.ID,
+		"user_id":  twitterAccount.UserID,
 	})
-	twitterTokenString, _ := twitterToken.SignedString(jwtSecret)
+	twitterTokenString, _ := twitterToken.SignedString(config.JWTSecret)
 
 	// Update token in database
-	if err := config.DB.Model(&twitterAccount).Update("token", twitterTokenString).Error; err != nil {
+	if err := config.DB.Model(&twitterAccount).Updates(map[string]interface{}{"token": twitterTokenString, "scopes": scopes}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"token":   twitterTokenString,
+		"scopes":  strings.Split(scopes, ","),
 		"message": "Twitter token regenerated successfully",
 	})
 }
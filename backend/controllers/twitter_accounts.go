@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// twitterAccountResponse shapes an account for the account endpoints, without
+// its password. The token is left out for organization members who cannot operate the account.
+func twitterAccountResponse(account models.TwitterAccount, userID string) gin.H {
+	token := account.Token
+	if !utils.CanAccess(userID, account.UserID, account.OrganizationID, models.OrgRoleOperator) {
+		token = ""
+	}
+	return gin.H{
+		"id":                account.ID,
+		"username":          account.Username,
+		"token":             token,
+		"organization_id":   account.OrganizationID,
+		"proxy":             utils_twitter.RedactProxyURL(account.ProxyURL),
+		"scopes":            strings.Split(account.Scopes, ","),
+		"status":            account.Status,
+		"last_error":        account.LastError,
+		"last_login_at":     account.LastLoginAt,
+		"last_validated_at": account.LastValidatedAt,
+		"login_in_progress": utils_twitter.IsLoginInProgress(account.ID),
+	}
+}
+
+// findTwitterAccount loads an account owned by user, or shared with an
+// organization where they hold at least minRole, writing the 404 itself
+func findTwitterAccount(c *gin.Context, user *models.User, minRole string) (*models.TwitterAccount, bool) {
+	var account models.TwitterAccount
+	if err := config.DB.Scopes(utils.Accessible(user.ID, minRole)).Where("id = ?", c.Param("id")).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
+		return nil, false
+	}
+	return &account, true
+}
+
+// GetTwitterAccount godoc
+// @Summary      Get a Twitter Account
+// @Description  Get one of your Twitter accounts with the stored health of its session: status (pending, logging_in, active, expired, failed), last_error, last_login_at and last_validated_at (requires JWT authentication)
+// @Tags         twitter
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Twitter account ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /twitter/accounts/{id} [get]
+func GetTwitterAccount(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	account, ok := findTwitterAccount(c, user, models.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, twitterAccountResponse(*account, user.ID))
+}
+
+// UpdateTwitterAccount godoc
+// @Summary      Update a Twitter Account Password
+// @Description  Store a new password for one of your Twitter accounts and log it in again with it in the background. The current session stays in use until the new login succeeds (requires JWT authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Twitter account ID"
+// @Param        request body schemas.UpdateTwitterAccountRequest true "New password"
+// @Success      202 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/accounts/{id} [put]
+func UpdateTwitterAccount(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	account, ok := findTwitterAccount(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var req schemas.UpdateTwitterAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if utils_twitter.IsLoginInProgress(account.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A login of this Twitter account is already running"})
+		return
+	}
+
+	if err := config.DB.Model(account).Update("password", req.Password).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	utils.StartTwitterLogin(*account)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Password updated and login started in background"})
+}
+
+// DeleteTwitterAccount godoc
+// @Summary      Delete a Twitter Account
+// @Description  Delete one of your Twitter accounts and its saved session. Its pending scheduled tweets and DMs and its tweet watches are cancelled, and its token stops working (requires JWT authentication)
+// @Tags         twitter
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Twitter account ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/accounts/{id} [delete]
+func DeleteTwitterAccount(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	account, ok := findTwitterAccount(c, user, models.OrgRoleAdmin)
+	if !ok {
+		return
+	}
+
+	// A running login would write the token file again after it is removed
+	if utils_twitter.IsLoginInProgress(account.ID) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A login of this Twitter account is running, try again once it finishes"})
+		return
+	}
+
+	if err := utils.DeleteTwitterAccount(*account); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete Twitter account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Twitter account deleted successfully"})
+}
+
+// TwitterLogin godoc
+// @Summary      Log a Twitter Account In
+// @Description  Log one of your Twitter accounts in again with its stored password, in the background (~30 seconds). Follow the result with GET /twitter/accounts/{id} (requires JWT authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TwitterLoginRequest true "Twitter username"
+// @Success      202 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Router       /twitter/login [post]
+func TwitterLogin(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.TwitterLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var account models.TwitterAccount
+	if err := config.DB.Scopes(utils.Accessible(user.ID, models.OrgRoleOperator)).Where("username = ?", req.Username).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter account not found or not owned by user"})
+		return
+	}
+
+	if !utils.StartTwitterLogin(account) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A login of this Twitter account is already running"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"id": account.ID, "message": "Twitter login started in background"})
+}
+
+// CheckTwitterAccountHealth godoc
+// @Summary      Check a Twitter Account's Session
+// @Description  Load and validate the saved session of one of your Twitter accounts against X now, store the result and return the account's health (requires JWT authentication)
+// @Tags         twitter
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Twitter account ID"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Router       /twitter/accounts/{id}/health [get]
+func CheckTwitterAccountHealth(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	account, ok := findTwitterAccount(c, user, models.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, twitterAccountResponse(utils.CheckTwitterSession(*account), user.ID))
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"testing"
+	"time"
+)
+
+func TestGetTwitterAccount(t *testing.T) {
+	f := newOrgFixture(t)
+	account := sharedTwitterAccount(t, f.admin, f.organization)
+	path := "/twitter/accounts/" + account.ID
+
+	runAPITests(t, http.MethodGet, "/twitter/accounts/:id", GetTwitterAccount, []apiTest{
+		{name: "no token", path: path, status: http.StatusUnauthorized},
+		{name: "not in the organization", path: path, token: f.outsiderToken, status: http.StatusNotFound},
+		{
+			name: "owner", path: path, token: f.adminToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "username", account.Username)
+				wantField(t, res, "token", account.Token)
+				wantField(t, res, "status", models.TwitterAccountActive)
+				if _, leaked := res["password"]; leaked {
+					t.Errorf("password included")
+				}
+			},
+		},
+		{
+			name: "viewer of the organization", path: path, token: f.viewerToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "token", "") },
+		},
+		{
+			name: "operator of the organization", path: path, token: f.operatorToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "token", account.Token) },
+		},
+	})
+}
+
+func TestUpdateTwitterAccount(t *testing.T) {
+	f := newOrgFixture(t)
+	account := sharedTwitterAccount(t, f.admin, f.organization)
+	path := "/twitter/accounts/" + account.ID
+	password := map[string]string{"password": "new password"}
+
+	runAPITests(t, http.MethodPut, "/twitter/accounts/:id", UpdateTwitterAccount, []apiTest{
+		{name: "not in the organization", path: path, token: f.outsiderToken, body: password, status: http.StatusNotFound},
+		{name: "operator of the organization", path: path, token: f.operatorToken, body: password, status: http.StatusNotFound},
+		{name: "no password", path: path, token: f.adminToken, body: map[string]string{}, status: http.StatusBadRequest},
+	})
+}
+
+func TestDeleteTwitterAccount(t *testing.T) {
+	f := newOrgFixture(t)
+	account := sharedTwitterAccount(t, f.operator, f.organization)
+	watch := &models.TweetWatch{
+		UserID: f.operator.ID, TwitterAccountID: account.ID, TweetID: "1", IntervalMinutes: 15,
+		EndsAt: time.Now().Add(time.Hour), NextRunAt: time.Now().Add(time.Hour), Status: "active",
+	}
+	if err := config.DB.Create(watch).Error; err != nil {
+		t.Fatalf("failed to create the tweet watch: %v", err)
+	}
+	path := "/twitter/accounts/" + account.ID
+
+	runAPITests(t, http.MethodDelete, "/twitter/accounts/:id", DeleteTwitterAccount, []apiTest{
+		{name: "viewer of the organization", path: path, token: f.viewerToken, status: http.StatusNotFound},
+		{
+			name: "admin of the organization", path: path, token: f.adminToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var count int64
+				config.DB.Model(&models.TwitterAccount{}).Where("id = ?", account.ID).Count(&count)
+				if count != 0 {
+					t.Errorf("account not deleted")
+				}
+				var stored models.TweetWatch
+				config.DB.Where("id = ?", watch.ID).First(&stored)
+				if stored.Status != "cancelled" {
+					t.Errorf("tweet watch is %s, want cancelled", stored.Status)
+				}
+			},
+		},
+		{name: "deleted", path: path, token: f.operatorToken, status: http.StatusNotFound},
+	})
+}
+
+func TestCheckTwitterAccountHealth(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	path := "/twitter/accounts/" + account.ID + "/health"
+
+	runAPITests(t, http.MethodGet, "/twitter/accounts/:id/health", CheckTwitterAccountHealth, []apiTest{
+		{name: "account of another user", path: path, token: userToken(t, testUser(t)), status: http.StatusNotFound},
+		{
+			name: "no saved session", path: path, token: userToken(t, user), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "status", models.TwitterAccountExpired)
+				wantField(t, res, "last_error", "No valid Twitter session found")
+				var stored models.TwitterAccount
+				config.DB.Where("id = ?", account.ID).First(&stored)
+				if stored.Status != models.TwitterAccountExpired || stored.LastValidatedAt == nil {
+					t.Errorf("stored status %s validated at %v, want expired and validated now", stored.Status, stored.LastValidatedAt)
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,573 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxTweetLength = 280
+	maxTweetImages = 4
+)
+
+// tweetMedia is a file attached to a tweet, read from a multipart request
+type tweetMedia struct {
+	Filename string
+	MimeType string
+	Data     []byte
+}
+
+// readTweetMedia reads the "media" files of a multipart request. A tweet takes
+// up to four images, or a single GIF or video.
+func readTweetMedia(c *gin.Context) ([]tweetMedia, error) {
+	form, err := c.MultipartForm()
+	if errors.Is(err, http.ErrNotMultipart) {
+		// Not a multipart request, so no media
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart form: %v", err)
+	}
+
+	files := form.File["media"]
+	media := make([]tweetMedia, 0, len(files))
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s", header.Filename)
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s", header.Filename)
+		}
+
+		mimeType := header.Header.Get("Content-Type")
+		if utils_twitter.MediaCategory(mimeType) == "" {
+			mimeType = http.DetectContentType(data)
+		}
+		if err := utils_twitter.ValidateMedia(data, mimeType); err != nil {
+			return nil, fmt.Errorf("%s: %v", header.Filename, err)
+		}
+		media = append(media, tweetMedia{Filename: header.Filename, MimeType: mimeType, Data: data})
+	}
+
+	if len(media) > 1 {
+		for _, m := range media {
+			if utils_twitter.MediaCategory(m.MimeType) != "tweet_image" {
+				return nil, errors.New("a GIF or video must be the only media of a tweet")
+			}
+		}
+	}
+	if len(media) > maxTweetImages {
+		return nil, fmt.Errorf("a tweet can have at most %d images", maxTweetImages)
+	}
+	return media, nil
+}
+
+// loadOwnTwitterSession loads the session of the token's own account. Writes
+// and DMs are never served from the account pool.
+func loadOwnTwitterSession(twitterAccount *models.TwitterAccount) (*utils_twitter.Session, error) {
+	session, err := utils_twitter.LoadSession(twitterAccount.UserID, twitterAccount.ID, twitterAccount.ProxyURL)
+	if err != nil {
+		utils.MarkTwitterSessionExpired(twitterAccount.ID, "No valid Twitter session found")
+		return nil, errors.New("No valid Twitter session found")
+	}
+	if !session.Validate() {
+		utils.MarkTwitterSessionExpired(twitterAccount.ID, "Twitter session expired")
+		return nil, errors.New("Twitter session expired")
+	}
+	utils_twitter.MarkAccountUsed(twitterAccount.ID)
+	return session, nil
+}
+
+// executeTwitterWrite runs a write action from the token's own Twitter account.
+// It enforces the action's quota and the request balance, records the write in
+// the API call log and responds. A dry run stops after the checks and returns
+// the preview instead of calling X.
+func executeTwitterWrite(
+	c *gin.Context,
+	twitterAccount *models.TwitterAccount,
+	startTime time.Time,
+	action string,
+	dryRun bool,
+	preview gin.H,
+	fn func(*utils_twitter.Session) (gin.H, error),
+) {
+	endpoint := utils.TwitterActionEndpoint(action)
+
+	fail := func(status int, errMsg string) {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, status, errMsg, dryRun)
+		c.JSON(status, gin.H{"error": errMsg})
+	}
+
+	// DMs need the dm scope, every other write the write scope
+	scope := models.TwitterScopeWrite
+	if action == utils.TwitterActionDM {
+		scope = models.TwitterScopeDM
+	}
+	if err := checkTwitterScope(twitterAccount, scope); err != nil {
+		fail(http.StatusForbidden, err.Error())
+		return
+	}
+
+	if err := utils.CheckTwitterActionQuota(twitterAccount, action); err != nil {
+		fail(http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	result := gin.H{"action": action, "method": c.Request.Method, "account": twitterAccount.Username, "dry_run": dryRun}
+	if dryRun {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, true, http.StatusOK, "", true)
+		usage, _ := utils.GetTwitterActionUsage(twitterAccount.ID, action)
+		result["preview"] = preview
+		result["quota"] = usage
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// The check above only looks; the slot is taken atomically before the write
+	slot, err := utils.ReserveTwitterAction(twitterAccount, action)
+	if err != nil {
+		var limitErr *utils.TwitterActionLimitError
+		if !errors.As(err, &limitErr) {
+			fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		setRetryAfter(c, limitErr.RetryAt)
+		fail(http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	reservation, err := utils.ReserveTwitterWrite(twitterAccount.UserID, action, endpoint)
+	if err != nil {
+		slot.Release()
+		fail(http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	session, err := loadOwnTwitterSession(twitterAccount)
+	if err != nil {
+		slot.Release()
+		reservation.Refund()
+		fail(http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	data, err := fn(session)
+	if err != nil {
+		slot.Release()
+		reservation.Refund()
+		status := twitterErrorStatus(err)
+		var apiErr *utils_twitter.APIError
+		if status == http.StatusTooManyRequests && errors.As(err, &apiErr) {
+			setRetryAfter(c, apiErr.Reset)
+		}
+		log.Printf("❌ Twitter %s failed for @%s: %v", action, twitterAccount.Username, err)
+		fail(status, err.Error())
+		return
+	}
+
+	reservation.Commit(reservation.Amount)
+	utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, true, http.StatusOK, "", false)
+	log.Printf("✅ Twitter %s by @%s", action, twitterAccount.Username)
+
+	for k, v := range data {
+		result[k] = v
+	}
+	usage, _ := utils.GetTwitterActionUsage(twitterAccount.ID, action)
+	result["quota"] = usage
+	c.JSON(http.StatusOK, result)
+}
+
+// postTweet handles tweets, replies and quotes, with optional media
+func postTweet(c *gin.Context, action string) {
+	startTime := time.Now()
+	endpoint := utils.TwitterActionEndpoint(action)
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", endpoint, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.PostTweetRequest
+	badRequest := func(errMsg string) {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusBadRequest, errMsg, req.DryRun)
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		badRequest(err.Error())
+		return
+	}
+
+	media, err := readTweetMedia(c)
+	if err != nil {
+		badRequest(err.Error())
+		return
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" && len(media) == 0 {
+		badRequest("text or media is required")
+		return
+	}
+	if utf8.RuneCountInString(text) > maxTweetLength {
+		badRequest(fmt.Sprintf("text is longer than %d characters", maxTweetLength))
+		return
+	}
+
+	opts := utils_twitter.TweetOptions{}
+	preview := gin.H{"text": text}
+	if action != utils.TwitterActionTweet {
+		targetID := utils_twitter.ExtractTweetID(req.URL)
+		if targetID == "" {
+			badRequest("Invalid tweet URL")
+			return
+		}
+		if action == utils.TwitterActionReply {
+			opts.ReplyToID = targetID
+			preview["in_reply_to"] = targetID
+		} else {
+			opts.QuoteURL = "https://x.com/i/web/status/" + targetID
+			preview["quoted_tweet_id"] = targetID
+		}
+	}
+	mediaPreview := make([]gin.H, 0, len(media))
+	for _, m := range media {
+		mediaPreview = append(mediaPreview, gin.H{"filename": m.Filename, "type": m.MimeType, "bytes": len(m.Data)})
+	}
+	preview["media"] = mediaPreview
+
+	executeTwitterWrite(c, twitterAccount, startTime, action, req.DryRun, preview, func(session *utils_twitter.Session) (gin.H, error) {
+		for _, m := range media {
+			mediaID, err := session.UploadMedia(m.Data, m.MimeType)
+			if err != nil {
+				return nil, err
+			}
+			opts.MediaIDs = append(opts.MediaIDs, mediaID)
+		}
+
+		tweetID, err := session.CreateTweet(text, opts)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{
+			"tweet_id":  tweetID,
+			"url":       fmt.Sprintf("https://x.com/%s/status/%s", twitterAccount.Username, tweetID),
+			"media_ids": opts.MediaIDs,
+		}, nil
+	})
+}
+
+// tweetAction handles likes, retweets and bookmarks and their undo
+func tweetAction(c *gin.Context, action string, fn func(*utils_twitter.Session, string) error) {
+	startTime := time.Now()
+	endpoint := utils.TwitterActionEndpoint(action)
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", endpoint, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.TweetActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusBadRequest, err.Error(), req.DryRun)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweetID := utils_twitter.ExtractTweetID(req.URL)
+	if tweetID == "" {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusBadRequest, "Invalid tweet URL", req.DryRun)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet URL"})
+		return
+	}
+
+	executeTwitterWrite(c, twitterAccount, startTime, action, req.DryRun, gin.H{"tweet_id": tweetID}, func(session *utils_twitter.Session) (gin.H, error) {
+		if err := fn(session, tweetID); err != nil {
+			return nil, err
+		}
+		return gin.H{"tweet_id": tweetID}, nil
+	})
+}
+
+// followAction handles follows and unfollows
+func followAction(c *gin.Context, fn func(*utils_twitter.Session, string) error) {
+	startTime := time.Now()
+	endpoint := utils.TwitterActionEndpoint(utils.TwitterActionFollow)
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", endpoint, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.FollowActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusBadRequest, err.Error(), req.DryRun)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	username := strings.TrimPrefix(strings.TrimSpace(req.Username), "@")
+
+	executeTwitterWrite(c, twitterAccount, startTime, utils.TwitterActionFollow, req.DryRun, gin.H{"username": username}, func(session *utils_twitter.Session) (gin.H, error) {
+		if err := fn(session, username); err != nil {
+			return nil, err
+		}
+		return gin.H{"username": username}, nil
+	})
+}
+
+// PostTweet godoc
+// @Summary      Post a Tweet
+// @Description  Post a tweet from the token's Twitter account. Send JSON, or multipart form data with up to four "media" images or one GIF or video. Costs one Twitter request and counts toward the account's daily tweet quota; dry_run validates and checks the quota without posting (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json,mpfd
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.PostTweetRequest true "Tweet text"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/actions/tweet [post]
+func PostTweet(c *gin.Context) {
+	postTweet(c, utils.TwitterActionTweet)
+}
+
+// PostReply godoc
+// @Summary      Reply to a Tweet
+// @Description  Reply to the tweet at url from the token's Twitter account, optionally with media like /twitter/actions/tweet. Costs one Twitter request and counts toward the account's daily reply quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json,mpfd
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.PostTweetRequest true "Reply text and tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/actions/reply [post]
+func PostReply(c *gin.Context) {
+	postTweet(c, utils.TwitterActionReply)
+}
+
+// PostQuote godoc
+// @Summary      Quote a Tweet
+// @Description  Quote the tweet at url from the token's Twitter account, optionally with media like /twitter/actions/tweet. Costs one Twitter request and counts toward the account's daily quote quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json,mpfd
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.PostTweetRequest true "Quote text and tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/actions/quote [post]
+func PostQuote(c *gin.Context) {
+	postTweet(c, utils.TwitterActionQuote)
+}
+
+// LikeTweet godoc
+// @Summary      Like a Tweet
+// @Description  Like a tweet from the token's Twitter account. Costs one Twitter request and counts toward the daily like quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetActionRequest true "Tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/like [post]
+func LikeTweet(c *gin.Context) {
+	tweetAction(c, utils.TwitterActionLike, (*utils_twitter.Session).LikeTweet)
+}
+
+// UnlikeTweet godoc
+// @Summary      Unlike a Tweet
+// @Description  Remove a like from the token's Twitter account. Counts toward the daily like quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetActionRequest true "Tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/like [delete]
+func UnlikeTweet(c *gin.Context) {
+	tweetAction(c, utils.TwitterActionLike, (*utils_twitter.Session).UnlikeTweet)
+}
+
+// RetweetTweet godoc
+// @Summary      Retweet a Tweet
+// @Description  Retweet a tweet from the token's Twitter account. Costs one Twitter request and counts toward the daily retweet quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetActionRequest true "Tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/retweet [post]
+func RetweetTweet(c *gin.Context) {
+	tweetAction(c, utils.TwitterActionRetweet, (*utils_twitter.Session).Retweet)
+}
+
+// UnretweetTweet godoc
+// @Summary      Undo a Retweet
+// @Description  Remove a retweet from the token's Twitter account. Counts toward the daily retweet quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetActionRequest true "Tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/retweet [delete]
+func UnretweetTweet(c *gin.Context) {
+	tweetAction(c, utils.TwitterActionRetweet, (*utils_twitter.Session).Unretweet)
+}
+
+// BookmarkTweet godoc
+// @Summary      Bookmark a Tweet
+// @Description  Bookmark a tweet for the token's Twitter account. Costs one Twitter request and counts toward the daily bookmark quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetActionRequest true "Tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/bookmark [post]
+func BookmarkTweet(c *gin.Context) {
+	tweetAction(c, utils.TwitterActionBookmark, (*utils_twitter.Session).BookmarkTweet)
+}
+
+// UnbookmarkTweet godoc
+// @Summary      Remove a Bookmark
+// @Description  Remove a bookmark of the token's Twitter account. Counts toward the daily bookmark quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TweetActionRequest true "Tweet URL"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/bookmark [delete]
+func UnbookmarkTweet(c *gin.Context) {
+	tweetAction(c, utils.TwitterActionBookmark, (*utils_twitter.Session).UnbookmarkTweet)
+}
+
+// FollowUser godoc
+// @Summary      Follow a User
+// @Description  Follow a user from the token's Twitter account. Costs one Twitter request and counts toward the daily follow quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.FollowActionRequest true "Username to follow"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/follow [post]
+func FollowUser(c *gin.Context) {
+	followAction(c, (*utils_twitter.Session).Follow)
+}
+
+// UnfollowUser godoc
+// @Summary      Unfollow a User
+// @Description  Unfollow a user from the token's Twitter account. Counts toward the daily follow quota (requires Twitter token authentication)
+// @Tags         actions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.FollowActionRequest true "Username to unfollow"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /twitter/actions/follow [delete]
+func UnfollowUser(c *gin.Context) {
+	followAction(c, (*utils_twitter.Session).Unfollow)
+}
+
+// GetTwitterActionQuotas godoc
+// @Summary      Get Write Action Quotas
+// @Description  Show how much of each daily write quota the token's Twitter account has used in the last 24 hours. Dry runs do not count (requires Twitter token authentication)
+// @Tags         actions
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/actions/quotas [get]
+func GetTwitterActionQuotas(c *gin.Context) {
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	actions := []string{
+		utils.TwitterActionTweet, utils.TwitterActionReply, utils.TwitterActionQuote, utils.TwitterActionLike,
+		utils.TwitterActionRetweet, utils.TwitterActionBookmark, utils.TwitterActionFollow, utils.TwitterActionDM,
+	}
+	quotas := make([]utils.TwitterActionUsage, 0, len(actions))
+	for _, action := range actions {
+		usage, err := utils.GetTwitterActionUsage(twitterAccount.ID, action)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count action usage"})
+			return
+		}
+		quotas = append(quotas, usage)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"account":      twitterAccount.Username,
+		"window_hours": int(utils.TwitterActionWindow.Hours()),
+		"quotas":       quotas,
+	})
+}
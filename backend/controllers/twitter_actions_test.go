@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testTwitterWrite logs a write of an action by a Twitter account. A sent
+// write also takes its action slot, as executeTwitterWrite does.
+func testTwitterWrite(t *testing.T, account *models.TwitterAccount, action string, success, dryRun bool) {
+	t.Helper()
+
+	log := models.ApiCallLog{
+		UserID: account.UserID, TwitterUsername: account.Username, Endpoint: utils.TwitterActionEndpoint(action),
+		Method: http.MethodPost, StatusCode: http.StatusOK, Success: success, DryRun: dryRun,
+	}
+	if err := config.DB.Create(&log).Error; err != nil {
+		t.Fatalf("failed to log the write: %v", err)
+	}
+	if success && !dryRun {
+		if err := config.DB.Create(&models.TwitterActionSlot{TwitterAccountID: account.ID, Action: action}).Error; err != nil {
+			t.Fatalf("failed to take the action slot: %v", err)
+		}
+	}
+}
+
+// withActionQuota sets the quota of an action for the rest of the test
+func withActionQuota(t *testing.T, action string, limit int) {
+	previous := utils.TwitterActionQuotas[action]
+	utils.TwitterActionQuotas[action] = limit
+	t.Cleanup(func() { utils.TwitterActionQuotas[action] = previous })
+}
+
+func TestLikeTweet(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	readOnly := testTwitterAccount(t, user)
+	withTwitterScopes(t, readOnly, models.TwitterScopeRead)
+	full := testTwitterAccount(t, user)
+	testTwitterWrite(t, full, utils.TwitterActionLike, true, false)
+	withActionQuota(t, utils.TwitterActionLike, 1)
+	like := func(dryRun bool) map[string]interface{} {
+		return map[string]interface{}{"url": "https://x.com/someone/status/1234567890", "dry_run": dryRun}
+	}
+
+	runAPITests(t, http.MethodPost, "/twitter/actions/like", LikeTweet, []apiTest{
+		{name: "no token", path: "/twitter/actions/like", body: like(true), status: http.StatusUnauthorized},
+		{name: "no URL", path: "/twitter/actions/like", token: account.Token, body: map[string]bool{"dry_run": true}, status: http.StatusBadRequest},
+		{
+			name: "invalid tweet URL", path: "/twitter/actions/like", token: account.Token,
+			body: map[string]interface{}{"url": "https://x.com/someone", "dry_run": true}, status: http.StatusBadRequest,
+		},
+		{name: "token without the write scope", path: "/twitter/actions/like", token: readOnly.Token, body: like(true), status: http.StatusForbidden},
+		{name: "quota reached", path: "/twitter/actions/like", token: full.Token, body: like(true), status: http.StatusTooManyRequests},
+		{
+			name: "dry run", path: "/twitter/actions/like", token: account.Token, body: like(true), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "dry_run", true)
+				wantField(t, res["preview"].(map[string]interface{}), "tweet_id", "1234567890")
+				wantField(t, res["quota"].(map[string]interface{}), "remaining", 1)
+			},
+		},
+		{
+			name: "dry runs leave the quota alone", path: "/twitter/actions/like", token: account.Token, body: like(true), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["quota"].(map[string]interface{}), "used", 0)
+			},
+		},
+	})
+}
+
+func TestPostTweet(t *testing.T) {
+	account := testTwitterAccount(t, testUser(t))
+
+	runAPITests(t, http.MethodPost, "/twitter/actions/tweet", PostTweet, []apiTest{
+		{name: "no text or media", path: "/twitter/actions/tweet", token: account.Token, body: map[string]interface{}{"text": "  ", "dry_run": true}, status: http.StatusBadRequest},
+		{
+			name: "text too long", path: "/twitter/actions/tweet", token: account.Token,
+			body: map[string]interface{}{"text": strings.Repeat("é", maxTweetLength+1), "dry_run": true}, status: http.StatusBadRequest,
+		},
+		{
+			name: "dry run", path: "/twitter/actions/tweet", token: account.Token,
+			body: map[string]interface{}{"text": " gm ", "dry_run": true}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "action", utils.TwitterActionTweet)
+				wantField(t, res["preview"].(map[string]interface{}), "text", "gm")
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodPost, "/twitter/actions/reply", PostReply, []apiTest{
+		{name: "invalid tweet URL", path: "/twitter/actions/reply", token: account.Token, body: map[string]interface{}{"text": "gm", "dry_run": true}, status: http.StatusBadRequest},
+		{
+			name: "dry run", path: "/twitter/actions/reply", token: account.Token,
+			body: map[string]interface{}{"text": "gm", "url": "https://x.com/someone/status/1234567890", "dry_run": true}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["preview"].(map[string]interface{}), "in_reply_to", "1234567890")
+			},
+		},
+	})
+}
+
+func TestReadTweetMedia(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		wantErr     bool
+	}{
+		{name: "JSON request", contentType: "application/json"},
+		{name: "no content type"},
+		{name: "multipart without a boundary", contentType: "multipart/form-data", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/twitter/actions/tweet", strings.NewReader("{}"))
+			if tt.contentType != "" {
+				c.Request.Header.Set("Content-Type", tt.contentType)
+			}
+
+			media, err := readTweetMedia(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readTweetMedia() error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(media) != 0 {
+				t.Errorf("read %d media files, want none", len(media))
+			}
+		})
+	}
+}
+
+func TestFollowUser(t *testing.T) {
+	account := testTwitterAccount(t, testUser(t))
+
+	runAPITests(t, http.MethodPost, "/twitter/actions/follow", FollowUser, []apiTest{
+		{name: "no username", path: "/twitter/actions/follow", token: account.Token, body: map[string]bool{"dry_run": true}, status: http.StatusBadRequest},
+		{
+			name: "dry run", path: "/twitter/actions/follow", token: account.Token,
+			body: map[string]interface{}{"username": " @someone", "dry_run": true}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["preview"].(map[string]interface{}), "username", "someone")
+			},
+		},
+	})
+}
+
+func TestGetTwitterActionQuotas(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	testTwitterWrite(t, account, utils.TwitterActionLike, true, false)
+	testTwitterWrite(t, account, utils.TwitterActionLike, true, true)
+	testTwitterWrite(t, account, utils.TwitterActionLike, false, false)
+	testTwitterWrite(t, testTwitterAccount(t, user), utils.TwitterActionLike, true, false)
+
+	runAPITests(t, http.MethodGet, "/twitter/actions/quotas", GetTwitterActionQuotas, []apiTest{
+		{name: "no token", path: "/twitter/actions/quotas", status: http.StatusUnauthorized},
+		{
+			name: "successful writes of the account", path: "/twitter/actions/quotas", token: account.Token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "account", account.Username)
+				wantCount(t, res, "quotas", 8)
+				for _, q := range res["quotas"].([]interface{}) {
+					q := q.(map[string]interface{})
+					used := 0
+					if q["action"] == utils.TwitterActionLike {
+						used = 1
+					}
+					wantField(t, q, "used", used)
+				}
+			},
+		},
+	})
+}
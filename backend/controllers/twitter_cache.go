@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"ripper-backend/utils"
+	utils_cache "ripper-backend/utils/cache"
+	utils_twitter "ripper-backend/utils/twitter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// twitterCacheTTLs is how long scraped data is reused per operation. Each TTL
+// can be overridden in seconds through its environment variable; 0 disables caching.
+var twitterCacheTTLs = map[string]time.Duration{
+	utils_twitter.OpTweetResult: utils_cache.TTLFromEnv("CACHE_TTL_TWEET", 5*time.Minute),
+	utils_twitter.OpFavoriters:  utils_cache.TTLFromEnv("CACHE_TTL_LIKES", 10*time.Minute),
+	utils_twitter.OpSearch:      utils_cache.TTLFromEnv("CACHE_TTL_QUOTES", 10*time.Minute),
+	utils_twitter.OpTweetDetail: utils_cache.TTLFromEnv("CACHE_TTL_COMMENTS", 5*time.Minute),
+	utils_twitter.OpRetweeters:  utils_cache.TTLFromEnv("CACHE_TTL_REPOSTS", 10*time.Minute),
+	tweetAnalyticsOp:            utils_cache.TTLFromEnv("CACHE_TTL_ANALYTICS", 5*time.Minute),
+}
+
+// wantsFreshData reports whether the caller asked to bypass the cache with ?fresh=true
+func wantsFreshData(c *gin.Context) bool {
+	return c.Query("fresh") == "true"
+}
+
+// getCachedTwitterData fills v with the result of operation on tweetID that
+// userID scraped earlier. It reports false on a miss, when caching is off for
+// the operation or when the caller asked for fresh data.
+func getCachedTwitterData(c *gin.Context, userID, operation, tweetID, cursor string, v interface{}) bool {
+	if wantsFreshData(c) || twitterCacheTTLs[operation] <= 0 {
+		return false
+	}
+	return utils_cache.Get(utils_cache.Key(userID, operation, tweetID, cursor), v)
+}
+
+// setCachedTwitterData stores a result of operation on tweetID freshly scraped by userID
+func setCachedTwitterData(userID, operation, tweetID, cursor string, v interface{}) {
+	utils_cache.Set(utils_cache.Key(userID, operation, tweetID, cursor), v, twitterCacheTTLs[operation])
+}
+
+// setCacheHeader sets X-Cache to HIT, MISS or BYPASS and returns the quota cost of a lookup of operation
+func setCacheHeader(c *gin.Context, operation string, hit bool) int {
+	switch {
+	case hit:
+		c.Header("X-Cache", "HIT")
+		return utils.TwitterCacheHitCost
+	case wantsFreshData(c):
+		c.Header("X-Cache", "BYPASS")
+	default:
+		c.Header("X-Cache", "MISS")
+	}
+	return utils.TwitterRequestCost(operation)
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryInt reads a positive integer query parameter, clamped to max
+func queryInt(c *gin.Context, name string, def, max int) int {
+	value, err := strconv.Atoi(c.Query(name))
+	if err != nil || value < 1 {
+		return def
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// engagementTypeFromQuery validates the optional ?type= filter
+func engagementTypeFromQuery(c *gin.Context) (string, bool) {
+	switch t := c.Query("type"); t {
+	case "", models.EngagementLiked, models.EngagementRetweeted, models.EngagementQuoted, models.EngagementReplied:
+		return t, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "type must be liked, retweeted, quoted or replied"})
+	return "", false
+}
+
+// GetDatasetTweets godoc
+// @Summary      List Stored Tweets
+// @Description  List tweets stored from your earlier scrapes, newest first (requires JWT authentication)
+// @Tags         dataset
+// @Produce      json
+// @Security     BearerAuth
+// @Param        author query string false "Only tweets by this username"
+// @Param        limit query int false "Maximum number of tweets" default(50)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/dataset/tweets [get]
+func GetDatasetTweets(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	limit := queryInt(c, "limit", 50, 1000)
+	query := config.DB.Model(&models.TwitterTweet{}).Scopes(utils.InDataset(user.ID))
+	if author := strings.TrimPrefix(c.Query("author"), "@"); author != "" {
+		query = query.Where("LOWER(author_username) = LOWER(?)", author)
+	}
+
+	var tweets []models.TwitterTweet
+	if err := query.Order("LENGTH(id) DESC, id DESC").Limit(limit).Find(&tweets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tweets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweets": tweets, "count": len(tweets)})
+}
+
+// GetDatasetTweetEngagers godoc
+// @Summary      List Stored Engagers of a Tweet
+// @Description  List users that engaged with a tweet according to your earlier scrapes (requires JWT authentication)
+// @Tags         dataset
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Tweet ID"
+// @Param        type query string false "liked, retweeted, quoted or replied"
+// @Param        limit query int false "Maximum number of users" default(100)
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/dataset/tweets/{id}/engagers [get]
+func GetDatasetTweetEngagers(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	tweetID := utils_twitter.NormalizeTweetID(c.Param("id"))
+	if tweetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tweet ID or URL"})
+		return
+	}
+
+	engagementType, ok := engagementTypeFromQuery(c)
+	if !ok {
+		return
+	}
+
+	users, err := utils.TweetEngagers(user.ID, tweetID, engagementType, queryInt(c, "limit", 100, 5000))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch engagers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweet_id": tweetID, "users": users, "count": len(users)})
+}
+
+// GetDatasetUser godoc
+// @Summary      Get Stored Twitter User
+// @Description  Get a stored Twitter profile and its engagements from your earlier scrapes (requires JWT authentication)
+// @Tags         dataset
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Twitter user ID or @username"
+// @Param        limit query int false "Maximum number of engagements" default(100)
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/dataset/users/{id} [get]
+func GetDatasetUser(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var twitterUser models.TwitterUser
+	id := c.Param("id")
+	query := config.DB.Where("id = ?", id)
+	if strings.HasPrefix(id, "@") {
+		query = config.DB.Where("LOWER(username) = LOWER(?)", strings.TrimPrefix(id, "@"))
+	}
+	if err := query.First(&twitterUser).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Twitter user not found in dataset"})
+		return
+	}
+
+	engagements, err := utils.TwitterUserEngagements(user.ID, twitterUser.ID, queryInt(c, "limit", 100, 1000))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch engagements"})
+		return
+	}
+
+	counts := map[string]int{}
+	for _, e := range engagements {
+		counts[e.Type]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":              twitterUser,
+		"engagements":       engagements,
+		"engagement_counts": counts,
+		"count":             len(engagements),
+	})
+}
+
+// GetDatasetTopEngagers godoc
+// @Summary      Find Repeat Engagers
+// @Description  Find users that engaged with at least `min` of a set of tweets, e.g. who liked more than 3 of an author's last 20 tweets. The tweets are either the latest `last` stored tweets of `author`, or an explicit comma-separated `tweets` list (requires JWT authentication)
+// @Tags         dataset
+// @Produce      json
+// @Security     BearerAuth
+// @Param        author query string false "Use the latest stored tweets of this username"
+// @Param        last query int false "How many of the author's latest tweets to consider" default(20)
+// @Param        tweets query string false "Comma-separated tweet IDs or URLs (instead of author)"
+// @Param        type query string false "liked, retweeted, quoted or replied (default any)"
+// @Param        min query int false "Minimum number of tweets engaged with" default(1)
+// @Param        limit query int false "Maximum number of users" default(100)
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/dataset/top-engagers [get]
+func GetDatasetTopEngagers(c *gin.Context) {
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	engagementType, ok := engagementTypeFromQuery(c)
+	if !ok {
+		return
+	}
+
+	var tweetIDs []string
+	if raw := c.Query("tweets"); raw != "" {
+		for _, input := range strings.Split(raw, ",") {
+			if tweetID := utils_twitter.NormalizeTweetID(strings.TrimSpace(input)); tweetID != "" {
+				tweetIDs = append(tweetIDs, tweetID)
+			}
+		}
+	} else if author := strings.TrimPrefix(c.Query("author"), "@"); author != "" {
+		ids, err := utils.LatestTweetIDsByAuthor(user.ID, author, queryInt(c, "last", 20, 500))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tweets"})
+			return
+		}
+		tweetIDs = ids
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either author or tweets is required"})
+		return
+	}
+
+	minTweets := queryInt(c, "min", 1, 500)
+	engagers, err := utils.TopEngagers(user.ID, tweetIDs, engagementType, minTweets, queryInt(c, "limit", 100, 5000))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch engagers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tweets":   tweetIDs,
+		"min":      minTweets,
+		"engagers": engagers,
+		"count":    len(engagers),
+	})
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var lastTwitterID atomic.Int64
+
+// testTwitterID returns a numeric tweet or Twitter user ID no other test uses,
+// larger than the ones returned before
+func testTwitterID() string {
+	lastTwitterID.CompareAndSwap(0, time.Now().UnixNano())
+	return strconv.FormatInt(lastTwitterID.Add(1), 10)
+}
+
+// datasetFixture is what a user scraped: three tweets of an author, oldest
+// first, and two Twitter users who engaged with them
+type datasetFixture struct {
+	author         string
+	tweets         []string
+	loyal, casual  *models.TwitterUser
+	user, stranger *models.User
+}
+
+// newDatasetFixture stores the dataset. loyal liked every tweet; casual liked
+// the first and replied to the second. stranger scraped casual liking the third.
+func newDatasetFixture(t *testing.T) datasetFixture {
+	t.Helper()
+
+	f := datasetFixture{
+		author:   "author_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:8],
+		user:     testUser(t),
+		stranger: testUser(t),
+	}
+	for i := 0; i < 3; i++ {
+		tweet := models.TwitterTweet{ID: testTwitterID(), AuthorID: "1", AuthorUsername: f.author, Text: "tweet " + strconv.Itoa(i)}
+		if err := config.DB.Create(&tweet).Error; err != nil {
+			t.Fatalf("failed to store the tweet: %v", err)
+		}
+		f.tweets = append(f.tweets, tweet.ID)
+	}
+	f.loyal = &models.TwitterUser{ID: testTwitterID(), Username: "loyal_" + f.author, Followers: 10}
+	f.casual = &models.TwitterUser{ID: testTwitterID(), Username: "casual_" + f.author, Followers: 5}
+	for _, u := range []*models.TwitterUser{f.loyal, f.casual} {
+		if err := config.DB.Create(u).Error; err != nil {
+			t.Fatalf("failed to store the Twitter user: %v", err)
+		}
+	}
+
+	engage := func(user *models.User, twitterUser *models.TwitterUser, tweetID, kind string) {
+		engagement := models.TwitterEngagement{UserID: user.ID, TweetID: tweetID, TwitterUserID: twitterUser.ID, Type: kind, LastSeenAt: time.Now()}
+		if err := config.DB.Create(&engagement).Error; err != nil {
+			t.Fatalf("failed to store the engagement: %v", err)
+		}
+	}
+	for _, tweetID := range f.tweets {
+		engage(f.user, f.loyal, tweetID, models.EngagementLiked)
+	}
+	engage(f.user, f.casual, f.tweets[0], models.EngagementLiked)
+	engage(f.user, f.casual, f.tweets[1], models.EngagementReplied)
+	engage(f.stranger, f.casual, f.tweets[2], models.EngagementLiked)
+	return f
+}
+
+func TestGetDatasetTweets(t *testing.T) {
+	f := newDatasetFixture(t)
+	token := userToken(t, f.user)
+
+	runAPITests(t, http.MethodGet, "/twitter/dataset/tweets", GetDatasetTweets, []apiTest{
+		{name: "no token", path: "/twitter/dataset/tweets", status: http.StatusUnauthorized},
+		{
+			name: "by author, newest first", path: "/twitter/dataset/tweets?author=@" + strings.ToUpper(f.author), token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 3)
+				wantField(t, res["tweets"].([]interface{})[0].(map[string]interface{}), "id", f.tweets[2])
+			},
+		},
+		{
+			name: "limit", path: "/twitter/dataset/tweets?limit=1&author=" + f.author, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 1) },
+		},
+		{
+			name: "only tweets the user scraped", path: "/twitter/dataset/tweets?author=" + f.author, token: userToken(t, f.stranger), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 1)
+				wantField(t, res["tweets"].([]interface{})[0].(map[string]interface{}), "id", f.tweets[2])
+			},
+		},
+	})
+}
+
+func TestGetDatasetTweetEngagers(t *testing.T) {
+	f := newDatasetFixture(t)
+	token := userToken(t, f.user)
+	route := "/twitter/dataset/tweets/:id/engagers"
+	engagers := func(tweetID string) string { return "/twitter/dataset/tweets/" + tweetID + "/engagers" }
+
+	runAPITests(t, http.MethodGet, route, GetDatasetTweetEngagers, []apiTest{
+		{name: "invalid tweet ID", path: engagers("nonsense"), token: token, status: http.StatusBadRequest},
+		{name: "unknown type", path: engagers(f.tweets[0]) + "?type=viewed", token: token, status: http.StatusBadRequest},
+		{
+			name: "every engager, most followed first", path: engagers(f.tweets[0]), token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				wantField(t, res["users"].([]interface{})[0].(map[string]interface{}), "id", f.loyal.ID)
+			},
+		},
+		{
+			name: "repliers", path: engagers(f.tweets[1]) + "?type=replied", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 1)
+				wantField(t, res["users"].([]interface{})[0].(map[string]interface{}), "id", f.casual.ID)
+			},
+		},
+		{
+			name: "what another user scraped is left out", path: engagers(f.tweets[2]), token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 1) },
+		},
+	})
+}
+
+func TestGetDatasetUser(t *testing.T) {
+	f := newDatasetFixture(t)
+	token := userToken(t, f.user)
+
+	runAPITests(t, http.MethodGet, "/twitter/dataset/users/:id", GetDatasetUser, []apiTest{
+		{name: "unknown user", path: "/twitter/dataset/users/@nobody_" + f.author, token: token, status: http.StatusNotFound},
+		{
+			name: "by ID", path: "/twitter/dataset/users/" + f.casual.ID, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				wantField(t, res, "engagement_counts", map[string]int{models.EngagementLiked: 1, models.EngagementReplied: 1})
+			},
+		},
+		{
+			name: "by username", path: "/twitter/dataset/users/@" + f.loyal.Username, token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res["user"].(map[string]interface{}), "id", f.loyal.ID)
+				wantField(t, res, "count", 3)
+			},
+		},
+	})
+}
+
+func TestGetDatasetTopEngagers(t *testing.T) {
+	f := newDatasetFixture(t)
+	token := userToken(t, f.user)
+	path := "/twitter/dataset/top-engagers"
+
+	runAPITests(t, http.MethodGet, path, GetDatasetTopEngagers, []apiTest{
+		{name: "no author or tweets", path: path, token: token, status: http.StatusBadRequest},
+		{name: "unknown type", path: path + "?author=" + f.author + "&type=viewed", token: token, status: http.StatusBadRequest},
+		{
+			name: "engaged with two of the author's tweets", path: path + "?author=" + f.author + "&min=2", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				top := res["engagers"].([]interface{})[0].(map[string]interface{})
+				wantField(t, top, "id", f.loyal.ID)
+				wantField(t, top, "engaged_tweets", 3)
+			},
+		},
+		{
+			name: "liked two of the author's tweets", path: path + "?author=" + f.author + "&min=2&type=liked", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) { wantField(t, res, "count", 1) },
+		},
+		{
+			name: "latest tweet only", path: path + "?author=" + f.author + "&last=1", token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "tweets", []string{f.tweets[2]})
+				wantField(t, res, "count", 1)
+			},
+		},
+		{
+			name: "tweet list", path: path + "?tweets=" + f.tweets[0] + ",nonsense," + f.tweets[1], token: token, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "tweets", []string{f.tweets[0], f.tweets[1]})
+				wantField(t, res, "count", 2)
+			},
+		},
+		{
+			name: "only the author's tweets the user scraped", path: path + "?author=" + f.author, token: userToken(t, f.stranger), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "tweets", []string{f.tweets[2]})
+				wantField(t, res, "count", 1)
+			},
+		},
+	})
+}
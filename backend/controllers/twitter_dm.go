@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDMLength is the longest text X accepts in a direct message
+const maxDMLength = 10000
+
+// readDMInbox runs a DM read of operation from the token's own account
+func readDMInbox(c *gin.Context, endpoint, operation string, fn func(*models.TwitterAccount, *utils_twitter.Session) (gin.H, error)) {
+	startTime := time.Now()
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", endpoint, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkTwitterScope(twitterAccount, models.TwitterScopeDM); err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusForbidden, err.Error())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation, err := utils.ReserveTwitterRequests(twitterAccount.UserID, utils.TwitterRequestCost(operation), endpoint)
+	if err != nil {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusTooManyRequests, err.Error())
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := loadOwnTwitterSession(twitterAccount)
+	if err != nil {
+		reservation.Refund()
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := fn(twitterAccount, session)
+	if err != nil {
+		reservation.Refund()
+		status := twitterErrorStatus(err)
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, status, err.Error())
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservation.Commit(reservation.Amount)
+	utils.LogTwitterAPICall(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, true, http.StatusOK, "")
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDMInbox godoc
+// @Summary      List DM Conversations
+// @Description  List the DM inbox of the token's Twitter account, most recent conversation first, with participants and the last message. Pass next_cursor back as cursor for older conversations. Requires the dm token scope (requires Twitter token authentication)
+// @Tags         dm
+// @Produce      json
+// @Security     BearerAuth
+// @Param        cursor query string false "Cursor from a previous page"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/dm/inbox [get]
+func GetDMInbox(c *gin.Context) {
+	readDMInbox(c, "/twitter/dm/inbox", utils_twitter.OpDMInbox, func(account *models.TwitterAccount, session *utils_twitter.Session) (gin.H, error) {
+		conversations, next, err := session.GetDMInbox(c.Query("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{
+			"account":       account.Username,
+			"conversations": conversations,
+			"count":         len(conversations),
+			"next_cursor":   next,
+		}, nil
+	})
+}
+
+// GetDMConversation godoc
+// @Summary      Get DM Conversation Messages
+// @Description  Get a page of a DM conversation's messages, newest first. Pass next_cursor back as cursor for older messages. Requires the dm token scope (requires Twitter token authentication)
+// @Tags         dm
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id path string true "Conversation ID"
+// @Param        cursor query string false "Cursor from a previous page"
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/dm/conversations/{id} [get]
+func GetDMConversation(c *gin.Context) {
+	readDMInbox(c, "/twitter/dm/conversations", utils_twitter.OpDMConversation, func(account *models.TwitterAccount, session *utils_twitter.Session) (gin.H, error) {
+		messages, next, err := session.GetDMConversation(c.Param("id"), c.Query("cursor"))
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{
+			"account":         account.Username,
+			"conversation_id": c.Param("id"),
+			"messages":        messages,
+			"count":           len(messages),
+			"next_cursor":     next,
+		}, nil
+	})
+}
+
+// SendDM godoc
+// @Summary      Send a Direct Message
+// @Description  Send a DM from the token's Twitter account to a username or an existing conversation. Send JSON, or multipart form data with one "media" image, GIF or video. Requires the dm token scope, counts toward the daily DM quota and is paced per account by TWITTER_DM_INTERVAL; dry_run validates without sending (requires Twitter token authentication)
+// @Tags         dm
+// @Accept       json,mpfd
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.SendDMRequest true "Recipient and text"
+// @Success      200 {object} map[string]interface{}
+// @Header       429 {string} Retry-After "Seconds until the account may send its next DM"
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      404 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Failure      502 {object} map[string]string
+// @Router       /twitter/actions/dm [post]
+func SendDM(c *gin.Context) {
+	startTime := time.Now()
+	endpoint := utils.TwitterActionEndpoint(utils.TwitterActionDM)
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", endpoint, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req schemas.SendDMRequest
+	fail := func(status int, errMsg string) {
+		utils.LogTwitterWrite(c, twitterAccount.UserID, twitterAccount.Username, endpoint, startTime, false, status, errMsg, req.DryRun)
+		c.JSON(status, gin.H{"error": errMsg})
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	media, err := readTweetMedia(c)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(media) > 1 {
+		fail(http.StatusBadRequest, "a DM can have at most one media file")
+		return
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" && len(media) == 0 {
+		fail(http.StatusBadRequest, "text or media is required")
+		return
+	}
+	if utf8.RuneCountInString(text) > maxDMLength {
+		fail(http.StatusBadRequest, fmt.Sprintf("text is longer than %d characters", maxDMLength))
+		return
+	}
+
+	if err := utils.CheckTwitterDMPacing(twitterAccount); err != nil {
+		setRetryAfter(c, err.RetryAt)
+		fail(http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	to := strings.TrimSpace(req.To)
+	preview := gin.H{"to": to, "text": text}
+	if len(media) > 0 {
+		preview["media"] = gin.H{"filename": media[0].Filename, "type": media[0].MimeType, "bytes": len(media[0].Data)}
+	}
+
+	executeTwitterWrite(c, twitterAccount, startTime, utils.TwitterActionDM, req.DryRun, preview, func(session *utils_twitter.Session) (gin.H, error) {
+		conversationID, err := session.ResolveDMConversation(to)
+		if err != nil {
+			return nil, err
+		}
+
+		mediaID := ""
+		if len(media) > 0 {
+			if mediaID, err = session.UploadDMMedia(media[0].Data, media[0].MimeType); err != nil {
+				return nil, err
+			}
+		}
+
+		messageID, err := session.SendDM(conversationID, text, mediaID)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{"conversation_id": conversationID, "message_id": messageID}, nil
+	})
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendDM(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	noDM := testTwitterAccount(t, user)
+	withTwitterScopes(t, noDM, models.TwitterScopeRead+","+models.TwitterScopeWrite)
+	paced := testTwitterAccount(t, user)
+	testTwitterWrite(t, paced, utils.TwitterActionDM, true, false)
+	dm := map[string]interface{}{"to": " @someone ", "text": "hello", "dry_run": true}
+
+	runAPITests(t, http.MethodPost, "/twitter/actions/dm", SendDM, []apiTest{
+		{name: "no token", path: "/twitter/actions/dm", body: dm, status: http.StatusUnauthorized},
+		{name: "no recipient", path: "/twitter/actions/dm", token: account.Token, body: map[string]interface{}{"text": "hello", "dry_run": true}, status: http.StatusBadRequest},
+		{name: "no text or media", path: "/twitter/actions/dm", token: account.Token, body: map[string]interface{}{"to": "someone", "dry_run": true}, status: http.StatusBadRequest},
+		{
+			name: "text too long", path: "/twitter/actions/dm", token: account.Token,
+			body: map[string]interface{}{"to": "someone", "text": strings.Repeat("a", maxDMLength+1), "dry_run": true}, status: http.StatusBadRequest,
+		},
+		{name: "token without the dm scope", path: "/twitter/actions/dm", token: noDM.Token, body: dm, status: http.StatusForbidden},
+		{name: "sent a DM moments ago", path: "/twitter/actions/dm", token: paced.Token, body: dm, status: http.StatusTooManyRequests},
+		{
+			name: "dry run", path: "/twitter/actions/dm", token: account.Token, body: dm, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "action", utils.TwitterActionDM)
+				wantField(t, res, "dry_run", true)
+				wantField(t, res, "preview", map[string]string{"to": "@someone", "text": "hello"})
+			},
+		},
+	})
+}
+
+func TestGetDMInbox(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	noDM := testTwitterAccount(t, user)
+	withTwitterScopes(t, noDM, models.TwitterScopeRead)
+	broke := testUser(t)
+	brokeAccount := testTwitterAccount(t, broke)
+	withBalance(t, broke, "twitter_reqs", 0)
+	reads := loadUser(t, user.ID).TwitterReqs
+
+	runAPITests(t, http.MethodGet, "/twitter/dm/inbox", GetDMInbox, []apiTest{
+		{name: "no token", path: "/twitter/dm/inbox", status: http.StatusUnauthorized},
+		{name: "token without the dm scope", path: "/twitter/dm/inbox", token: noDM.Token, status: http.StatusForbidden},
+		{name: "no Twitter reads left", path: "/twitter/dm/inbox", token: brokeAccount.Token, status: http.StatusTooManyRequests},
+		{
+			name: "no session", path: "/twitter/dm/inbox", token: account.Token, status: http.StatusUnauthorized,
+			check: func(t *testing.T, res map[string]interface{}) {
+				if left := loadUser(t, user.ID).TwitterReqs; left != reads {
+					t.Errorf("%d Twitter reads left, want the %d reserved refunded", left, reads)
+				}
+			},
+		},
+	})
+
+	runAPITests(t, http.MethodGet, "/twitter/dm/conversations/:id", GetDMConversation, []apiTest{
+		{name: "token without the dm scope", path: "/twitter/dm/conversations/1-2", token: noDM.Token, status: http.StatusForbidden},
+		{name: "no session", path: "/twitter/dm/conversations/1-2", token: account.Token, status: http.StatusUnauthorized},
+	})
+}
+
+func TestSendBulkDMs(t *testing.T) {
+	user := testUser(t)
+	testScheduler(t)
+	account := testTwitterAccount(t, user)
+	other := testTwitterAccount(t, testUser(t))
+	token := userToken(t, user)
+	bulk := func(username string, messages ...string) map[string]interface{} {
+		list := make([]map[string]string, len(messages))
+		for i, message := range messages {
+			list[i] = map[string]string{"recipient": "someone" + string(rune('a'+i)), "message": message}
+		}
+		return map[string]interface{}{"session_name": username, "channel": models.ChannelTwitterDM, "messages": list}
+	}
+
+	runAPITests(t, http.MethodPost, "/whatsapp/send-bulk", SendBulkMessages, []apiTest{
+		{
+			name: "unknown channel", path: "/whatsapp/send-bulk", token: token,
+			body:   map[string]interface{}{"session_name": account.Username, "channel": "email", "messages": []map[string]string{{"recipient": "a", "message": "hi"}}},
+			status: http.StatusBadRequest,
+		},
+		{name: "no messages", path: "/whatsapp/send-bulk", token: token, body: bulk(account.Username), status: http.StatusBadRequest},
+		{name: "account of another user", path: "/whatsapp/send-bulk", token: token, body: bulk(other.Username, "hi"), status: http.StatusNotFound},
+		{name: "message too long", path: "/whatsapp/send-bulk", token: token, body: bulk(account.Username, strings.Repeat("a", maxDMLength+1)), status: http.StatusBadRequest},
+		{
+			name: "paced DMs", path: "/whatsapp/send-bulk", token: token, body: bulk(account.Username, "hi", "hello"), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "total", 2)
+				items := scheduledBatch(t, res["batch_id"].(string))
+				if len(items) != 2 {
+					t.Fatalf("stored %d DMs, want 2", len(items))
+				}
+				if gap := items[1].ScheduledAt.Sub(items[0].ScheduledAt); gap < utils.TwitterDMInterval-time.Second {
+					t.Errorf("DMs %v apart, want at least %v", gap, utils.TwitterDMInterval)
+				}
+				for _, item := range items {
+					if item.Channel != models.ChannelTwitterDM || item.TwitterAccountID == nil || *item.TwitterAccountID != account.ID {
+						t.Errorf("DM %d stored on %s", item.SequenceNumber, item.Channel)
+					}
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// twitterEndpoint declares a tweet scraping endpoint served by serveTwitterEndpoint:
+// the X operation it runs, the token scope it needs, how a fresh result is
+// stored and how the response is shaped. T is the type of the scraped data.
+// What a call costs comes from utils.TwitterScrapeCost for the operation.
+type twitterEndpoint[T any] struct {
+	Path      string // endpoint recorded in the API call log
+	Operation string // X operation; selects the rate limit bucket, cache TTL and cost
+	Scope     string // token scope required to call the endpoint
+	FailMsg   string // error reported when the scrape fails for an unknown reason
+	// FilterEngagers accepts the min_quality and exclude_bots query parameters
+	FilterEngagers bool
+	// Fetch scrapes the data of a tweet
+	Fetch func(session *utils_twitter.Session, tweetID string) (T, error)
+	// Save stores freshly scraped data in the background; optional
+	Save func(userID, tweetID string, data T)
+	// Respond shapes the response body
+	Respond func(data T, filter qualityFilter) interface{}
+}
+
+// Tweet scraping endpoints. Each takes {"url": "<tweet URL>"}, honours ?pool=
+// and ?fresh=, and is served from the response cache when possible.
+var (
+	tweetEndpoint = twitterEndpoint[*utils_twitter.TweetWithMedia]{
+		Path:      "/twitter/post",
+		Operation: utils_twitter.OpTweetResult,
+		Scope:     models.TwitterScopeRead,
+		FailMsg:   "Failed to fetch tweet data",
+		Fetch: func(session *utils_twitter.Session, tweetID string) (*utils_twitter.TweetWithMedia, error) {
+			return session.GetTweetWithMedia(tweetID)
+		},
+		Save: func(userID, tweetID string, tweet *utils_twitter.TweetWithMedia) {
+			utils.SaveScrapedTweet(tweet.Tweet)
+		},
+		Respond: func(tweet *utils_twitter.TweetWithMedia, _ qualityFilter) interface{} {
+			return tweet
+		},
+	}
+
+	likesEndpoint = twitterEndpoint[[]*utils_twitter.User]{
+		Path:           "/twitter/post/likes",
+		Operation:      utils_twitter.OpFavoriters,
+		Scope:          models.TwitterScopeRead,
+		FailMsg:        "Failed to fetch likers data",
+		FilterEngagers: true,
+		Fetch: func(session *utils_twitter.Session, tweetID string) ([]*utils_twitter.User, error) {
+			return session.GetLikers(tweetID)
+		},
+		Save: func(userID, tweetID string, likers []*utils_twitter.User) {
+			utils.SaveEngagers(userID, tweetID, models.EngagementLiked, likers)
+		},
+		Respond: func(likers []*utils_twitter.User, filter qualityFilter) interface{} {
+			likers, filtered := filter.apply(likers)
+			return gin.H{"likers": likers, "count": len(likers), "filtered_count": filtered}
+		},
+	}
+
+	quotesEndpoint = twitterEndpoint[[]*utils_twitter.Tweet]{
+		Path:      "/twitter/post/quotes",
+		Operation: utils_twitter.OpSearch,
+		Scope:     models.TwitterScopeRead,
+		FailMsg:   "Failed to fetch quotes data",
+		Fetch: func(session *utils_twitter.Session, tweetID string) ([]*utils_twitter.Tweet, error) {
+			return session.SearchQuotedTweets(tweetID)
+		},
+		Save: func(userID, tweetID string, quotes []*utils_twitter.Tweet) {
+			utils.SaveEngagementTweets(userID, tweetID, models.EngagementQuoted, quotes)
+		},
+		Respond: func(quotes []*utils_twitter.Tweet, _ qualityFilter) interface{} {
+			return gin.H{"quotes": quotes, "count": len(quotes)}
+		},
+	}
+
+	commentsEndpoint = twitterEndpoint[[]*utils_twitter.Tweet]{
+		Path:      "/twitter/post/comments",
+		Operation: utils_twitter.OpTweetDetail,
+		Scope:     models.TwitterScopeRead,
+		FailMsg:   "Failed to fetch comments data",
+		Fetch: func(session *utils_twitter.Session, tweetID string) ([]*utils_twitter.Tweet, error) {
+			return session.GetAllTweetReplies(tweetID)
+		},
+		Save: func(userID, tweetID string, comments []*utils_twitter.Tweet) {
+			utils.SaveEngagementTweets(userID, tweetID, models.EngagementReplied, comments)
+		},
+		Respond: func(comments []*utils_twitter.Tweet, _ qualityFilter) interface{} {
+			return gin.H{"comments": comments, "count": len(comments)}
+		},
+	}
+
+	repostsEndpoint = twitterEndpoint[[]*utils_twitter.User]{
+		Path:           "/twitter/post/reposts",
+		Operation:      utils_twitter.OpRetweeters,
+		Scope:          models.TwitterScopeRead,
+		FailMsg:        "Failed to fetch reposts data",
+		FilterEngagers: true,
+		Fetch: func(session *utils_twitter.Session, tweetID string) ([]*utils_twitter.User, error) {
+			return session.GetRetweeters(tweetID)
+		},
+		Save: func(userID, tweetID string, retweeters []*utils_twitter.User) {
+			utils.SaveEngagers(userID, tweetID, models.EngagementRetweeted, retweeters)
+		},
+		Respond: func(retweeters []*utils_twitter.User, filter qualityFilter) interface{} {
+			retweeters, filtered := filter.apply(retweeters)
+			return gin.H{"reposts": retweeters, "count": len(retweeters), "filtered_count": filtered}
+		},
+	}
+)
+
+// serveTwitterEndpoint runs the shared pipeline of a tweet scraping endpoint:
+// authenticate the token and check its scope, bind the tweet URL, serve from
+// the cache or scrape through the account pool, bill the reads balance, log
+// the call and respond. The cost is reserved before the scrape, committed with
+// the number of pages fetched and refunded when the scrape fails.
+func serveTwitterEndpoint[T any](c *gin.Context, e twitterEndpoint[T]) {
+	startTime := time.Now()
+	twitterAccount, err := authenticateTwitterToken(c)
+	if err != nil {
+		utils.LogTwitterAPICall(c, "", "", e.Path, startTime, false, http.StatusUnauthorized, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	fail := func(username string, status int, errMsg string) {
+		utils.LogTwitterAPICall(c, twitterAccount.UserID, username, e.Path, startTime, false, status, errMsg)
+		c.JSON(status, gin.H{"error": errMsg})
+	}
+
+	if err := checkTwitterScope(twitterAccount, e.Scope); err != nil {
+		fail(twitterAccount.Username, http.StatusForbidden, err.Error())
+		return
+	}
+
+	var req schemas.GetTweetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		fail(twitterAccount.Username, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tweetID := utils_twitter.ExtractTweetID(req.URL)
+	if tweetID == "" {
+		fail(twitterAccount.Username, http.StatusBadRequest, "Invalid tweet URL")
+		return
+	}
+
+	var filter qualityFilter
+	if e.FilterEngagers {
+		if filter, err = qualityFilterFromQuery(c); err != nil {
+			fail(twitterAccount.Username, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var data T
+	cached := getCachedTwitterData(c, twitterAccount.UserID, e.Operation, tweetID, "", &data)
+	cost := setCacheHeader(c, e.Operation, cached)
+
+	reservation, err := utils.ReserveTwitterRequests(twitterAccount.UserID, cost, e.Path)
+	if err != nil {
+		fail(twitterAccount.Username, http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	usedAccount := twitterAccount
+	if !cached {
+		var status, pages int
+		usedAccount, status, err = runWithTwitterSession(c, twitterAccount, e.Operation, func(session *utils_twitter.Session) error {
+			var err error
+			data, err = e.Fetch(session, tweetID)
+			pages = session.Pages(e.Operation)
+			return err
+		})
+		if err != nil {
+			reservation.Refund()
+			errMsg := err.Error()
+			if status == http.StatusInternalServerError {
+				errMsg = e.FailMsg
+			}
+			fail(usedAccount.Username, status, errMsg)
+			return
+		}
+		cost = utils.TwitterScrapeCost(e.Operation, pages)
+		setCachedTwitterData(twitterAccount.UserID, e.Operation, tweetID, "", data)
+		if e.Save != nil {
+			go e.Save(twitterAccount.UserID, tweetID, data)
+		}
+	}
+
+	reservation.Commit(cost)
+
+	// Log successful call
+	utils.LogTwitterAPICall(c, twitterAccount.UserID, usedAccount.Username, e.Path, startTime, true, http.StatusOK, "")
+
+	c.JSON(http.StatusOK, e.Respond(data, filter))
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+)
+
+func TestGetTweets(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	writeOnly := testTwitterAccount(t, user)
+	withTwitterScopes(t, writeOnly, models.TwitterScopeWrite)
+	broke := testUser(t)
+	brokeAccount := testTwitterAccount(t, broke)
+	withBalance(t, broke, "twitter_reqs", 0)
+	reads := loadUser(t, user.ID).TwitterReqs
+	other := testTwitterAccount(t, testUser(t))
+	url := "https://x.com/someone/status/" + cachedTweet(t, user)
+
+	runAPITests(t, http.MethodPost, "/twitter/post", GetTweets, []apiTest{
+		{name: "no token", path: "/twitter/post", body: map[string]string{"url": url}, status: http.StatusUnauthorized},
+		{name: "token without the read scope", path: "/twitter/post", token: writeOnly.Token, body: map[string]string{"url": url}, status: http.StatusForbidden},
+		{name: "no URL", path: "/twitter/post", token: account.Token, body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "invalid tweet URL", path: "/twitter/post", token: account.Token, body: map[string]string{"url": "https://x.com/someone"}, status: http.StatusBadRequest},
+		{name: "no Twitter reads left", path: "/twitter/post", token: brokeAccount.Token, body: map[string]string{"url": url}, status: http.StatusTooManyRequests},
+		// The tweet is only cached for user, so another user's lookup reaches X, where the test account has no session
+		{name: "cached for another user", path: "/twitter/post", token: other.Token, body: map[string]string{"url": url}, status: http.StatusUnauthorized},
+		{
+			name: "cached tweet", path: "/twitter/post", token: account.Token, body: map[string]string{"url": url}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "text", "cached tweet")
+				if left := loadUser(t, user.ID).TwitterReqs; left != reads-utils.TwitterCacheHitCost {
+					t.Errorf("%d Twitter reads left, want %d", left, reads-utils.TwitterCacheHitCost)
+				}
+			},
+		},
+	})
+}
+
+func TestGetLikes(t *testing.T) {
+	account := testTwitterAccount(t, testUser(t))
+	tweetID := testTwitterID()
+	setCachedTwitterData(account.UserID, utils_twitter.OpFavoriters, tweetID, "", []*utils_twitter.User{
+		{ID: testTwitterID(), Username: "alice", Description: "writes about Go"},
+		{ID: testTwitterID(), Username: "user12345", DefaultProfileImage: true},
+	})
+	likes := map[string]string{"url": "https://x.com/someone/status/" + tweetID}
+
+	runAPITests(t, http.MethodPost, "/twitter/post/likes", GetLikes, []apiTest{
+		{name: "invalid min_quality", path: "/twitter/post/likes?min_quality=101", token: account.Token, body: likes, status: http.StatusBadRequest},
+		{
+			name: "every liker, scored", path: "/twitter/post/likes", token: account.Token, body: likes, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				wantField(t, res, "filtered_count", 0)
+				wantField(t, res["likers"].([]interface{})[0].(map[string]interface{}), "quality_score", 100)
+			},
+		},
+		{
+			name: "likers above a quality score", path: "/twitter/post/likes?min_quality=80", token: account.Token, body: likes, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 1)
+				wantField(t, res, "filtered_count", 1)
+				wantField(t, res["likers"].([]interface{})[0].(map[string]interface{}), "username", "alice")
+			},
+		},
+	})
+}
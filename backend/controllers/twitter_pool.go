@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// poolStrategyFromRequest reads the optional ?pool= query parameter.
+// Without it the request is bound to the account whose token was sent.
+func poolStrategyFromRequest(c *gin.Context) (utils_twitter.PoolStrategy, bool, error) {
+	pool := c.Query("pool")
+	switch utils_twitter.PoolStrategy(pool) {
+	case "":
+		return "", false, nil
+	case utils_twitter.PoolRoundRobin, utils_twitter.PoolLeastRecentlyUsed:
+		return utils_twitter.PoolStrategy(pool), true, nil
+	case "true":
+		return utils_twitter.PoolRoundRobin, true, nil
+	}
+	return "", false, fmt.Errorf("invalid pool strategy %q (use round_robin or lru)", pool)
+}
+
+// runWithTwitterSession loads a Twitter session and runs fn on it. By default the
+// session of the authenticated account is used; with ?pool= any healthy account
+// owned by the same user may serve the request, and when fn hits Twitter's rate
+// limit the account is cooled down and the next one is tried. It returns the
+// account that served (or last attempted) the request and the HTTP status to
+// report on failure.
+func runWithTwitterSession(
+	c *gin.Context,
+	twitterAccount *models.TwitterAccount,
+	operation string,
+	fn func(*utils_twitter.Session) error,
+) (*models.TwitterAccount, int, error) {
+	strategy, usePool, err := poolStrategyFromRequest(c)
+	if err != nil {
+		return twitterAccount, http.StatusBadRequest, err
+	}
+
+	accounts := []models.TwitterAccount{*twitterAccount}
+	if usePool {
+		if err := config.DB.Where("user_id = ?", twitterAccount.UserID).Order("username").Find(&accounts).Error; err != nil {
+			return twitterAccount, http.StatusInternalServerError, fmt.Errorf("failed to load account pool")
+		}
+	}
+
+	byID := make(map[string]models.TwitterAccount, len(accounts))
+	accountIDs := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+		accountIDs = append(accountIDs, account.ID)
+	}
+
+	ordered := utils_twitter.OrderPoolAccounts(twitterAccount.UserID, accountIDs, operation, strategy)
+	if len(ordered) == 0 {
+		setRetryAfter(c, utils_twitter.NextPoolReset(accountIDs, operation))
+		if usePool {
+			return twitterAccount, http.StatusTooManyRequests, fmt.Errorf("all Twitter accounts in the pool are rate limited")
+		}
+		return twitterAccount, http.StatusTooManyRequests, fmt.Errorf("Twitter account is rate limited")
+	}
+
+	lastAccount := twitterAccount
+	lastStatus, lastErr := http.StatusUnauthorized, errors.New("No valid Twitter session found")
+	for _, id := range ordered {
+		account := byID[id]
+		lastAccount = &account
+
+		session, err := utils_twitter.LoadSession(account.UserID, account.ID, account.ProxyURL)
+		if err != nil {
+			if usePool {
+				utils_twitter.MarkAccountUnhealthy(account.ID, "no valid session")
+			}
+			utils.MarkTwitterSessionExpired(account.ID, "No valid Twitter session found")
+			lastStatus, lastErr = http.StatusUnauthorized, errors.New("No valid Twitter session found")
+			continue
+		}
+
+		if !session.Validate() {
+			if usePool {
+				utils_twitter.MarkAccountUnhealthy(account.ID, "session expired")
+			}
+			utils.MarkTwitterSessionExpired(account.ID, "Twitter session expired")
+			lastStatus, lastErr = http.StatusUnauthorized, errors.New("Twitter session expired")
+			continue
+		}
+
+		utils_twitter.MarkAccountUsed(account.ID)
+
+		err = fn(session)
+		if err == nil {
+			return &account, http.StatusOK, nil
+		}
+		status := twitterErrorStatus(err)
+		if errors.Is(err, utils_twitter.ErrRateLimited) {
+			lastStatus, lastErr = status, err
+			continue
+		}
+		// A revoked or suspended account only fails this request; the pool can try another
+		if usePool && (errors.Is(err, utils_twitter.ErrUnauthorized) || errors.Is(err, utils_twitter.ErrSuspended)) {
+			utils_twitter.MarkAccountUnhealthy(account.ID, err.Error())
+			lastStatus, lastErr = status, err
+			continue
+		}
+		return &account, status, err
+	}
+
+	if lastStatus == http.StatusTooManyRequests {
+		setRetryAfter(c, utils_twitter.NextPoolReset(accountIDs, operation))
+	}
+	return lastAccount, lastStatus, lastErr
+}
+
+// twitterErrorStatus maps an error from the X client to the HTTP status reported to the caller
+func twitterErrorStatus(err error) int {
+	var apiErr *utils_twitter.APIError
+	switch {
+	case errors.Is(err, utils_twitter.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, utils_twitter.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, utils_twitter.ErrSuspended):
+		return http.StatusForbidden
+	case errors.Is(err, utils_twitter.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils_twitter.ErrConflict):
+		return http.StatusConflict
+	case errors.As(err, &apiErr):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// setRetryAfter sets the Retry-After header when the reset time is known
+func setRetryAfter(c *gin.Context, reset time.Time) {
+	if wait := time.Until(reset); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	}
+}
+
+// GetTwitterAccountPool godoc
+// @Summary      Get Twitter Account Pool Status
+// @Description  Show the rotation, cooldown and rate-limit state of every Twitter account owned by the authenticated user (requires JWT authentication)
+// @Tags         twitter
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /twitter/pool [get]
+func GetTwitterAccountPool(c *gin.Context) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid token"})
+		return
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return config.JWTSecret, nil
+	})
+
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	email := claims["email"].(string)
+
+	var user models.User
+	if err := config.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+
+	var accounts []models.TwitterAccount
+	if err := config.DB.Scopes(utils.Accessible(user.ID, models.OrgRoleViewer)).Order("username").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		return
+	}
+
+	response := make([]gin.H, len(accounts))
+	for i, account := range accounts {
+		status := utils_twitter.GetAccountPoolStatus(account.ID)
+		response[i] = gin.H{
+			"id":              account.ID,
+			"username":        account.Username,
+			"available":       status.CooldownUntil == nil,
+			"last_used_at":    status.LastUsedAt,
+			"cooldown_until":  status.CooldownUntil,
+			"cooldown_reason": status.CooldownReason,
+			"rate_limits":     status.RateLimits,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": response, "count": len(accounts)})
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+)
+
+func TestGetTwitterAccountPool(t *testing.T) {
+	f := newOrgFixture(t)
+	own := testTwitterAccount(t, f.viewer)
+	shared := sharedTwitterAccount(t, f.owner, f.organization)
+	testTwitterAccount(t, f.outsider)
+	utils_twitter.MarkAccountUnhealthy(shared.ID, "session expired")
+
+	runAPITests(t, http.MethodGet, "/twitter/pool", GetTwitterAccountPool, []apiTest{
+		{name: "no token", path: "/twitter/pool", status: http.StatusUnauthorized},
+		{name: "invalid token", path: "/twitter/pool", token: "nonsense", status: http.StatusUnauthorized},
+		{
+			name: "own and shared accounts", path: "/twitter/pool", token: f.viewerToken, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				for _, a := range res["accounts"].([]interface{}) {
+					a := a.(map[string]interface{})
+					switch a["id"] {
+					case own.ID:
+						wantField(t, a, "available", true)
+					case shared.ID:
+						wantField(t, a, "available", false)
+						wantField(t, a, "cooldown_reason", "session expired")
+					default:
+						t.Errorf("account %v of another user listed", a["id"])
+					}
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"fmt"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// qualityFilter is the optional server-side engager filter of ?min_quality= and ?exclude_bots=
+type qualityFilter struct {
+	minScore    int
+	excludeBots bool
+}
+
+// qualityFilterFromQuery reads the engager quality filter from the query string
+func qualityFilterFromQuery(c *gin.Context) (qualityFilter, error) {
+	filter := qualityFilter{excludeBots: c.Query("exclude_bots") == "true"}
+	if raw := c.Query("min_quality"); raw != "" {
+		score, err := strconv.Atoi(raw)
+		if err != nil || score < 0 || score > 100 {
+			return filter, fmt.Errorf("min_quality must be a number between 0 and 100")
+		}
+		filter.minScore = score
+	}
+	return filter, nil
+}
+
+// apply scores users and returns the ones passing the filter and how many were dropped
+func (f qualityFilter) apply(users []*utils_twitter.User) ([]*utils_twitter.User, int) {
+	utils_twitter.ScoreUsers(users)
+	kept := utils_twitter.FilterUsersByQuality(users, f.minScore, f.excludeBots)
+	return kept, len(users) - len(kept)
+}
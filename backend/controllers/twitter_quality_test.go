@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+	"time"
+)
+
+func TestGetRepostsQualityFilter(t *testing.T) {
+	account := testTwitterAccount(t, testUser(t))
+	tweetID := testTwitterID()
+	setCachedTwitterData(account.UserID, utils_twitter.OpRetweeters, tweetID, "", []*utils_twitter.User{
+		{ID: testTwitterID(), Username: "alice", Description: "writes about Go", CreatedAt: time.Now().AddDate(-3, 0, 0), Tweets: 500},
+		{ID: testTwitterID(), Username: "user12345", DefaultProfileImage: true, CreatedAt: time.Now().AddDate(0, 0, -5), Following: 300},
+	})
+	reposts := map[string]string{"url": "https://x.com/someone/status/" + tweetID}
+
+	runAPITests(t, http.MethodPost, "/twitter/post/reposts", GetReposts, []apiTest{
+		{name: "invalid min_quality", path: "/twitter/post/reposts?min_quality=high", token: account.Token, body: reposts, status: http.StatusBadRequest},
+		{
+			name: "scored and flagged", path: "/twitter/post/reposts", token: account.Token, body: reposts, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 2)
+				bot := res["reposts"].([]interface{})[1].(map[string]interface{})
+				wantField(t, bot, "likely_bot", true)
+				wantCount(t, bot, "quality_reasons", 6)
+			},
+		},
+		{
+			name: "likely bots left out", path: "/twitter/post/reposts?exclude_bots=true", token: account.Token, body: reposts, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 1)
+				wantField(t, res, "filtered_count", 1)
+				wantField(t, res["reposts"].([]interface{})[0].(map[string]interface{}), "username", "alice")
+			},
+		},
+	})
+}
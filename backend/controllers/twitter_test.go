@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+)
+
+// cachedTweet puts a tweet scraped by user in the response cache, so looking
+// it up doesn't reach X
+func cachedTweet(t *testing.T, user *models.User) string {
+	t.Helper()
+
+	tweetID := testTwitterID()
+	setCachedTwitterData(user.ID, utils_twitter.OpTweetResult, tweetID, "", &utils_twitter.TweetWithMedia{
+		Tweet: &utils_twitter.Tweet{ID: tweetID, Text: "cached tweet"},
+	})
+	return tweetID
+}
+
+func TestGetTweetsBatch(t *testing.T) {
+	user := testUser(t)
+	account := testTwitterAccount(t, user)
+	writeOnly := testTwitterAccount(t, user)
+	withTwitterScopes(t, writeOnly, models.TwitterScopeWrite)
+	broke := testUser(t)
+	brokeAccount := testTwitterAccount(t, broke)
+	withBalance(t, broke, "twitter_reqs", 0)
+	tweetID := cachedTweet(t, user)
+	reads := loadUser(t, user.ID).TwitterReqs
+	tooMany := make([]string, maxBatchTweets+1)
+	for i := range tooMany {
+		tooMany[i] = tweetID
+	}
+	batch := func(tweets ...string) map[string][]string { return map[string][]string{"tweets": tweets} }
+
+	runAPITests(t, http.MethodPost, "/twitter/posts/batch", GetTweetsBatch, []apiTest{
+		{name: "no token", path: "/twitter/posts/batch", body: batch(tweetID), status: http.StatusUnauthorized},
+		{name: "token without the read scope", path: "/twitter/posts/batch", token: writeOnly.Token, body: batch(tweetID), status: http.StatusForbidden},
+		{name: "no tweets", path: "/twitter/posts/batch", token: account.Token, body: batch(), status: http.StatusBadRequest},
+		{name: "too many tweets", path: "/twitter/posts/batch", token: account.Token, body: batch(tooMany...), status: http.StatusBadRequest},
+		{
+			name: "no valid tweet", path: "/twitter/posts/batch", token: account.Token, body: batch("nonsense", "https://x.com/someone"), status: http.StatusBadRequest,
+			check: func(t *testing.T, res map[string]interface{}) { wantCount(t, res, "results", 2) },
+		},
+		{name: "no Twitter reads left", path: "/twitter/posts/batch", token: brokeAccount.Token, body: batch(tweetID), status: http.StatusTooManyRequests},
+		{
+			name: "cached tweets", path: "/twitter/posts/batch", token: account.Token,
+			body: batch(tweetID, "https://x.com/someone/status/"+tweetID, "nonsense"), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "count", 3)
+				wantField(t, res, "success_count", 2)
+				wantField(t, res, "fail_count", 1)
+				wantField(t, res, "cached_count", 1)
+				results := res["results"].([]interface{})
+				for i, r := range results[:2] {
+					r := r.(map[string]interface{})
+					wantField(t, r, "cached", true)
+					if text := r["tweet"].(map[string]interface{})["text"]; text != "cached tweet" {
+						t.Errorf("result %d is %v", i, text)
+					}
+				}
+				wantField(t, results[2].(map[string]interface{}), "error", "Invalid tweet ID or URL")
+
+				// Duplicates are charged once, at the price of a cache hit
+				if left := loadUser(t, user.ID).TwitterReqs; left != reads-utils.TwitterCacheHitCost {
+					t.Errorf("%d Twitter reads left, want %d", left, reads-utils.TwitterCacheHitCost)
+				}
+			},
+		},
+	})
+}
@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/schemas"
+	"ripper-backend/utils"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginChallengeTTL is how long the second step of a login may take
+const loginChallengeTTL = 5 * time.Minute
+
+// issueLoginToken answers a completed login with the user's JWT
+func issueLoginToken(c *gin.Context, user *models.User) {
+	if err := utils.ClearFailedLogins(user); err != nil {
+		log.Printf("⚠️ Failed to clear failed logins for %s: %v", user.Email, err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": user.Email,
+		"exp":   time.Now().Add(time.Hour * 24).Unix(),
+	})
+
+	tokenString, _ := token.SignedString(config.JWTSecret)
+
+	c.JSON(http.StatusOK, schemas.LoginResponse{
+		Token: tokenString,
+		Email: user.Email,
+	})
+}
+
+// issueLoginChallenge answers the first step of a login with 2FA with a
+// challenge token, to exchange with a code at /auth/login/2fa
+func issueLoginChallenge(c *gin.Context, user *models.User) {
+	challenge, err := signLinkToken(purposeLoginChallenge, user, loginChallengeTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas.LoginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+		ExpiresIn:         int(loginChallengeTTL.Seconds()),
+	})
+}
+
+// LoginTwoFactor godoc
+// @Summary      Complete a Two-Factor Login
+// @Description  Exchange the challenge token of /auth/login and a TOTP or recovery code for the JWT. The challenge is valid for 5 minutes. Wrong codes count as failed logins towards the lockout
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request body schemas.LoginTwoFactorRequest true "Challenge token and code"
+// @Success      200 {object} schemas.LoginResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      429 {object} map[string]string
+// @Router       /auth/login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+	var req schemas.LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := parseLinkToken(purposeLoginChallenge, req.ChallengeToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, log in again"})
+		return
+	}
+
+	if user.IsLocked() {
+		respondLockedOut(c, *user.LockedUntil)
+		return
+	}
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return
+	}
+	if !user.IsTwoFactorEnabled() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired challenge, log in again"})
+		return
+	}
+
+	if !utils.VerifyTwoFactorCode(user, req.Code) {
+		recordFailedLogin(c, user)
+		return
+	}
+
+	issueLoginToken(c, user)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary      Get 2FA Status
+// @Description  Whether two-factor authentication is enabled, how many recovery codes are left, and which of your organizations require it (requires JWT authentication)
+// @Tags         two-factor
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Router       /2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	response := gin.H{
+		"enabled":                   user.IsTwoFactorEnabled(),
+		"enabled_at":                user.TOTPEnabledAt,
+		"required_by_organizations": utils.TwoFactorOrganizations(user.ID),
+		"recovery_codes_left":       int64(0),
+	}
+	if user.IsTwoFactorEnabled() {
+		response["recovery_codes_left"] = utils.RecoveryCodesLeft(user.ID)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupTwoFactor godoc
+// @Summary      Set Up 2FA
+// @Description  Generate a TOTP secret and its otpauth:// provisioning URI, to show as a QR code for an authenticator app. 2FA is enabled once a code is confirmed at /2fa/enable. Calling it again replaces the pending secret (requires JWT authentication)
+// @Tags         two-factor
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200 {object} map[string]interface{}
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	if user.IsTwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	if err := config.DB.Model(user).Update("totp_secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(user.Email, secret),
+	})
+}
+
+// EnableTwoFactor godoc
+// @Summary      Enable 2FA
+// @Description  Confirm a code from the authenticator app set up at /2fa/setup to enable two-factor authentication. The response holds 10 single-use recovery codes, shown only this once (requires JWT authentication)
+// @Tags         two-factor
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TwoFactorCodeRequest true "TOTP code"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.IsTwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set up two-factor authentication at /2fa/setup first"})
+		return
+	}
+
+	step, ok := utils.CheckTOTP(user.TOTPSecret, strings.TrimSpace(req.Code))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code, check your authenticator app and its clock"})
+		return
+	}
+
+	codes, err := utils.EnableTwoFactor(user, step)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable 2FA
+// @Description  Turn two-factor authentication off with your password and a TOTP or recovery code. Not possible while one of your organizations requires it (requires JWT authentication)
+// @Tags         two-factor
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.DisableTwoFactorRequest true "Password and code"
+// @Success      200 {object} schemas.MessageResponse
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if organizations := utils.TwoFactorOrganizations(user.ID); len(organizations) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Your organizations require two-factor authentication",
+			"organizations": organizations,
+		})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+	if !utils.VerifyTwoFactorCode(user, req.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	if err := utils.DisableTwoFactor(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas.MessageResponse{Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate Recovery Codes
+// @Description  Replace your recovery codes with 10 new ones, confirmed with a TOTP or recovery code. The old codes stop working and the new ones are shown only this once (requires JWT authentication)
+// @Tags         two-factor
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request body schemas.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success      200 {object} map[string]interface{}
+// @Failure      400 {object} map[string]string
+// @Failure      401 {object} map[string]string
+// @Failure      403 {object} map[string]string
+// @Failure      409 {object} map[string]string
+// @Failure      500 {object} map[string]string
+// @Router       /2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	if rejectApiKey(c) {
+		return
+	}
+	user, ok := authenticateUser(c)
+	if !ok {
+		return
+	}
+
+	var req schemas.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !utils.VerifyTwoFactorCode(user, req.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	}
+
+	codes, err := utils.RegenerateRecoveryCodes(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	"testing"
+	"time"
+)
+
+// totpAt returns the TOTP code of a secret for the period offset periods from now
+func totpAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("invalid TOTP secret %q: %v", secret, err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30+offset))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offsetByte := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offsetByte:offsetByte+4])&0x7fffffff)%1000000)
+}
+
+// withTwoFactor enables 2FA for a user, as confirmed with the code of the
+// previous period so the current one is still unused, and returns the secret
+// and recovery codes
+func withTwoFactor(t *testing.T, user *models.User) (string, []string) {
+	t.Helper()
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("failed to generate the secret: %v", err)
+	}
+	if err := config.DB.Model(user).Update("totp_secret", secret).Error; err != nil {
+		t.Fatalf("failed to set the secret: %v", err)
+	}
+	codes, err := utils.EnableTwoFactor(user, time.Now().Unix()/30-1)
+	if err != nil {
+		t.Fatalf("failed to enable 2FA: %v", err)
+	}
+	return secret, codes
+}
+
+func TestGetTwoFactorStatus(t *testing.T) {
+	user := testUser(t)
+	enrolled := testUser(t)
+	withTwoFactor(t, enrolled)
+	organization := testOrganization(t, testUser(t), requiringTwoFactor)
+	testMember(t, organization, user, models.OrgRoleViewer)
+	key, _ := testApiKey(t, user)
+
+	runAPITests(t, http.MethodGet, "/2fa", GetTwoFactorStatus, []apiTest{
+		{name: "no token", path: "/2fa", status: http.StatusUnauthorized},
+		{name: "with an API key", path: "/2fa", token: key, status: http.StatusForbidden},
+		{
+			name: "not enabled, required by an organization", path: "/2fa", token: userToken(t, user), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "enabled", false)
+				wantField(t, res, "recovery_codes_left", 0)
+				wantField(t, res, "required_by_organizations", []string{organization.Name})
+			},
+		},
+		{
+			name: "enabled", path: "/2fa", token: userToken(t, enrolled), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "enabled", true)
+				wantField(t, res, "recovery_codes_left", 10)
+			},
+		},
+	})
+}
+
+func TestSetupTwoFactor(t *testing.T) {
+	user := testUser(t)
+	enrolled := testUser(t)
+	withTwoFactor(t, enrolled)
+
+	runAPITests(t, http.MethodPost, "/2fa/setup", SetupTwoFactor, []apiTest{
+		{name: "no token", path: "/2fa/setup", status: http.StatusUnauthorized},
+		{name: "already enabled", path: "/2fa/setup", token: userToken(t, enrolled), status: http.StatusConflict},
+		{
+			name: "new secret", path: "/2fa/setup", token: userToken(t, user), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var stored models.User
+				config.DB.Where("id = ?", user.ID).First(&stored)
+				if stored.TOTPSecret == "" || res["secret"] != stored.TOTPSecret {
+					t.Errorf("secret %v is not the stored one %q", res["secret"], stored.TOTPSecret)
+				}
+				wantField(t, res, "provisioning_uri", utils.TOTPProvisioningURI(user.Email, stored.TOTPSecret))
+				if stored.IsTwoFactorEnabled() {
+					t.Errorf("2FA is enabled before a code was confirmed")
+				}
+			},
+		},
+	})
+}
+
+func TestEnableTwoFactor(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+	secret, _ := utils.GenerateTOTPSecret()
+
+	runAPITests(t, http.MethodPost, "/2fa/enable", EnableTwoFactor, []apiTest{
+		{name: "not set up", path: "/2fa/enable", token: token, body: map[string]string{"code": "123456"}, status: http.StatusBadRequest},
+	})
+
+	config.DB.Model(user).Update("totp_secret", secret)
+	runAPITests(t, http.MethodPost, "/2fa/enable", EnableTwoFactor, []apiTest{
+		{name: "no code", path: "/2fa/enable", token: token, body: map[string]string{}, status: http.StatusBadRequest},
+		{name: "wrong code", path: "/2fa/enable", token: token, body: map[string]string{"code": totpAt(t, secret, 5)}, status: http.StatusBadRequest},
+		{
+			name: "code of the authenticator", path: "/2fa/enable", token: token, body: map[string]string{"code": totpAt(t, secret, 0)},
+			status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantCount(t, res, "recovery_codes", 10)
+				if left := utils.RecoveryCodesLeft(user.ID); left != 10 {
+					t.Errorf("%d recovery codes stored, want 10", left)
+				}
+			},
+		},
+		{name: "already enabled", path: "/2fa/enable", token: token, body: map[string]string{"code": totpAt(t, secret, 0)}, status: http.StatusConflict},
+	})
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+	secret, codes := withTwoFactor(t, user)
+
+	required := testUser(t)
+	_, requiredCodes := withTwoFactor(t, required)
+	testOrganization(t, required, requiringTwoFactor)
+
+	notEnabled := testUser(t)
+
+	runAPITests(t, http.MethodPost, "/2fa/disable", DisableTwoFactor, []apiTest{
+		{
+			name: "not enabled", path: "/2fa/disable", token: userToken(t, notEnabled),
+			body: map[string]string{"password": testPassword, "code": "123456"}, status: http.StatusConflict,
+		},
+		{
+			name: "required by an organization", path: "/2fa/disable", token: userToken(t, required),
+			body: map[string]string{"password": testPassword, "code": requiredCodes[0]}, status: http.StatusConflict,
+		},
+		{
+			name: "wrong password", path: "/2fa/disable", token: token,
+			body: map[string]string{"password": "wrong", "code": totpAt(t, secret, 0)}, status: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong code", path: "/2fa/disable", token: token,
+			body: map[string]string{"password": testPassword, "code": "000000"}, status: http.StatusUnauthorized,
+		},
+		{
+			name: "recovery code", path: "/2fa/disable", token: token,
+			body: map[string]string{"password": testPassword, "code": codes[0]}, status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				var stored models.User
+				config.DB.Where("id = ?", user.ID).First(&stored)
+				if stored.IsTwoFactorEnabled() || stored.TOTPSecret != "" {
+					t.Errorf("2FA is still enabled")
+				}
+				if left := utils.RecoveryCodesLeft(user.ID); left != 0 {
+					t.Errorf("%d recovery codes left, want 0", left)
+				}
+			},
+		},
+	})
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	user := testUser(t)
+	token := userToken(t, user)
+	secret, codes := withTwoFactor(t, user)
+
+	runAPITests(t, http.MethodPost, "/2fa/recovery-codes", RegenerateRecoveryCodes, []apiTest{
+		{
+			name: "not enabled", path: "/2fa/recovery-codes", token: userToken(t, testUser(t)),
+			body: map[string]string{"code": "123456"}, status: http.StatusConflict,
+		},
+		{name: "wrong code", path: "/2fa/recovery-codes", token: token, body: map[string]string{"code": "000000"}, status: http.StatusUnauthorized},
+		{
+			name: "TOTP code", path: "/2fa/recovery-codes", token: token, body: map[string]string{"code": totpAt(t, secret, 0)},
+			status: http.StatusOK,
+			check:  func(t *testing.T, res map[string]interface{}) { wantCount(t, res, "recovery_codes", 10) },
+		},
+		{
+			name: "old recovery code", path: "/2fa/recovery-codes", token: token, body: map[string]string{"code": codes[0]},
+			status: http.StatusUnauthorized,
+		},
+	})
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	user := testUser(t)
+	secret, codes := withTwoFactor(t, user)
+	challenge, err := signLinkToken(purposeLoginChallenge, user, loginChallengeTTL)
+	if err != nil {
+		t.Fatalf("failed to sign the challenge: %v", err)
+	}
+	wrongPurpose, _ := signLinkToken(purposeResetPassword, user, time.Hour)
+
+	notEnabled := testUser(t)
+	notEnabledChallenge, _ := signLinkToken(purposeLoginChallenge, notEnabled, loginChallengeTTL)
+
+	request := func(challenge, code string) map[string]string {
+		return map[string]string{"challenge_token": challenge, "code": code}
+	}
+	tests := []apiTest{
+		{name: "no code", path: "/auth/login/2fa", body: map[string]string{"challenge_token": challenge}, status: http.StatusBadRequest},
+		{name: "invalid challenge", path: "/auth/login/2fa", body: request("nonsense", codes[0]), status: http.StatusUnauthorized},
+		{name: "challenge of another purpose", path: "/auth/login/2fa", body: request(wrongPurpose, codes[0]), status: http.StatusUnauthorized},
+		{name: "2FA not enabled", path: "/auth/login/2fa", body: request(notEnabledChallenge, "123456"), status: http.StatusUnauthorized},
+		{
+			name: "TOTP code", path: "/auth/login/2fa", body: request(challenge, totpAt(t, secret, 0)), status: http.StatusOK,
+			check: func(t *testing.T, res map[string]interface{}) {
+				wantField(t, res, "email", user.Email)
+				if token, _ := res["token"].(string); token == "" {
+					t.Errorf("no token issued")
+				}
+			},
+		},
+		{name: "TOTP code replayed", path: "/auth/login/2fa", body: request(challenge, totpAt(t, secret, 0)), status: http.StatusUnauthorized},
+		{name: "recovery code", path: "/auth/login/2fa", body: request(challenge, codes[0]), status: http.StatusOK},
+		{name: "recovery code reused", path: "/auth/login/2fa", body: request(challenge, codes[0]), status: http.StatusUnauthorized},
+	}
+	// The reused code above is the first failed login in a row, the fifth locks the user out
+	for i := 2; i <= 5; i++ {
+		status := http.StatusUnauthorized
+		if i == 5 {
+			status = http.StatusTooManyRequests
+		}
+		tests = append(tests, apiTest{
+			name: fmt.Sprintf("wrong code %d in a row", i), path: "/auth/login/2fa", body: request(challenge, "000000"), status: status,
+		})
+	}
+	tests = append(tests, apiTest{
+		name: "right code while locked out", path: "/auth/login/2fa", body: request(challenge, codes[1]), status: http.StatusTooManyRequests,
+	})
+
+	runAPITests(t, http.MethodPost, "/auth/login/2fa", LoginTwoFactor, tests)
+}
@@ -13,6 +13,7 @@ import (
 
 	"ripper-backend/config"
 	"ripper-backend/models"
+	"ripper-backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -37,7 +38,7 @@ func getUserIDFromToken(tokenString string) (string, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil {
@@ -339,7 +340,7 @@ func GetWhatsAppAccounts(c *gin.Context) {
 
 	// Fetch WhatsApp accounts from database
 	var accounts []models.WhatsAppAccount
-	if err := config.DB.Where("user_id = ?", userID).Find(&accounts).Error; err != nil {
+	if err := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleViewer)).Find(&accounts).Error; err != nil {
 		fmt.Println("DEBUG: Database error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch WhatsApp accounts"})
 		return
@@ -371,6 +372,25 @@ func SendWhatsAppMessage(c *gin.Context) {
 	fmt.Printf("DEBUG SendMessage: Received request - SessionID: %s, Phone: %s, Message: %s\n",
 		req.SessionID, req.Phone, req.Message)
 
+	// Bill the message to the owner of the session
+	var account models.WhatsAppAccount
+	if err := config.DB.Where("session_id = ?", req.SessionID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "WhatsApp session not found"})
+		return
+	}
+
+	var owner models.User
+	if err := config.DB.Where("id = ?", account.UserID).First(&owner).Error; err == nil && owner.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		return
+	}
+
+	reservation, err := utils.ReserveWhatsAppMessage(account.UserID, "/whatsapp/send-message")
+	if err != nil {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Build service URL for this session's pod
 	serviceURL := fmt.Sprintf("http://whatsapp-svc-%s.%s.svc.cluster.local:8083", req.SessionID, k8sManager.GetNamespace())
 
@@ -392,6 +412,7 @@ func SendWhatsAppMessage(c *gin.Context) {
 		bytes.NewBuffer(jsonData),
 	)
 	if err != nil {
+		reservation.Refund()
 		fmt.Printf("DEBUG SendMessage: Microservice error: %v\n", err)
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error":   "WhatsApp service unavailable",
@@ -403,6 +424,7 @@ func SendWhatsAppMessage(c *gin.Context) {
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		reservation.Refund()
 		fmt.Printf("DEBUG SendMessage: Failed to read response: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to read response from WhatsApp service",
@@ -413,6 +435,12 @@ func SendWhatsAppMessage(c *gin.Context) {
 	fmt.Printf("DEBUG SendMessage: Microservice response status: %d\n", resp.StatusCode)
 	fmt.Printf("DEBUG SendMessage: Microservice response body: %s\n", string(body))
 
+	if resp.StatusCode != http.StatusOK {
+		reservation.Refund()
+	} else {
+		reservation.Commit(reservation.Amount)
+	}
+
 	var sendResponse map[string]interface{}
 	if err := json.Unmarshal(body, &sendResponse); err != nil {
 		fmt.Printf("DEBUG SendMessage: Failed to parse response: %v\n", err)
@@ -443,9 +471,9 @@ func DeleteWhatsAppAccount(c *gin.Context) {
 		return
 	}
 
-	// Find the account
+	// Find the account, owned by the user or shared with an organization they administer
 	var account models.WhatsAppAccount
-	result := config.DB.Where("id = ? AND user_id = ?", accountID, userID).First(&account)
+	result := config.DB.Scopes(utils.Accessible(userID, models.OrgRoleAdmin)).Where("id = ?", accountID).First(&account)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
 		return
@@ -11,6 +11,9 @@ import (
 	"ripper-backend/controllers"
 	_ "ripper-backend/docs"
 	"ripper-backend/scheduler"
+	utils_cache "ripper-backend/utils/cache"
+	utils_mailer "ripper-backend/utils/mailer"
+	utils_ratelimit "ripper-backend/utils/ratelimit"
 	"ripper-backend/websocket"
 
 	"github.com/gin-contrib/cors"
@@ -39,8 +42,25 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	// Refuse to start without a private JWT signing key
+	if err := config.LoadJWTSecret(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
 	config.ConnectDB()
 
+	// Give the admin role to the users listed in ADMIN_EMAILS
+	controllers.BootstrapAdmins()
+
+	// Initialize response cache
+	utils_cache.Init()
+
+	// Initialize API rate limiter
+	utils_ratelimit.Init()
+
+	// Initialize mailer for verification and password reset emails
+	utils_mailer.Init()
+
 	// Initialize K8s manager (optional - will work without K8s)
 	log.Println("🔧 Initializing Kubernetes manager...")
 	if err := controllers.InitK8sManager(); err != nil {
@@ -53,6 +73,8 @@ func main() {
 	// Initialize message scheduler
 	log.Println("🚀 Initializing Message Scheduler...")
 	scheduler.InitScheduler()
+	scheduler.InitTweetWatcher()
+	scheduler.InitQuotaResetter()
 
 	// Graceful shutdown handler
 	sigChan := make(chan os.Signal, 1)
@@ -62,6 +84,8 @@ func main() {
 		<-sigChan
 		log.Println("🛑 Shutting down gracefully...")
 		scheduler.StopScheduler()
+		scheduler.StopTweetWatcher()
+		scheduler.StopQuotaResetter()
 		os.Exit(0)
 	}()
 
@@ -74,11 +98,17 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowAllOrigins:  true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Authorization"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With", "X-Twitter-Account"},
+		ExposeHeaders:    []string{"Content-Length", "Authorization", "Retry-After", "X-Cache", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: false, // Cannot use credentials with AllowAllOrigins
 	}))
 
+	// Accept personal API keys wherever a JWT is accepted
+	r.Use(controllers.AuthenticateApiKeys())
+
+	// Refuse every request made with the token of a suspended user
+	r.Use(controllers.RejectSuspended())
+
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Ripper API is running",
@@ -95,30 +125,135 @@ func main() {
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	auth := r.Group("/auth")
+	auth := r.Group("/auth", controllers.RateLimit("auth"))
 	{
 		auth.POST("/signup", controllers.Signup)
 		auth.POST("/login", controllers.Login)
+		auth.POST("/login/2fa", controllers.LoginTwoFactor)
+		auth.POST("/verify-email", controllers.VerifyEmail)
+		auth.POST("/resend-verification", controllers.ResendVerification)
+		auth.POST("/forgot-password", controllers.ForgotPassword)
+		auth.POST("/reset-password", controllers.ResetPassword)
 	}
 
 	r.GET("/profile", controllers.GetProfile)
 	r.POST("/change-password", controllers.ChangePassword)
 	r.GET("/dashboard", controllers.Dashboard)
 	r.GET("/ws", websocket.HandleWebSocket)
+	r.GET("/quota", controllers.GetQuota)
+	r.GET("/quota/ledger", controllers.GetUsageLedger)
+	r.GET("/plans", controllers.ListPlans)
+
+	twoFactor := r.Group("/2fa")
+	{
+		twoFactor.GET("", controllers.GetTwoFactorStatus)
+		twoFactor.POST("/setup", controllers.SetupTwoFactor)
+		twoFactor.POST("/enable", controllers.EnableTwoFactor)
+		twoFactor.POST("/disable", controllers.DisableTwoFactor)
+		twoFactor.POST("/recovery-codes", controllers.RegenerateRecoveryCodes)
+	}
+
+	apiKeys := r.Group("/api-keys")
+	{
+		apiKeys.POST("", controllers.CreateApiKey)
+		apiKeys.GET("", controllers.ListApiKeys)
+		apiKeys.DELETE("/:id", controllers.RevokeApiKey)
+	}
+
+	orgs := r.Group("/orgs")
+	{
+		orgs.POST("", controllers.CreateOrganization)
+		orgs.GET("", controllers.ListOrganizations)
+		orgs.GET("/:id", controllers.GetOrganization)
+		orgs.PUT("/:id", controllers.UpdateOrganization)
+		orgs.DELETE("/:id", controllers.DeleteOrganization)
+		orgs.PUT("/:id/two-factor", controllers.SetOrganizationTwoFactor)
+		orgs.POST("/:id/members", controllers.AddOrganizationMember)
+		orgs.PUT("/:id/members/:user_id", controllers.SetOrganizationMemberRole)
+		orgs.DELETE("/:id/members/:user_id", controllers.RemoveOrganizationMember)
+		orgs.POST("/:id/accounts", controllers.ShareAccount)
+		orgs.DELETE("/:id/accounts/:type/:account_id", controllers.UnshareAccount)
+	}
+
+	admin := r.Group("/admin", controllers.RateLimit("admin"))
+	{
+		admin.POST("/plans", controllers.SavePlan)
+		admin.PUT("/users/:id/plan", controllers.SetUserPlan)
+		admin.POST("/users/:id/topup", controllers.TopUpQuota)
+		admin.GET("/users", controllers.ListUsers)
+		admin.GET("/users/:id", controllers.GetUser)
+		admin.PUT("/users/:id/role", controllers.SetUserRole)
+		admin.POST("/users/:id/suspend", controllers.SuspendUser)
+		admin.POST("/users/:id/unsuspend", controllers.UnsuspendUser)
+		admin.GET("/users/:id/api-logs", controllers.GetUserAPILogs)
+		admin.GET("/users/:id/message-logs", controllers.GetUserMessageLogs)
+		admin.DELETE("/whatsapp/pods/:session_id", controllers.ForceDeleteWhatsAppPod)
+		admin.GET("/audit", controllers.GetAdminAuditLog)
+	}
 
-	twitter := r.Group("/twitter")
+	twitter := r.Group("/twitter", controllers.RateLimit("twitter"))
 	{
 		twitter.GET("/", controllers.GetTwitterAccounts)
+		twitter.GET("/accounts", controllers.GetTwitterAccounts)
 		twitter.POST("/account", controllers.AddTwitterAccount)
+		twitter.GET("/accounts/:id", controllers.GetTwitterAccount)
+		twitter.PUT("/accounts/:id", controllers.UpdateTwitterAccount)
+		twitter.DELETE("/accounts/:id", controllers.DeleteTwitterAccount)
+		twitter.GET("/accounts/:id/health", controllers.CheckTwitterAccountHealth)
+		twitter.POST("/login", controllers.TwitterLogin)
 		twitter.POST("/regenerate-token", controllers.RegenerateTwitterToken)
+		twitter.GET("/pool", controllers.GetTwitterAccountPool)
+		twitter.PUT("/account/proxy", controllers.SetTwitterAccountProxy)
+		twitter.GET("/proxies", controllers.ListProxies)
+		twitter.POST("/proxies", controllers.AddProxy)
+		twitter.DELETE("/proxies/:id", controllers.DeleteProxy)
+		twitter.POST("/proxies/check", controllers.CheckProxies)
+		twitter.POST("/proxies/assign", controllers.AssignProxies)
+		twitter.GET("/dataset/tweets", controllers.GetDatasetTweets)
+		twitter.GET("/dataset/tweets/:id/engagers", controllers.GetDatasetTweetEngagers)
+		twitter.GET("/dataset/users/:id", controllers.GetDatasetUser)
+		twitter.GET("/dataset/top-engagers", controllers.GetDatasetTopEngagers)
+		twitter.GET("/watches", controllers.ListTweetWatches)
+		twitter.POST("/watches", controllers.CreateTweetWatch)
+		twitter.GET("/watches/:id", controllers.GetTweetWatch)
+		twitter.DELETE("/watches/:id", controllers.CancelTweetWatch)
+		twitter.GET("/watches/:id/series", controllers.GetTweetWatchSeries)
+		twitter.POST("/watches/:id/alerts", controllers.AddTweetWatchAlert)
+		twitter.POST("/watches/:id/resume", controllers.ResumeTweetWatch)
 		twitter.POST("/post", controllers.GetTweets)
+		twitter.POST("/posts/batch", controllers.GetTweetsBatch)
 		twitter.POST("/post/likes", controllers.GetLikes)
 		twitter.POST("/post/quotes", controllers.GetQuotes)
 		twitter.POST("/post/comments", controllers.GetComments)
 		twitter.POST("/post/reposts", controllers.GetReposts)
+		twitter.POST("/post/analytics", controllers.GetTweetAnalytics)
+		twitter.POST("/post/engagers/diff", controllers.DiffTweetEngagers)
+		twitter.GET("/engagers/:id/changes", controllers.GetEngagerChanges)
+		twitter.GET("/engagers/:id/checkpoints", controllers.GetEngagerCheckpoints)
+		twitter.GET("/giveaways", controllers.ListGiveaways)
+		twitter.POST("/giveaways", controllers.CreateGiveaway)
+		twitter.GET("/giveaways/:id", controllers.GetGiveaway)
+		twitter.GET("/giveaways/:id/verify", controllers.VerifyGiveaway)
+		twitter.POST("/giveaways/:id/draw", controllers.DrawGiveaway)
+		twitter.POST("/scheduled", controllers.ScheduleTweets)
+		twitter.GET("/actions/quotas", controllers.GetTwitterActionQuotas)
+		twitter.POST("/actions/tweet", controllers.PostTweet)
+		twitter.POST("/actions/reply", controllers.PostReply)
+		twitter.POST("/actions/quote", controllers.PostQuote)
+		twitter.POST("/actions/like", controllers.LikeTweet)
+		twitter.DELETE("/actions/like", controllers.UnlikeTweet)
+		twitter.POST("/actions/retweet", controllers.RetweetTweet)
+		twitter.DELETE("/actions/retweet", controllers.UnretweetTweet)
+		twitter.POST("/actions/bookmark", controllers.BookmarkTweet)
+		twitter.DELETE("/actions/bookmark", controllers.UnbookmarkTweet)
+		twitter.POST("/actions/follow", controllers.FollowUser)
+		twitter.DELETE("/actions/follow", controllers.UnfollowUser)
+		twitter.POST("/actions/dm", controllers.SendDM)
+		twitter.GET("/dm/inbox", controllers.GetDMInbox)
+		twitter.GET("/dm/conversations/:id", controllers.GetDMConversation)
 	}
 
-	whatsapp := r.Group("/whatsapp")
+	whatsapp := r.Group("/whatsapp", controllers.RateLimit("whatsapp"))
 	{
 		// K8s-based WhatsApp account creation (new flow)
 		whatsapp.POST("/accounts/create", controllers.CreateWhatsAppAccount)               // New: Create account with K8s pod
@@ -144,7 +279,7 @@ func main() {
 		whatsapp.GET("/message-logs/stats", controllers.GetMessageLogStats)           // Get message stats
 	}
 
-	logs := r.Group("/logs")
+	logs := r.Group("/logs", controllers.RateLimit("logs"))
 	{
 		logs.GET("", controllers.GetAPILogs)
 		logs.GET("/stats", controllers.GetAPIStats)
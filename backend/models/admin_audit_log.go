@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AdminAuditLog records one action an admin took through the /admin endpoints
+type AdminAuditLog struct {
+	ID         string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	AdminID    string    `json:"admin_id" gorm:"type:uuid;not null;index"`
+	AdminEmail string    `json:"admin_email" gorm:"not null"`
+	Action     string    `json:"action" gorm:"not null;index"` // e.g. suspend_user, topup_quota, delete_whatsapp_pod
+	TargetType string    `json:"target_type"`                  // user, plan, whatsapp_pod
+	TargetID   string    `json:"target_id" gorm:"index"`
+	Details    string    `json:"details" gorm:"type:text"` // JSON of the request
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
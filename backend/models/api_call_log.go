@@ -18,6 +18,8 @@ type ApiCallLog struct {
 	ResponseTime    int64     `json:"response_time"` // in milliseconds
 	IPAddress       string    `json:"ip_address"`
 	UserAgent       string    `json:"user_agent" gorm:"type:text"`
+	DryRun          bool      `json:"dry_run" gorm:"default:false"` // a write that was validated but not sent
 	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
 	User            User      `json:"user" gorm:"foreignKey:UserID"`
+	OrganizationID  *string   `json:"organization_id" gorm:"type:uuid;index"` // shared with the organization of its Twitter account
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes an API key can be limited to, by route
+const (
+	ApiKeyScopeTwitter  = "twitter"  // the /twitter routes, running as one of the user's Twitter accounts where a token is expected
+	ApiKeyScopeWhatsApp = "whatsapp" // the /whatsapp routes
+	ApiKeyScopeAccount  = "account"  // every other route: profile, quota, logs, organizations, admin
+)
+
+// AllApiKeyScopes is what a new key gets unless narrowed
+var AllApiKeyScopes = []string{ApiKeyScopeTwitter, ApiKeyScopeWhatsApp, ApiKeyScopeAccount}
+
+// ApiKey is a long-lived personal key for server-to-server access. Only the
+// SHA-256 of the key is stored; the key itself is shown once, at creation.
+type ApiKey struct {
+	ID         string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"` // first characters of the key, to tell keys apart
+	KeyHash    string     `json:"-" gorm:"not null;uniqueIndex"`
+	Scopes     string     `json:"scopes" gorm:"not null"` // comma separated: twitter, whatsapp, account
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	User       User       `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// HasScope reports whether the key grants scope
+func (k ApiKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// CacheEntry is a cached API response, used when the Postgres response cache is enabled
+type CacheEntry struct {
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Value     []byte    `json:"value" gorm:"type:bytea;not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// EngagerSetMember is one user in the stored engager set of a tweet. A member is
+// present while RemovedSeq is nil; the sequence numbers are the checkpoints at
+// which it was (last) added and removed.
+type EngagerSetMember struct {
+	ID            string     `json:"-" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        string     `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_engager_set_member"`
+	TweetID       string     `json:"tweet_id" gorm:"not null;uniqueIndex:idx_engager_set_member"`
+	Type          string     `json:"type" gorm:"not null;uniqueIndex:idx_engager_set_member"` // liked, retweeted, replied
+	TwitterUserID string     `json:"twitter_user_id" gorm:"not null;uniqueIndex:idx_engager_set_member"`
+	Username      string     `json:"username"`
+	Name          string     `json:"name"`
+	AddedSeq      int        `json:"added_checkpoint" gorm:"not null;index"`
+	RemovedSeq    *int       `json:"removed_checkpoint,omitempty" gorm:"index"`
+	AddedAt       time.Time  `json:"added_at"`
+	RemovedAt     *time.Time `json:"removed_at,omitempty"`
+}
+
+// EngagerCheckpoint records one diff of a tweet's engager set
+type EngagerCheckpoint struct {
+	ID        string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string    `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_engager_checkpoint"`
+	TweetID   string    `json:"tweet_id" gorm:"not null;uniqueIndex:idx_engager_checkpoint"`
+	Type      string    `json:"type" gorm:"not null;uniqueIndex:idx_engager_checkpoint"`
+	Seq       int       `json:"checkpoint" gorm:"not null;uniqueIndex:idx_engager_checkpoint"`
+	Total     int       `json:"total"`
+	Added     int       `json:"added"`
+	Removed   int       `json:"removed"`
+	Partial   bool      `json:"partial"` // the scrape was truncated, so no one was marked removed
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
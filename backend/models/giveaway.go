@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Giveaway entrant statuses
+const (
+	EntrantWinner     = "winner"
+	EntrantEligible   = "eligible"   // eligible but not drawn
+	EntrantSkipped    = "skipped"    // drawn but failed a check made at draw time, e.g. not following
+	EntrantIneligible = "ineligible" // failed the rules before the draw
+)
+
+// Giveaway statuses. A giveaway without a seed of the caller's is committed
+// first: its seed hash is published while the seed stays secret until the draw.
+const (
+	GiveawayCommitted = "committed"
+	GiveawayDrawing   = "drawing"
+	GiveawayDrawn     = "drawn"
+)
+
+// Giveaway is the audit record of one winner draw from a tweet's engagers
+type Giveaway struct {
+	ID                string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID            string    `json:"user_id" gorm:"type:uuid;not null;index"`
+	TwitterAccountID  string    `json:"twitter_account_id" gorm:"type:uuid"`
+	TweetID           string    `json:"tweet_id" gorm:"not null;index"`
+	TweetURL          string    `json:"tweet_url"`
+	RequireLike       bool      `json:"require_like"`
+	RequireRetweet    bool      `json:"require_retweet"`
+	RequireReply      bool      `json:"require_reply"`
+	ReplyContains     string    `json:"reply_contains"`
+	MustFollow        string    `json:"must_follow"`
+	MinAccountAgeDays int       `json:"min_account_age_days"`
+	MinFollowers      int       `json:"min_followers"`
+	ExcludeBots       bool      `json:"exclude_bots"`
+	WinnerCount       int       `json:"winner_count"`
+	Status            string    `json:"status" gorm:"default:'drawn';index"` // committed, drawing or drawn
+	Seed              string    `json:"seed"`                                // random seed of the draw, hidden until drawn
+	SeedHash          string    `json:"seed_hash"`                           // sha256 of the seed, fixed before the draw
+	EligibleHash      string    `json:"eligible_hash"`                       // sha256 of the sorted eligible user IDs
+	EntrantCount      int       `json:"entrant_count"`
+	EligibleCount     int       `json:"eligible_count"`
+	Truncated         bool      `json:"truncated"` // an engager scrape stopped at its page cap, so entrants may be missing
+	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime"`
+	User              User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// GiveawayEntrant is one engager considered in a giveaway and the outcome for them
+type GiveawayEntrant struct {
+	ID               string     `json:"-" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	GiveawayID       string     `json:"-" gorm:"type:uuid;not null;index"`
+	TwitterUserID    string     `json:"twitter_user_id" gorm:"not null"`
+	Username         string     `json:"username"`
+	Name             string     `json:"name"`
+	Followers        int        `json:"followers"`
+	AccountCreatedAt *time.Time `json:"account_created_at,omitempty"`
+	Liked            bool       `json:"liked"`
+	Retweeted        bool       `json:"retweeted"`
+	Replied          bool       `json:"replied"`
+	Status           string     `json:"status" gorm:"index"`   // winner, eligible, skipped, ineligible
+	Reason           string     `json:"reason,omitempty"`      // why the entrant was ineligible or skipped
+	Position         *int       `json:"position,omitempty"`    // place in the shuffled draw order
+	WinnerRank       int        `json:"winner_rank,omitempty"` // 1 for the first winner drawn
+}
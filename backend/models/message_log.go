@@ -21,4 +21,5 @@ type MessageLog struct {
 	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime;index"`
 	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 	User           User       `json:"user" gorm:"foreignKey:UserID"`
+	OrganizationID *string    `json:"organization_id" gorm:"type:uuid;index"` // shared with the organization of its WhatsApp account
 }
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Organization is a team workspace. Twitter and WhatsApp accounts shared with
+// it, and the batches and logs made with them, are visible to its members.
+type Organization struct {
+	ID               string               `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name             string               `json:"name" gorm:"not null"`
+	RequireTwoFactor bool                 `json:"require_two_factor" gorm:"default:false"` // members need 2FA to use what is shared with it
+	CreatedAt        time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+	Members          []OrganizationMember `json:"members,omitempty" gorm:"foreignKey:OrganizationID"`
+}
+
+// OrganizationMember gives a user a role in an organization
+type OrganizationMember struct {
+	ID             string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	OrganizationID string    `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_organization_member"`
+	UserID         string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_organization_member;index"`
+	Role           string    `json:"role" gorm:"not null"` // owner, admin, operator, viewer
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+	User           User      `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// Organization roles, from most to least privileged
+const (
+	OrgRoleOwner    = "owner"    // everything an admin can, plus managing owners and deleting the organization
+	OrgRoleAdmin    = "admin"    // manages members and shared accounts
+	OrgRoleOperator = "operator" // sends, schedules and cancels with shared accounts
+	OrgRoleViewer   = "viewer"   // reads shared accounts, batches and logs
+)
+
+var orgRoleRanks = map[string]int{
+	OrgRoleViewer:   1,
+	OrgRoleOperator: 2,
+	OrgRoleAdmin:    3,
+	OrgRoleOwner:    4,
+}
+
+// IsOrgRole reports whether role is an organization role
+func IsOrgRole(role string) bool {
+	return orgRoleRanks[role] > 0
+}
+
+// OrgRoleAtLeast reports whether role grants everything min grants
+func OrgRoleAtLeast(role, min string) bool {
+	return IsOrgRole(role) && orgRoleRanks[role] >= orgRoleRanks[min]
+}
+
+// OrgRolesAtLeast lists the roles that grant everything min grants
+func OrgRolesAtLeast(min string) []string {
+	var roles []string
+	for role := range orgRoleRanks {
+		if OrgRoleAtLeast(role, min) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Quota types a plan sets an allowance for. Each is a balance column of User.
+const (
+	QuotaTwitterReads  = "twitter_reads"     // scraping, billed in Twitter requests
+	QuotaTwitterWrites = "twitter_writes"    // write actions and DMs, billed in Twitter requests
+	QuotaWhatsApp      = "whatsapp_messages" // WhatsApp messages sent
+)
+
+// AllQuotaTypes lists the quota types in display order
+var AllQuotaTypes = []string{QuotaTwitterReads, QuotaTwitterWrites, QuotaWhatsApp}
+
+// How often a plan refills its allowances
+const (
+	PlanPeriodDaily   = "daily"
+	PlanPeriodMonthly = "monthly"
+)
+
+// DefaultPlanName is the plan of users that were never assigned one
+const DefaultPlanName = "free"
+
+// Kinds of usage ledger entries
+const (
+	LedgerReserve = "reserve" // taken before a call
+	LedgerCommit  = "commit"  // difference between what was reserved and what the call cost
+	LedgerRefund  = "refund"  // reservation returned after a failed call
+	LedgerReset   = "reset"   // refill at the start of a plan period
+	LedgerTopUp   = "topup"   // credit granted by an admin
+	LedgerAdjust  = "adjust"  // debit made by an admin
+)
+
+// Plan sets the allowance of every quota type, refilled every period, and the
+// API rate limit of its users
+type Plan struct {
+	ID                string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name              string    `json:"name" gorm:"unique;not null"`
+	Period            string    `json:"period" gorm:"default:'monthly'"` // daily, monthly
+	TwitterReads      int       `json:"twitter_reads"`
+	TwitterWrites     int       `json:"twitter_writes"`
+	WhatsAppMessages  int       `json:"whatsapp_messages"`
+	RequestsPerMinute int       `json:"requests_per_minute" gorm:"default:60"` // API rate limit per route group
+	Burst             int       `json:"burst" gorm:"default:20"`               // requests allowed at once before the rate applies
+	CreatedAt         time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt         time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Allowance returns what the plan refills a quota type to
+func (p Plan) Allowance(quotaType string) int {
+	switch quotaType {
+	case QuotaTwitterReads:
+		return p.TwitterReads
+	case QuotaTwitterWrites:
+		return p.TwitterWrites
+	case QuotaWhatsApp:
+		return p.WhatsAppMessages
+	}
+	return 0
+}
+
+// NextReset returns when a period that starts at from ends
+func (p Plan) NextReset(from time.Time) time.Time {
+	if p.Period == PlanPeriodDaily {
+		return from.AddDate(0, 0, 1)
+	}
+	return from.AddDate(0, 1, 0)
+}
+
+// UsageLedgerEntry records one debit or credit of a user's quota balance
+type UsageLedgerEntry struct {
+	ID        string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;index"`
+	QuotaType string    `json:"quota_type" gorm:"not null;index"`
+	Amount    int       `json:"amount" gorm:"not null"` // positive for credits, negative for debits
+	Kind      string    `json:"kind" gorm:"not null"`   // reserve, commit, refund, reset, topup, adjust
+	Reason    string    `json:"reason" gorm:"type:text"`
+	Balance   int       `json:"balance"` // balance after the entry
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index"`
+}
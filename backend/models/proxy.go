@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Proxy is an outbound HTTP or SOCKS5 proxy a user can assign to their Twitter accounts
+type Proxy struct {
+	ID            string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	URL           string     `json:"-" gorm:"type:text;not null"` // may contain credentials, never returned as-is
+	Label         string     `json:"label"`
+	Status        string     `json:"status" gorm:"default:'unchecked'"` // unchecked, healthy, unhealthy
+	LatencyMs     int64      `json:"latency_ms"`
+	LastError     string     `json:"last_error" gorm:"type:text"`
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	User          User       `json:"user" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the token bucket of one caller and route group, used when
+// the Postgres rate limiter is enabled
+type RateLimitBucket struct {
+	Key       string    `json:"key" gorm:"primaryKey"`
+	Tokens    float64   `json:"tokens" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null;index"`
+}
@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+// Channels a scheduled item is delivered through
+const (
+	ChannelWhatsApp  = "whatsapp"
+	ChannelTwitter   = "twitter"
+	ChannelTwitterDM = "twitter_dm"
+)
+
 // ScheduledMessage represents a message scheduled to be sent at a specific time
 type ScheduledMessage struct {
 	ID             string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
@@ -22,6 +29,16 @@ type ScheduledMessage struct {
 	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 	User           User       `json:"user" gorm:"foreignKey:UserID"`
+	OrganizationID *string    `json:"organization_id" gorm:"type:uuid;index"` // shared with the organization of its account
+
+	// Twitter items are published from TwitterAccountID; SessionID holds its username.
+	// A DM's RecipientPhone holds the recipient's username or conversation ID.
+	Channel          string  `json:"channel" gorm:"default:'whatsapp';index"` // whatsapp, twitter, twitter_dm
+	TwitterAccountID *string `json:"twitter_account_id,omitempty" gorm:"type:uuid;index"`
+	InReplyToTweetID string  `json:"in_reply_to_tweet_id,omitempty"` // tweet the first item replies to
+	IsThread         bool    `json:"is_thread"`                      // each tweet after the first replies to the previous one in the batch
+	TweetID          string  `json:"tweet_id,omitempty"`             // published tweet
+	DMMessageID      string  `json:"dm_message_id,omitempty"`        // sent direct message
 }
 
 // ScheduleConfig represents the scheduling configuration for a batch
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// TweetWatch periodically snapshots the engagement counts of a tweet
+type TweetWatch struct {
+	ID               string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID           string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	TwitterAccountID string     `json:"twitter_account_id" gorm:"type:uuid;not null"` // account whose session takes the snapshots
+	TweetID          string     `json:"tweet_id" gorm:"not null;index"`
+	TweetURL         string     `json:"tweet_url"`
+	IntervalMinutes  int        `json:"interval_minutes" gorm:"not null"`
+	EndsAt           time.Time  `json:"ends_at" gorm:"not null"`
+	NextRunAt        time.Time  `json:"next_run_at" gorm:"not null;index"`
+	Status           string     `json:"status" gorm:"default:'active';index"` // active, paused, completed, cancelled
+	LastError        string     `json:"last_error" gorm:"type:text"`
+	Engagers         string     `json:"engagers"`    // comma-separated engager sets diffed on every snapshot: liked, retweeted, replied
+	WebhookURL       string     `json:"webhook_url"` // receives a new_engagers event per diff with new engagers
+	SnapshotCount    int        `json:"snapshot_count"`
+	LastSnapshotAt   *time.Time `json:"last_snapshot_at"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	User             User       `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// TweetSnapshot is one point of a watched tweet's engagement time series
+type TweetSnapshot struct {
+	ID         string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	WatchID    string    `json:"watch_id" gorm:"type:uuid;not null;index"`
+	TweetID    string    `json:"tweet_id" gorm:"not null"`
+	Likes      int       `json:"likes"`
+	Retweets   int       `json:"retweets"`
+	Replies    int       `json:"replies"`
+	Quotes     int       `json:"quotes"`
+	Views      int       `json:"views"`
+	CapturedAt time.Time `json:"captured_at" gorm:"not null;index"`
+}
+
+// TweetWatchAlert fires once when a metric of a watched tweet reaches its threshold
+type TweetWatchAlert struct {
+	ID             string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	WatchID        string     `json:"watch_id" gorm:"type:uuid;not null;index"`
+	Metric         string     `json:"metric" gorm:"not null"` // likes, retweets, replies, quotes, views
+	Threshold      int        `json:"threshold" gorm:"not null"`
+	TriggeredAt    *time.Time `json:"triggered_at"`
+	TriggeredValue int        `json:"triggered_value"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Value returns the snapshot's count for a metric name
+func (s TweetSnapshot) Value(metric string) int {
+	switch metric {
+	case "likes":
+		return s.Likes
+	case "retweets":
+		return s.Retweets
+	case "replies":
+		return s.Replies
+	case "quotes":
+		return s.Quotes
+	case "views":
+		return s.Views
+	}
+	return 0
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TwitterActionSlot is one use of a write action's daily quota by a Twitter
+// account. It is taken before the write is sent and given back if the write
+// fails, so concurrent writes can't exceed the quota or the DM pacing.
+type TwitterActionSlot struct {
+	ID               string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TwitterAccountID string    `json:"twitter_account_id" gorm:"type:uuid;not null;index:idx_twitter_action_slot"`
+	Action           string    `json:"action" gorm:"not null;index:idx_twitter_action_slot"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_twitter_action_slot"`
+}
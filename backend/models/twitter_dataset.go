@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Engagement types stored in TwitterEngagement.Type
+const (
+	EngagementLiked     = "liked"
+	EngagementRetweeted = "retweeted"
+	EngagementQuoted    = "quoted"
+	EngagementReplied   = "replied"
+)
+
+// TwitterUser is a Twitter profile seen while scraping, keyed by its Twitter ID
+type TwitterUser struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	Username     string    `json:"username" gorm:"index"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description" gorm:"type:text"`
+	Followers    int       `json:"followers"`
+	Following    int       `json:"following"`
+	Verified     bool      `json:"verified"`
+	BlueVerified bool      `json:"blue_verified"`
+	FirstSeenAt  time.Time `json:"first_seen_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TwitterTweet is a tweet seen while scraping, keyed by its Twitter ID
+type TwitterTweet struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	AuthorID       string     `json:"author_id" gorm:"index"`
+	AuthorUsername string     `json:"author_username" gorm:"index"`
+	AuthorName     string     `json:"author_name"`
+	Text           string     `json:"text" gorm:"type:text"`
+	Likes          int        `json:"likes"`
+	Retweets       int        `json:"retweets"`
+	Replies        int        `json:"replies"`
+	Quotes         int        `json:"quotes"`
+	Views          int        `json:"views"`
+	PostedAt       *time.Time `json:"posted_at" gorm:"index"`
+	FirstSeenAt    time.Time  `json:"first_seen_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TwitterEngagement records that a Twitter user liked, retweeted, quoted or replied to a tweet.
+// Engagements are kept per owning user so each user only queries what they scraped.
+type TwitterEngagement struct {
+	ID            string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID        string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_twitter_engagement"`
+	TweetID       string    `json:"tweet_id" gorm:"not null;uniqueIndex:idx_twitter_engagement;index"`
+	TwitterUserID string    `json:"twitter_user_id" gorm:"not null;uniqueIndex:idx_twitter_engagement;index"`
+	Type          string    `json:"type" gorm:"not null;uniqueIndex:idx_twitter_engagement"`                       // liked, retweeted, quoted, replied
+	SourceTweetID string    `json:"source_tweet_id" gorm:"not null;default:'';uniqueIndex:idx_twitter_engagement"` // the quote or reply tweet
+	FirstSeenAt   time.Time `json:"first_seen_at" gorm:"autoCreateTime"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+	User          User      `json:"user" gorm:"foreignKey:UserID"`
+}
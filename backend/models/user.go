@@ -1,14 +1,72 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
+
+// Scopes a Twitter account token can be limited to
+const (
+	TwitterScopeRead  = "read"  // scraping endpoints
+	TwitterScopeWrite = "write" // write actions
+	TwitterScopeDM    = "dm"    // reading and sending DMs
+)
+
+// AllTwitterScopes is what a new or regenerated token gets unless narrowed
+var AllTwitterScopes = []string{TwitterScopeRead, TwitterScopeWrite, TwitterScopeDM}
 
 type User struct {
-	ID          string    `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Email       string    `json:"email" gorm:"unique;not null"`
-	Password    string    `json:"-" gorm:"not null"`
-	TwitterReqs int       `json:"twitter_reqs" gorm:"default:100"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID               string     `json:"id" gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	Name             string     `json:"name" gorm:"not null"`
+	Email            string     `json:"email" gorm:"unique;not null"`
+	Password         string     `json:"-" gorm:"not null"`
+	TwitterReqs      int        `json:"twitter_reqs" gorm:"default:100"`      // Twitter reads balance
+	TwitterWrites    int        `json:"twitter_writes" gorm:"default:100"`    // Twitter writes balance
+	WhatsAppMessages int        `json:"whatsapp_messages" gorm:"default:100"` // WhatsApp messages balance
+	PlanID           *string    `json:"plan_id" gorm:"type:uuid"`             // nil means the default plan
+	QuotaResetAt     *time.Time `json:"quota_reset_at" gorm:"index"`          // when the balances are next refilled
+	Role             string     `json:"role" gorm:"default:'member';index"`   // admin, member
+	SuspendedAt      *time.Time `json:"suspended_at"`                         // set while an admin has suspended the user
+	SuspendedReason  string     `json:"suspended_reason"`
+	EmailVerifiedAt  *time.Time `json:"email_verified_at"`     // nil until the user follows their verification link
+	VerifyNonce      string     `json:"-" gorm:"default:''"`   // random stamp of verification links, set when the first is sent
+	FailedLogins     int        `json:"-" gorm:"default:0"`    // consecutive failed logins, reset by a successful one
+	LockedUntil      *time.Time `json:"-"`                     // logins are refused until then
+	TOTPSecret       string     `json:"-"`                     // set at 2FA setup, in use once TOTPEnabledAt is set
+	TOTPEnabledAt    *time.Time `json:"two_factor_enabled_at"` // nil while 2FA is off
+	TOTPLastStep     int64      `json:"-" gorm:"default:0"`    // time step of the last accepted code, so a code works once
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// User roles
+const (
+	RoleAdmin  = "admin"  // can use the /admin endpoints
+	RoleMember = "member" // every other user
+)
+
+// IsAdmin reports whether the user has the admin role
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsEmailVerified reports whether the user has verified their email
+func (u User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsTwoFactorEnabled reports whether logins need a TOTP or recovery code
+func (u User) IsTwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// IsLocked reports whether failed logins have locked the user out for now
+func (u User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// IsSuspended reports whether an admin has suspended the user
+func (u User) IsSuspended() bool {
+	return u.SuspendedAt != nil
 }
 
 type TwitterAccount struct {
@@ -16,8 +74,36 @@ type TwitterAccount struct {
 	Username string `json:"username" gorm:"not null"`
 	Password string `json:"password" gorm:"not null"`
 	Token    string `json:"token" gorm:"not null"`
+	ProxyURL string `json:"proxy_url"` // optional http(s):// or socks5:// proxy, with credentials
 	UserID   string `json:"user_id" gorm:"type:uuid;not null"`
 	User     User   `json:"user" gorm:"foreignKey:UserID"`
+	Scopes   string `json:"scopes" gorm:"default:'read,write,dm'"` // comma separated scopes of Token
+	// Organization the account is shared with, if any
+	OrganizationID *string `json:"organization_id" gorm:"type:uuid;index"`
+	// Health of the account's session
+	Status          string     `json:"status" gorm:"default:'pending'"` // pending, logging_in, active, expired, failed
+	LastError       string     `json:"last_error" gorm:"type:text"`
+	LastLoginAt     *time.Time `json:"last_login_at"`
+	LastValidatedAt *time.Time `json:"last_validated_at"`
+}
+
+// Health states of a Twitter account's session
+const (
+	TwitterAccountPending   = "pending"    // never logged in, or not checked since
+	TwitterAccountLoggingIn = "logging_in" // a login is running
+	TwitterAccountActive    = "active"     // the session was accepted by X
+	TwitterAccountExpired   = "expired"    // the session is missing, too old or was rejected
+	TwitterAccountFailed    = "failed"     // the last login failed; see LastError
+)
+
+// HasScope reports whether the account's token grants scope
+func (a TwitterAccount) HasScope(scope string) bool {
+	for _, s := range strings.Split(a.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 type WhatsAppAccount struct {
@@ -32,4 +118,6 @@ type WhatsAppAccount struct {
 	ServiceURL  string    `json:"service_url"` // K8s service URL
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	// Organization the account is shared with, if any
+	OrganizationID *string `json:"organization_id" gorm:"type:uuid;index"`
 }
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+)
+
+// ScheduledDM is one direct message of a scheduled batch
+type ScheduledDM struct {
+	Recipient    string `json:"recipient"` // username or conversation ID
+	Message      string `json:"message"`
+	DelaySeconds int    `json:"delay_seconds"`
+}
+
+// ScheduleDMs schedules direct messages from a Twitter account. Messages are
+// spread at least utils.TwitterDMInterval apart, after the account's last DM
+// and any DMs it already has pending.
+func (s *MessageScheduler) ScheduleDMs(userID string, account *models.TwitterAccount, dms []ScheduledDM) (string, []models.ScheduledMessage, error) {
+	batchID := fmt.Sprintf("batch_%d", time.Now().UnixNano())
+	baseTime := time.Now()
+
+	log.Printf("📦 Scheduling DMs: batch=%s, account=@%s, messages=%d", batchID, account.Username, len(dms))
+
+	// The first DM waits for the account's last sent or latest pending DM
+	nextAt := utils.NextTwitterDMAt(account.ID)
+	var lastPending models.ScheduledMessage
+	if err := s.db.Where("twitter_account_id = ? AND channel = ? AND status IN ?", account.ID, models.ChannelTwitterDM, []string{"pending", "sending"}).
+		Order("scheduled_at DESC").First(&lastPending).Error; err == nil {
+		if pendingNext := lastPending.ScheduledAt.Add(utils.TwitterDMInterval); pendingNext.After(nextAt) {
+			nextAt = pendingNext
+		}
+	}
+
+	items := make([]models.ScheduledMessage, 0, len(dms))
+	for i, dm := range dms {
+		scheduledAt := baseTime.Add(time.Duration(dm.DelaySeconds) * time.Second)
+		if scheduledAt.Before(nextAt) {
+			scheduledAt = nextAt
+		}
+		nextAt = scheduledAt.Add(utils.TwitterDMInterval)
+
+		accountID := account.ID
+		items = append(items, models.ScheduledMessage{
+			UserID:           userID,
+			SessionID:        account.Username,
+			RecipientPhone:   dm.Recipient,
+			Message:          dm.Message,
+			ScheduledAt:      scheduledAt,
+			Status:           "pending",
+			BatchID:          batchID,
+			SequenceNumber:   i + 1,
+			ActualDelay:      int(scheduledAt.Sub(baseTime).Seconds()),
+			Channel:          models.ChannelTwitterDM,
+			TwitterAccountID: &accountID,
+			OrganizationID:   account.OrganizationID,
+		})
+	}
+
+	if err := s.db.Create(&items).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to schedule DMs: %v", err)
+	}
+
+	log.Printf("✅ DM scheduling complete: batch=%s, total=%d", batchID, len(items))
+	return batchID, items, nil
+}
+
+// sendScheduledDM sends a due direct message, waiting for the account's pacing
+func (s *MessageScheduler) sendScheduledDM(msg *models.ScheduledMessage) {
+	log.Printf("✉️ Sending scheduled DM ID: %s from @%s to %s", msg.ID, msg.SessionID, msg.RecipientPhone)
+
+	account, ok := s.startTwitterItem(msg)
+	if !ok {
+		return
+	}
+
+	if next := utils.NextTwitterDMAt(account.ID); next.After(time.Now()) {
+		s.retryScheduledTweet(msg, next, msg.ErrorMessage)
+		return
+	}
+
+	s.publishTwitterItem(msg, account, utils.TwitterActionDM, func(session *utils_twitter.Session) error {
+		conversationID, err := session.ResolveDMConversation(msg.RecipientPhone)
+		if err != nil {
+			return err
+		}
+		msg.DMMessageID, err = session.SendDM(conversationID, msg.Message, "")
+		return err
+	})
+}
@@ -15,6 +15,7 @@ import (
 
 	"ripper-backend/config"
 	"ripper-backend/models"
+	"ripper-backend/utils"
 
 	"gorm.io/gorm"
 )
@@ -90,7 +91,7 @@ func (s *MessageScheduler) processPendingMessages() {
 	// Find all pending messages where scheduled_at <= now
 	now := time.Now()
 	result := s.db.Where("status = ? AND scheduled_at <= ?", "pending", now).
-		Order("scheduled_at ASC").
+		Order("scheduled_at ASC, sequence_number ASC").
 		Limit(50). // Process max 50 messages at a time
 		Find(&messages)
 
@@ -112,6 +113,15 @@ func (s *MessageScheduler) processPendingMessages() {
 
 // sendScheduledMessage sends a single scheduled message
 func (s *MessageScheduler) sendScheduledMessage(msg *models.ScheduledMessage) {
+	switch msg.Channel {
+	case models.ChannelTwitter:
+		s.publishScheduledTweet(msg)
+		return
+	case models.ChannelTwitterDM:
+		s.sendScheduledDM(msg)
+		return
+	}
+
 	log.Printf("📤 Sending scheduled message ID: %s to %s", msg.ID, msg.RecipientPhone)
 
 	// Update status to 'sending' to prevent duplicate sends
@@ -121,8 +131,8 @@ func (s *MessageScheduler) sendScheduledMessage(msg *models.ScheduledMessage) {
 		return
 	}
 
-	// Send the message via WhatsApp microservice
-	err := s.sendToWhatsApp(msg)
+	// Send the message via WhatsApp microservice, billed to the WhatsApp balance
+	err := s.sendBilledToWhatsApp(msg)
 
 	now := time.Now()
 	msg.SentAt = &now
@@ -142,6 +152,23 @@ func (s *MessageScheduler) sendScheduledMessage(msg *models.ScheduledMessage) {
 	}
 }
 
+// sendBilledToWhatsApp sends the message with sendToWhatsApp after reserving it
+// from the user's WhatsApp balance, which is refunded when the send fails
+func (s *MessageScheduler) sendBilledToWhatsApp(msg *models.ScheduledMessage) error {
+	reservation, err := utils.ReserveWhatsAppMessage(msg.UserID, "scheduled:"+msg.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sendToWhatsApp(msg); err != nil {
+		reservation.Refund()
+		return err
+	}
+
+	reservation.Commit(reservation.Amount)
+	return nil
+}
+
 // sendToWhatsApp sends the message to WhatsApp microservice
 func (s *MessageScheduler) sendToWhatsApp(msg *models.ScheduledMessage) error {
 	log.Printf("📞 Calling WhatsApp service for session: %s, phone: %s", msg.SessionID, msg.RecipientPhone)
@@ -166,6 +193,7 @@ func (s *MessageScheduler) sendToWhatsApp(msg *models.ScheduledMessage) error {
 			BatchID:        msg.BatchID,
 			SequenceNumber: msg.SequenceNumber,
 			DelaySeconds:   msg.ActualDelay,
+			OrganizationID: msg.OrganizationID,
 		}
 
 		if err := s.db.Create(&messageLog).Error; err != nil {
@@ -295,26 +323,11 @@ func (s *MessageScheduler) sendToWhatsApp(msg *models.ScheduledMessage) error {
 
 // ScheduleMessage creates a new scheduled message
 func (s *MessageScheduler) ScheduleMessage(msg *models.ScheduledMessage) error {
-	// Calculate actual delay if random delay is configured
-	if msg.RandomDelayMin > 0 || msg.RandomDelayMax > 0 {
-		min := msg.RandomDelayMin
-		max := msg.RandomDelayMax
-
-		if min > max {
-			min, max = max, min
-		}
-
-		if max > 0 {
-			msg.ActualDelay = min + rand.Intn(max-min+1)
-		} else {
-			msg.ActualDelay = min
-		}
-
-		// Add the random delay to scheduled time
-		msg.ScheduledAt = msg.ScheduledAt.Add(time.Duration(msg.ActualDelay) * time.Second)
+	applyRandomDelay(msg)
 
-		log.Printf("🎲 Random delay applied: %d seconds (min: %d, max: %d)",
-			msg.ActualDelay, msg.RandomDelayMin, msg.RandomDelayMax)
+	// Messages from a shared WhatsApp session are shared with its organization
+	if msg.OrganizationID == nil {
+		msg.OrganizationID = utils.WhatsAppSessionOrganization(msg.SessionID)
 	}
 
 	result := s.db.Create(msg)
@@ -338,6 +351,7 @@ func (s *MessageScheduler) ScheduleMessage(msg *models.ScheduledMessage) error {
 		BatchID:        msg.BatchID,
 		SequenceNumber: msg.SequenceNumber,
 		DelaySeconds:   msg.ActualDelay,
+		OrganizationID: msg.OrganizationID,
 	}
 
 	// Save log entry to database
@@ -351,6 +365,32 @@ func (s *MessageScheduler) ScheduleMessage(msg *models.ScheduledMessage) error {
 	return nil
 }
 
+// applyRandomDelay picks the actual delay of a message within its random delay
+// range and adds it to the scheduled time
+func applyRandomDelay(msg *models.ScheduledMessage) {
+	// Calculate actual delay if random delay is configured
+	if msg.RandomDelayMin > 0 || msg.RandomDelayMax > 0 {
+		min := msg.RandomDelayMin
+		max := msg.RandomDelayMax
+
+		if min > max {
+			min, max = max, min
+		}
+
+		if max > 0 {
+			msg.ActualDelay = min + rand.Intn(max-min+1)
+		} else {
+			msg.ActualDelay = min
+		}
+
+		// Add the random delay to scheduled time
+		msg.ScheduledAt = msg.ScheduledAt.Add(time.Duration(msg.ActualDelay) * time.Second)
+
+		log.Printf("🎲 Random delay applied: %d seconds (min: %d, max: %d)",
+			msg.ActualDelay, msg.RandomDelayMin, msg.RandomDelayMax)
+	}
+}
+
 // ScheduleBulkMessages schedules multiple messages with delays
 func (s *MessageScheduler) ScheduleBulkMessages(
 	userID string,
@@ -529,7 +569,8 @@ func indexOf(s, substr string) int {
 // CancelScheduledMessage cancels a scheduled message
 func (s *MessageScheduler) CancelScheduledMessage(messageID string, userID string) error {
 	result := s.db.Model(&models.ScheduledMessage{}).
-		Where("id = ? AND user_id = ? AND status = ?", messageID, userID, "pending").
+		Scopes(utils.Accessible(userID, models.OrgRoleOperator)).
+		Where("id = ? AND status = ?", messageID, "pending").
 		Update("status", "cancelled")
 
 	if result.Error != nil {
@@ -547,7 +588,8 @@ func (s *MessageScheduler) CancelScheduledMessage(messageID string, userID strin
 // CancelBatch cancels all pending messages in a batch
 func (s *MessageScheduler) CancelBatch(batchID string, userID string) error {
 	result := s.db.Model(&models.ScheduledMessage{}).
-		Where("batch_id = ? AND user_id = ? AND status = ?", batchID, userID, "pending").
+		Scopes(utils.Accessible(userID, models.OrgRoleOperator)).
+		Where("batch_id = ? AND status = ?", batchID, "pending").
 		Update("status", "cancelled")
 
 	if result.Error != nil {
@@ -559,15 +601,19 @@ func (s *MessageScheduler) CancelBatch(batchID string, userID string) error {
 }
 
 // GetScheduledMessages retrieves scheduled messages for a user
-func (s *MessageScheduler) GetScheduledMessages(userID string, status string) ([]models.ScheduledMessage, error) {
+func (s *MessageScheduler) GetScheduledMessages(userID string, status string, channel string) ([]models.ScheduledMessage, error) {
 	var messages []models.ScheduledMessage
 
-	query := s.db.Where("user_id = ?", userID)
+	query := s.db.Scopes(utils.Accessible(userID, models.OrgRoleViewer))
 
 	if status != "" {
 		query = query.Where("status = ?", status)
 	}
 
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
 	result := query.Order("scheduled_at DESC").Find(&messages)
 
 	if result.Error != nil {
@@ -586,7 +632,8 @@ func (s *MessageScheduler) GetBatchStatus(batchID string, userID string) (map[st
 
 	err := s.db.Model(&models.ScheduledMessage{}).
 		Select("status, COUNT(*) as count").
-		Where("batch_id = ? AND user_id = ?", batchID, userID).
+		Scopes(utils.Accessible(userID, models.OrgRoleViewer)).
+		Where("batch_id = ?", batchID).
 		Group("status").
 		Find(&results).Error
 
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// QuotaResetter refills the quota balances of users whose plan period ended
+type QuotaResetter struct {
+	db       *gorm.DB
+	stopChan chan bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewQuotaResetter creates a new quota resetter
+func NewQuotaResetter(db *gorm.DB) *QuotaResetter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &QuotaResetter{
+		db:       db,
+		stopChan: make(chan bool),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start seeds the default plan and begins the worker
+func (r *QuotaResetter) Start() {
+	log.Println("🔄 Quota Resetter: Starting...")
+
+	if err := utils.EnsureDefaultPlan(); err != nil {
+		log.Printf("❌ Failed to create the default plan: %v", err)
+		return
+	}
+
+	go r.worker()
+
+	log.Println("✅ Quota Resetter: Worker started")
+}
+
+// Stop gracefully stops the resetter
+func (r *QuotaResetter) Stop() {
+	log.Println("🛑 Quota Resetter: Stopping...")
+	r.cancel()
+	close(r.stopChan)
+	log.Println("✅ Quota Resetter: Stopped")
+}
+
+// worker runs continuously and refills balances that are due
+func (r *QuotaResetter) worker() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	r.processDueResets()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			r.processDueResets()
+		}
+	}
+}
+
+// processDueResets refills users whose next reset is due or was never scheduled
+func (r *QuotaResetter) processDueResets() {
+	now := time.Now()
+	var users []models.User
+	err := r.db.Where("quota_reset_at IS NULL OR quota_reset_at <= ?", now).
+		Order("quota_reset_at ASC NULLS FIRST").
+		Limit(100).
+		Find(&users).Error
+	if err != nil {
+		log.Printf("❌ Error fetching users due a quota reset: %v", err)
+		return
+	}
+
+	plans := map[string]*models.Plan{}
+	for _, user := range users {
+		select {
+		case <-r.ctx.Done():
+			return
+		default:
+		}
+
+		key := ""
+		if user.PlanID != nil {
+			key = *user.PlanID
+		}
+		plan, ok := plans[key]
+		if !ok {
+			if plan, err = utils.UserPlan(user); err != nil {
+				log.Printf("❌ Failed to load the plan of user %s: %v", user.ID, err)
+				continue
+			}
+			plans[key] = plan
+		}
+
+		if err := utils.ResetUserQuotas(user.ID, plan, now); err != nil {
+			log.Printf("❌ Failed to reset the quotas of user %s: %v", user.ID, err)
+			continue
+		}
+		log.Printf("🔄 Reset quotas of user %s to the %s plan", user.ID, plan.Name)
+	}
+}
+
+// Global quota resetter instance
+var GlobalQuotaResetter *QuotaResetter
+
+// InitQuotaResetter initializes and starts the global quota resetter
+func InitQuotaResetter() {
+	GlobalQuotaResetter = NewQuotaResetter(config.DB)
+	GlobalQuotaResetter.Start()
+}
+
+// StopQuotaResetter stops the global quota resetter
+func StopQuotaResetter() {
+	if GlobalQuotaResetter != nil {
+		GlobalQuotaResetter.Stop()
+	}
+}
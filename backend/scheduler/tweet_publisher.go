@@ -0,0 +1,258 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+)
+
+// tweetRetryDelay is how long a scheduled tweet waits when the account's quota
+// is used up or X rate limits it without a known reset time
+const tweetRetryDelay = 15 * time.Minute
+
+// ScheduledTweet is one tweet of a scheduled batch or thread
+type ScheduledTweet struct {
+	Text         string `json:"text"`
+	DelaySeconds int    `json:"delay_seconds"` // delay from the batch's start time
+}
+
+// TweetScheduleOptions configures how a batch of tweets is published
+type TweetScheduleOptions struct {
+	StartAt          time.Time
+	Thread           bool   // publish the tweets as a thread
+	InReplyToTweetID string // the first tweet replies to this tweet
+	RandomDelayMin   int
+	RandomDelayMax   int
+}
+
+// ScheduleTweets schedules tweets to be published from a Twitter account. A
+// thread is published in order, each tweet replying to the previous one, and
+// waits for the previous tweet even if its own time has come.
+func (s *MessageScheduler) ScheduleTweets(
+	userID string,
+	account *models.TwitterAccount,
+	tweets []ScheduledTweet,
+	opts TweetScheduleOptions,
+) (string, []models.ScheduledMessage, error) {
+	batchID := fmt.Sprintf("batch_%d", time.Now().UnixNano())
+	if opts.StartAt.IsZero() {
+		opts.StartAt = time.Now()
+	}
+
+	log.Printf("📦 Scheduling tweets: batch=%s, account=@%s, tweets=%d, thread=%v", batchID, account.Username, len(tweets), opts.Thread)
+
+	items := make([]models.ScheduledMessage, 0, len(tweets))
+	for i, tweet := range tweets {
+		accountID := account.ID
+		item := models.ScheduledMessage{
+			UserID:           userID,
+			SessionID:        account.Username,
+			Message:          tweet.Text,
+			ScheduledAt:      opts.StartAt.Add(time.Duration(tweet.DelaySeconds) * time.Second),
+			Status:           "pending",
+			BatchID:          batchID,
+			SequenceNumber:   i + 1,
+			RandomDelayMin:   opts.RandomDelayMin,
+			RandomDelayMax:   opts.RandomDelayMax,
+			Channel:          models.ChannelTwitter,
+			TwitterAccountID: &accountID,
+			OrganizationID:   account.OrganizationID,
+			IsThread:         opts.Thread,
+		}
+		// In a thread only the first tweet replies to the given tweet
+		if i == 0 || !opts.Thread {
+			item.InReplyToTweetID = opts.InReplyToTweetID
+		}
+		applyRandomDelay(&item)
+		items = append(items, item)
+	}
+
+	if err := s.db.Create(&items).Error; err != nil {
+		return "", nil, fmt.Errorf("failed to schedule tweets: %v", err)
+	}
+
+	log.Printf("✅ Tweet scheduling complete: batch=%s, total=%d", batchID, len(items))
+	return batchID, items, nil
+}
+
+// publishScheduledTweet publishes a due tweet, in order for threads
+func (s *MessageScheduler) publishScheduledTweet(msg *models.ScheduledMessage) {
+	log.Printf("🐦 Publishing scheduled tweet ID: %s from @%s", msg.ID, msg.SessionID)
+
+	account, ok := s.startTwitterItem(msg)
+	if !ok {
+		return
+	}
+
+	replyTo := msg.InReplyToTweetID
+	if msg.IsThread && msg.SequenceNumber > 1 {
+		var previous models.ScheduledMessage
+		if err := s.db.Where("batch_id = ? AND sequence_number = ?", msg.BatchID, msg.SequenceNumber-1).First(&previous).Error; err != nil {
+			s.finishScheduledTweet(msg, "failed", "previous tweet of the thread not found")
+			return
+		}
+		switch previous.Status {
+		case "sent":
+			replyTo = previous.TweetID
+		case "failed", "cancelled":
+			s.finishScheduledTweet(msg, "failed", "previous tweet of the thread was not published")
+			return
+		default:
+			// Wait for the previous tweet; it may be pending, paused or retrying
+			s.retryScheduledTweet(msg, msg.ScheduledAt, msg.ErrorMessage)
+			return
+		}
+	}
+
+	action := utils.TwitterActionTweet
+	if replyTo != "" {
+		action = utils.TwitterActionReply
+	}
+
+	s.publishTwitterItem(msg, account, action, func(session *utils_twitter.Session) error {
+		tweetID, err := session.CreateTweet(msg.Message, utils_twitter.TweetOptions{ReplyToID: replyTo})
+		msg.TweetID = tweetID
+		return err
+	})
+}
+
+// startTwitterItem marks a due tweet or DM as sending and loads its account
+func (s *MessageScheduler) startTwitterItem(msg *models.ScheduledMessage) (*models.TwitterAccount, bool) {
+	// Update status to 'sending' to prevent duplicate sends
+	msg.Status = "sending"
+	if err := s.db.Save(msg).Error; err != nil {
+		log.Printf("❌ Failed to update scheduled item status: %v", err)
+		return nil, false
+	}
+
+	var account models.TwitterAccount
+	if msg.TwitterAccountID == nil || s.db.Where("id = ?", *msg.TwitterAccountID).First(&account).Error != nil {
+		s.finishScheduledTweet(msg, "failed", "Twitter account not found")
+		return nil, false
+	}
+	return &account, true
+}
+
+// publishTwitterItem performs the write of a due tweet or DM from account.
+// Items over quota or rate limited are retried later, and every pending item
+// of the account is paused when its session is no longer valid.
+func (s *MessageScheduler) publishTwitterItem(
+	msg *models.ScheduledMessage,
+	account *models.TwitterAccount,
+	action string,
+	send func(*utils_twitter.Session) error,
+) {
+	slot, err := utils.ReserveTwitterAction(account, action)
+	if err != nil {
+		retryAt := time.Now().Add(tweetRetryDelay)
+		var limitErr *utils.TwitterActionLimitError
+		if errors.As(err, &limitErr) && limitErr.RetryAt.After(retryAt) {
+			retryAt = limitErr.RetryAt
+		}
+		s.retryScheduledTweet(msg, retryAt, err.Error())
+		return
+	}
+	reservation, err := utils.ReserveTwitterWrite(account.UserID, action, "scheduled:"+msg.ID)
+	if err != nil {
+		slot.Release()
+		s.pauseTwitterAccountItems(msg, account.ID, err.Error())
+		return
+	}
+
+	session, err := utils_twitter.LoadSession(account.UserID, account.ID, account.ProxyURL)
+	if err != nil || !session.Validate() {
+		slot.Release()
+		reservation.Refund()
+		utils.MarkTwitterSessionExpired(account.ID, "Twitter session expired")
+		s.pauseTwitterAccountItems(msg, account.ID, "Twitter session expired - please log in again")
+		return
+	}
+	utils_twitter.MarkAccountUsed(account.ID)
+
+	startTime := time.Now()
+	err = send(session)
+	endpoint := utils.TwitterActionEndpoint(action)
+	requestURL := "scheduled:" + msg.ID
+	if err != nil {
+		slot.Release()
+		reservation.Refund()
+		status := http.StatusInternalServerError
+		var apiErr *utils_twitter.APIError
+		if errors.As(err, &apiErr) {
+			status = apiErr.StatusCode
+		}
+		utils.LogScheduledTwitterWrite(account.UserID, account.Username, endpoint, requestURL, startTime, false, status, err.Error())
+
+		switch {
+		case errors.Is(err, utils_twitter.ErrUnauthorized), errors.Is(err, utils_twitter.ErrSuspended):
+			s.pauseTwitterAccountItems(msg, account.ID, err.Error())
+		case errors.Is(err, utils_twitter.ErrRateLimited):
+			retryAt := time.Now().Add(tweetRetryDelay)
+			if apiErr != nil && apiErr.Reset.After(time.Now()) {
+				retryAt = apiErr.Reset
+			}
+			s.retryScheduledTweet(msg, retryAt, err.Error())
+		default:
+			s.finishScheduledTweet(msg, "failed", err.Error())
+		}
+		return
+	}
+
+	reservation.Commit(reservation.Amount)
+	utils.LogScheduledTwitterWrite(account.UserID, account.Username, endpoint, requestURL, startTime, true, http.StatusOK, "")
+
+	s.finishScheduledTweet(msg, "sent", "")
+	log.Printf("✅ Scheduled %s %s sent from @%s", action, msg.ID, account.Username)
+}
+
+// finishScheduledTweet records the final status of a scheduled tweet or DM
+func (s *MessageScheduler) finishScheduledTweet(msg *models.ScheduledMessage, status, errMsg string) {
+	if status == "failed" {
+		log.Printf("❌ Failed to send scheduled %s %s: %s", msg.Channel, msg.ID, errMsg)
+	}
+
+	now := time.Now()
+	msg.SentAt = &now
+	msg.Status = status
+	msg.ErrorMessage = errMsg
+	if err := s.db.Save(msg).Error; err != nil {
+		log.Printf("❌ Failed to update scheduled item after sending: %v", err)
+	}
+}
+
+// retryScheduledTweet puts a tweet or DM back in the queue for retryAt
+func (s *MessageScheduler) retryScheduledTweet(msg *models.ScheduledMessage, retryAt time.Time, reason string) {
+	msg.Status = "pending"
+	msg.ScheduledAt = retryAt
+	msg.ErrorMessage = reason
+	if err := s.db.Save(msg).Error; err != nil {
+		log.Printf("❌ Failed to requeue scheduled item %s: %v", msg.ID, err)
+	}
+}
+
+// pauseTwitterAccountItems pauses an item and every pending tweet and DM of its account
+func (s *MessageScheduler) pauseTwitterAccountItems(msg *models.ScheduledMessage, twitterAccountID, reason string) {
+	log.Printf("⚠️ Pausing scheduled items of @%s: %s", msg.SessionID, reason)
+
+	msg.Status = "paused"
+	msg.ErrorMessage = reason
+	if err := s.db.Save(msg).Error; err != nil {
+		log.Printf("❌ Failed to pause scheduled item %s: %v", msg.ID, err)
+	}
+
+	result := s.db.Model(&models.ScheduledMessage{}).
+		Where("twitter_account_id = ? AND status = ?", twitterAccountID, "pending").
+		Updates(map[string]interface{}{
+			"status":        "paused",
+			"error_message": reason,
+		})
+	if result.RowsAffected > 0 {
+		log.Printf("⏸️ Paused %d more scheduled items of @%s", result.RowsAffected, msg.SessionID)
+	}
+}
@@ -0,0 +1,275 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"ripper-backend/utils"
+	utils_twitter "ripper-backend/utils/twitter"
+	"ripper-backend/websocket"
+
+	"gorm.io/gorm"
+)
+
+// TweetWatcher periodically snapshots the engagement counts of watched tweets
+type TweetWatcher struct {
+	db       *gorm.DB
+	stopChan chan bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// NewTweetWatcher creates a new tweet watcher
+func NewTweetWatcher(db *gorm.DB) *TweetWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TweetWatcher{
+		db:       db,
+		stopChan: make(chan bool),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start migrates the watch tables and begins the worker
+func (w *TweetWatcher) Start() {
+	log.Println("👀 Tweet Watcher: Starting...")
+
+	if err := w.db.AutoMigrate(&models.TweetWatch{}, &models.TweetSnapshot{}, &models.TweetWatchAlert{}); err != nil {
+		log.Printf("❌ Failed to migrate tweet watch tables: %v", err)
+		return
+	}
+
+	go w.worker()
+
+	log.Println("✅ Tweet Watcher: Worker started")
+}
+
+// Stop gracefully stops the watcher
+func (w *TweetWatcher) Stop() {
+	log.Println("🛑 Tweet Watcher: Stopping...")
+	w.cancel()
+	close(w.stopChan)
+	log.Println("✅ Tweet Watcher: Stopped")
+}
+
+// worker runs continuously and snapshots watches that are due
+func (w *TweetWatcher) worker() {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			w.processDueWatches()
+		}
+	}
+}
+
+// processDueWatches finds active watches whose next snapshot is due
+func (w *TweetWatcher) processDueWatches() {
+	var watches []models.TweetWatch
+	err := w.db.Preload("User").
+		Where("status = ? AND next_run_at <= ?", "active", time.Now()).
+		Order("next_run_at ASC").
+		Limit(20).
+		Find(&watches).Error
+	if err != nil {
+		log.Printf("❌ Error fetching due tweet watches: %v", err)
+		return
+	}
+
+	for i := range watches {
+		select {
+		case <-w.ctx.Done():
+			return
+		default:
+		}
+		w.snapshot(&watches[i])
+	}
+}
+
+// snapshot fetches the tweet of a watch once and stores its counts
+func (w *TweetWatcher) snapshot(watch *models.TweetWatch) {
+	now := time.Now()
+	if now.After(watch.EndsAt) {
+		w.db.Model(watch).Updates(map[string]interface{}{"status": "completed"})
+		log.Printf("✅ Tweet watch %s completed with %d snapshots", watch.ID, watch.SnapshotCount)
+		return
+	}
+
+	reservation, err := utils.ReserveTwitterRequests(watch.UserID, utils.TwitterRequestCost(utils_twitter.OpTweetResult), "watch:"+watch.ID)
+	if err != nil {
+		w.db.Model(watch).Updates(map[string]interface{}{"status": "paused", "last_error": err.Error()})
+		log.Printf("⏸️ Tweet watch %s paused: %v", watch.ID, err)
+		return
+	}
+
+	var account models.TwitterAccount
+	if err := w.db.Where("id = ?", watch.TwitterAccountID).First(&account).Error; err != nil {
+		reservation.Refund()
+		w.db.Model(watch).Updates(map[string]interface{}{"status": "paused", "last_error": "Twitter account not found"})
+		return
+	}
+
+	next := now.Add(time.Duration(watch.IntervalMinutes) * time.Minute)
+
+	session, err := utils_twitter.LoadSession(account.UserID, account.ID, account.ProxyURL)
+	if err != nil {
+		reservation.Refund()
+		w.db.Model(watch).Updates(map[string]interface{}{"next_run_at": next, "last_error": "No valid Twitter session found"})
+		return
+	}
+
+	tweet, err := session.GetTweetWithMedia(watch.TweetID)
+	if err != nil {
+		reservation.Refund()
+		updates := map[string]interface{}{"next_run_at": next, "last_error": err.Error()}
+		var apiErr *utils_twitter.APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Kind == utils_twitter.ErrRateLimited && apiErr.Reset.After(now) && apiErr.Reset.Before(next):
+			updates["next_run_at"] = apiErr.Reset
+		case errors.Is(err, utils_twitter.ErrNotFound):
+			updates["status"] = "completed"
+		}
+		w.db.Model(watch).Updates(updates)
+		log.Printf("⚠️ Tweet watch %s snapshot failed: %v", watch.ID, err)
+		return
+	}
+
+	snap := models.TweetSnapshot{
+		WatchID:    watch.ID,
+		TweetID:    watch.TweetID,
+		Likes:      tweet.Likes,
+		Retweets:   tweet.Retweets,
+		Replies:    tweet.Replies,
+		Quotes:     tweet.Quotes,
+		Views:      tweet.Views,
+		CapturedAt: now,
+	}
+	if err := w.db.Create(&snap).Error; err != nil {
+		reservation.Refund()
+		log.Printf("❌ Failed to store snapshot of tweet watch %s: %v", watch.ID, err)
+		return
+	}
+
+	reservation.Commit(reservation.Amount)
+	utils.SaveScrapedTweet(tweet.Tweet)
+
+	updates := map[string]interface{}{
+		"next_run_at":      next,
+		"last_snapshot_at": now,
+		"last_error":       "",
+		"snapshot_count":   gorm.Expr("snapshot_count + 1"),
+	}
+	if next.After(watch.EndsAt) {
+		updates["status"] = "completed"
+	}
+	w.db.Model(watch).Updates(updates)
+
+	w.checkAlerts(watch, snap)
+	w.diffEngagers(watch, session)
+}
+
+// diffEngagers diffs the engager sets tracked by a watch and notifies about new engagers
+func (w *TweetWatcher) diffEngagers(watch *models.TweetWatch, session *utils_twitter.Session) {
+	if watch.Engagers == "" {
+		return
+	}
+
+	for _, engagementType := range strings.Split(watch.Engagers, ",") {
+		operation, err := utils.EngagerSetOperation(engagementType)
+		if err != nil {
+			log.Printf("⚠️ Tweet watch %s has an unknown engager type %q", watch.ID, engagementType)
+			continue
+		}
+		reservation, err := utils.ReserveTwitterRequests(watch.UserID, utils.TwitterRequestCost(operation), "watch:"+watch.ID)
+		if err != nil {
+			log.Printf("⏸️ Skipping %s diff of tweet watch %s: %v", engagementType, watch.ID, err)
+			return
+		}
+
+		engagers, err := utils.FetchEngagerSet(session, watch.UserID, watch.TweetID, engagementType)
+		if err != nil {
+			reservation.Refund()
+			log.Printf("⚠️ Tweet watch %s failed to fetch %s engagers: %v", watch.ID, engagementType, err)
+			if errors.Is(err, utils_twitter.ErrRateLimited) {
+				return
+			}
+			continue
+		}
+		reservation.Commit(utils.TwitterScrapeCost(operation, session.Pages(operation)))
+
+		diff, err := utils.DiffEngagers(watch.UserID, watch.TweetID, engagementType, engagers, session.Truncated(operation))
+		if err != nil {
+			log.Printf("❌ Failed to store %s checkpoint of tweet watch %s: %v", engagementType, watch.ID, err)
+			continue
+		}
+		if len(diff.Added) > 0 && !diff.Baseline {
+			log.Printf("🆕 Tweet %s has %d new %s engager(s)", watch.TweetID, len(diff.Added), engagementType)
+		}
+		go utils.NotifyNewEngagers(watch.User.Email, watch.WebhookURL, diff)
+	}
+}
+
+// checkAlerts fires the untriggered alerts of a watch whose threshold the snapshot reached
+func (w *TweetWatcher) checkAlerts(watch *models.TweetWatch, snap models.TweetSnapshot) {
+	var alerts []models.TweetWatchAlert
+	if err := w.db.Where("watch_id = ? AND triggered_at IS NULL", watch.ID).Find(&alerts).Error; err != nil {
+		return
+	}
+
+	for _, alert := range alerts {
+		value := snap.Value(alert.Metric)
+		if value < alert.Threshold {
+			continue
+		}
+
+		// Only the first worker to mark the alert sends it
+		result := w.db.Model(&models.TweetWatchAlert{}).
+			Where("id = ? AND triggered_at IS NULL", alert.ID).
+			Updates(map[string]interface{}{"triggered_at": snap.CapturedAt, "triggered_value": value})
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		log.Printf("🔔 Tweet %s reached %d %s (threshold %d)", watch.TweetID, value, alert.Metric, alert.Threshold)
+		websocket.SendToUser(watch.User.Email, websocket.Message{
+			Type:   "tweet_watch",
+			Action: "alert",
+			Data: map[string]interface{}{
+				"watch_id":  watch.ID,
+				"tweet_id":  watch.TweetID,
+				"alert_id":  alert.ID,
+				"metric":    alert.Metric,
+				"threshold": alert.Threshold,
+				"value":     value,
+			},
+			Success: true,
+		})
+	}
+}
+
+// Global tweet watcher instance
+var GlobalTweetWatcher *TweetWatcher
+
+// InitTweetWatcher initializes the global tweet watcher
+func InitTweetWatcher() {
+	GlobalTweetWatcher = NewTweetWatcher(config.DB)
+	GlobalTweetWatcher.Start()
+}
+
+// StopTweetWatcher stops the global tweet watcher
+func StopTweetWatcher() {
+	if GlobalTweetWatcher != nil {
+		GlobalTweetWatcher.Stop()
+	}
+}
@@ -1,5 +1,7 @@
 package schemas
 
+import "time"
+
 type SignupRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
@@ -20,20 +22,39 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
 	NewPassword     string `json:"new_password" binding:"required,min=6"`
 }
 
 type TwitterAccountRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username string   `json:"username" binding:"required"`
+	Password string   `json:"password" binding:"required"`
+	ProxyURL string   `json:"proxy_url"`
+	Scopes   []string `json:"scopes"` // read, write and/or dm; all when empty
 }
 
 type GetTweetsRequest struct {
 	URL string `json:"url" binding:"required"`
 }
 
+type GetTweetsBatchRequest struct {
+	Tweets []string `json:"tweets" binding:"required,min=1"`
+}
+
 type TwitterLoginRequest struct {
 	Username string `json:"username" binding:"required"`
 }
@@ -55,5 +76,178 @@ type GetRepostsRequest struct {
 }
 
 type RegenerateTwitterTokenRequest struct {
+	Username string   `json:"username" binding:"required"`
+	Scopes   []string `json:"scopes"` // read, write and/or dm; all when empty
+}
+
+type AddProxyRequest struct {
+	URL   string `json:"url" binding:"required"`
+	Label string `json:"label"`
+}
+
+type SetTwitterAccountProxyRequest struct {
 	Username string `json:"username" binding:"required"`
-}
\ No newline at end of file
+	ProxyURL string `json:"proxy_url"` // empty removes the proxy
+}
+
+type AssignProxiesRequest struct {
+	Reassign bool `json:"reassign"` // also move accounts that already have a proxy
+}
+
+type TweetWatchAlertRequest struct {
+	Metric    string `json:"metric" binding:"required,oneof=likes retweets replies quotes views"`
+	Threshold int    `json:"threshold" binding:"required,min=1"`
+}
+
+type CreateTweetWatchRequest struct {
+	URL             string                   `json:"url" binding:"required"`
+	Username        string                   `json:"username"`         // Twitter account taking the snapshots, defaults to your first account by username
+	IntervalMinutes int                      `json:"interval_minutes"` // default 60, minimum 5
+	DurationHours   int                      `json:"duration_hours"`   // default 24, maximum 720
+	Alerts          []TweetWatchAlertRequest `json:"alerts" binding:"dive"`
+	Engagers        []string                 `json:"engagers" binding:"dive,oneof=liked retweeted replied"` // engager sets to diff on every snapshot
+	WebhookURL      string                   `json:"webhook_url"`                                           // receives a new_engagers event per diff with new engagers
+}
+
+type EngagerDiffRequest struct {
+	URL        string `json:"url" binding:"required"`
+	Type       string `json:"type" binding:"required,oneof=liked retweeted replied"`
+	Since      *int   `json:"since"`       // return changes since this checkpoint instead of the previous one
+	WebhookURL string `json:"webhook_url"` // receives a new_engagers event per diff with new engagers
+}
+
+type CreateGiveawayRequest struct {
+	URL               string `json:"url" binding:"required"`
+	Winners           int    `json:"winners" binding:"required,min=1,max=100"`
+	RequireLike       bool   `json:"require_like"`
+	RequireRetweet    bool   `json:"require_retweet"`
+	RequireReply      bool   `json:"require_reply"`
+	ReplyContains     string `json:"reply_contains"` // implies require_reply
+	MustFollow        string `json:"must_follow"`    // username, with or without @
+	MinAccountAgeDays int    `json:"min_account_age_days" binding:"min=0"`
+	MinFollowers      int    `json:"min_followers" binding:"min=0"`
+	ExcludeBots       bool   `json:"exclude_bots"`
+	Seed              string `json:"seed"` // a public value fixed beforehand, drawn at once; without it the giveaway is only committed
+}
+
+type TweetAnalyticsRequest struct {
+	URL     string   `json:"url" binding:"required"`
+	Sources []string `json:"sources" binding:"dive,oneof=replies quotes"` // default both
+	Top     int      `json:"top"`                                         // entries per list, default 20, max 100
+}
+
+type PostTweetRequest struct {
+	Text   string `json:"text" form:"text"`
+	URL    string `json:"url" form:"url"`         // tweet replied to or quoted; required for replies and quotes
+	DryRun bool   `json:"dry_run" form:"dry_run"` // validate and check the quota without posting
+}
+
+type TweetActionRequest struct {
+	URL    string `json:"url" binding:"required"`
+	DryRun bool   `json:"dry_run"`
+}
+
+type FollowActionRequest struct {
+	Username string `json:"username" binding:"required"`
+	DryRun   bool   `json:"dry_run"`
+}
+
+type ScheduledTweetRequest struct {
+	Text         string `json:"text" binding:"required"`
+	DelaySeconds int    `json:"delay_seconds" binding:"min=0"` // from scheduled_at
+}
+
+type ScheduleTweetsRequest struct {
+	Username       string                  `json:"username"` // Twitter account publishing the tweets, defaults to your first account by username
+	Tweets         []ScheduledTweetRequest `json:"tweets" binding:"required,min=1,max=100,dive"`
+	Thread         bool                    `json:"thread"`       // publish the tweets as a thread
+	ReplyTo        string                  `json:"reply_to"`     // URL of a tweet the tweets (or the thread) reply to
+	ScheduledAt    *time.Time              `json:"scheduled_at"` // RFC 3339, defaults to now
+	RandomDelayMin int                     `json:"random_delay_min" binding:"min=0"`
+	RandomDelayMax int                     `json:"random_delay_max" binding:"min=0"`
+}
+
+type SendDMRequest struct {
+	To     string `json:"to" form:"to" binding:"required"` // username, or a conversation ID from the inbox
+	Text   string `json:"text" form:"text"`
+	DryRun bool   `json:"dry_run" form:"dry_run"` // validate and check the quota and pacing without sending
+}
+
+type PlanRequest struct {
+	Name              string `json:"name" binding:"required"`
+	Period            string `json:"period" binding:"omitempty,oneof=daily monthly"` // default monthly
+	TwitterReads      int    `json:"twitter_reads" binding:"min=0"`
+	TwitterWrites     int    `json:"twitter_writes" binding:"min=0"`
+	WhatsAppMessages  int    `json:"whatsapp_messages" binding:"min=0"`
+	RequestsPerMinute int    `json:"requests_per_minute" binding:"min=0"` // API rate limit per route group, default 60
+	Burst             int    `json:"burst" binding:"min=0"`               // default 20
+}
+
+type SetUserPlanRequest struct {
+	Plan string `json:"plan" binding:"required"` // plan name
+}
+
+type QuotaTopUpRequest struct {
+	QuotaType string `json:"quota_type" binding:"required,oneof=twitter_reads twitter_writes whatsapp_messages"`
+	Amount    int    `json:"amount" binding:"required"` // negative to debit
+	Reason    string `json:"reason" binding:"required"`
+}
+
+type UpdateTwitterAccountRequest struct {
+	Password string `json:"password" binding:"required"` // the account is logged in again with it
+}
+
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type AddOrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required,email"` // must already have signed up
+	Role  string `json:"role" binding:"required,oneof=owner admin operator viewer"`
+}
+
+type SetOrganizationMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin operator viewer"`
+}
+
+type ShareAccountRequest struct {
+	Type      string `json:"type" binding:"required,oneof=twitter whatsapp"`
+	AccountID string `json:"account_id" binding:"required"`
+}
+
+type CreateApiKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes"` // twitter, whatsapp, account; default all
+}
+
+type LoginChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"` // seconds
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+type SetOrganizationTwoFactorRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
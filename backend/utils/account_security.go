@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Progressive login lockout: from the loginLockoutThreshold-th consecutive
+// failed login on, the user is locked out for loginLockoutBase, doubling with
+// every further failure up to loginLockoutMax
+const (
+	loginLockoutThreshold = 5
+	loginLockoutBase      = time.Minute
+	loginLockoutMax       = time.Hour
+)
+
+// LoginLockout returns how long a user is locked out after failures
+// consecutive failed logins, zero below the threshold
+func LoginLockout(failures int) time.Duration {
+	if failures < loginLockoutThreshold {
+		return 0
+	}
+	lockout := loginLockoutBase
+	for i := loginLockoutThreshold; i < failures && lockout < loginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > loginLockoutMax {
+		lockout = loginLockoutMax
+	}
+	return lockout
+}
+
+// RecordFailedLogin counts a failed login for the user and locks them out once
+// they reach the threshold. It returns when the lockout ends, or nil.
+func RecordFailedLogin(user *models.User) (*time.Time, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Select("failed_logins").First(user).Error; err != nil {
+			return err
+		}
+
+		lockout := LoginLockout(user.FailedLogins)
+		if lockout == 0 {
+			return nil
+		}
+		lockedUntil := time.Now().Add(lockout)
+		user.LockedUntil = &lockedUntil
+		return tx.Model(user).Update("locked_until", lockedUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user.LockedUntil, nil
+}
+
+// ClearFailedLogins resets the failed login count and lifts any lockout
+func ClearFailedLogins(user *models.User) error {
+	if user.FailedLogins == 0 && user.LockedUntil == nil {
+		return nil
+	}
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	return config.DB.Model(user).Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+// EnsureVerifyNonce gives the user the random nonce their verification links
+// are stamped with, unless they have one. Concurrent callers end up with the same nonce.
+func EnsureVerifyNonce(user *models.User) error {
+	if user.VerifyNonce != "" {
+		return nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	err := config.DB.Model(&models.User{}).Where("id = ? AND verify_nonce = ''", user.ID).Update("verify_nonce", hex.EncodeToString(b)).Error
+	if err != nil {
+		return err
+	}
+	return config.DB.Model(user).Select("verify_nonce").First(user).Error
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginLockout(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{10, 32 * time.Minute},
+		{11, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := LoginLockout(tt.failures); got != tt.want {
+			t.Errorf("LoginLockout(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRecordFailedLogin(t *testing.T) {
+	user := testUser(t)
+
+	for failures := 1; failures <= 6; failures++ {
+		lockedUntil, err := RecordFailedLogin(user)
+		if err != nil {
+			t.Fatalf("RecordFailedLogin() failed: %v", err)
+		}
+		if user.FailedLogins != failures {
+			t.Errorf("after %d failures FailedLogins = %d", failures, user.FailedLogins)
+		}
+
+		want := LoginLockout(failures)
+		switch {
+		case want == 0 && lockedUntil != nil:
+			t.Errorf("after %d failures locked until %v, want no lockout", failures, lockedUntil)
+		case want > 0 && (lockedUntil == nil || time.Until(*lockedUntil) > want || time.Until(*lockedUntil) < want-time.Minute/2):
+			t.Errorf("after %d failures locked until %v, want about %v from now", failures, lockedUntil, want)
+		}
+	}
+
+	if err := ClearFailedLogins(user); err != nil {
+		t.Fatalf("ClearFailedLogins() failed: %v", err)
+	}
+	if lockedUntil, _ := RecordFailedLogin(user); lockedUntil != nil || user.FailedLogins != 1 {
+		t.Errorf("after clearing, a failure counts %d and locks until %v", user.FailedLogins, lockedUntil)
+	}
+}
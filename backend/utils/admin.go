@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogAdminAction writes an admin action to the audit trail. It is saved
+// synchronously so an action is never reported done without its audit row.
+func LogAdminAction(c *gin.Context, admin *models.User, action, targetType, targetID string, details interface{}) {
+	entry := models.AdminAuditLog{
+		AdminID:    admin.ID,
+		AdminEmail: admin.Email,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IPAddress:  c.ClientIP(),
+	}
+	if details != nil {
+		if data, err := json.Marshal(details); err == nil {
+			entry.Details = string(data)
+		}
+	}
+
+	if err := config.DB.Create(&entry).Error; err != nil {
+		log.Printf("❌ Failed to audit %s by %s: %v", action, admin.Email, err)
+	}
+}
+
+// PromoteAdmins gives the admin role to the users with the given emails.
+// ADMIN_EMAILS bootstraps the first admins this way.
+func PromoteAdmins(emails []string) {
+	var lowered []string
+	for _, email := range emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			lowered = append(lowered, email)
+		}
+	}
+	if len(lowered) == 0 {
+		return
+	}
+
+	result := config.DB.Model(&models.User{}).
+		Where("LOWER(email) IN ? AND role <> ?", lowered, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		log.Printf("❌ Failed to promote admins: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("👑 Promoted %d user(s) from ADMIN_EMAILS to admin", result.RowsAffected)
+	}
+}
+
+// SuspendUser blocks a user from the API. Their pending scheduled messages and
+// running tweet watches are paused so nothing is sent or scraped on their behalf.
+func SuspendUser(userID, reason string) error {
+	err := config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"suspended_at": time.Now(), "suspended_reason": reason}).Error
+	if err != nil {
+		return err
+	}
+
+	config.DB.Model(&models.ScheduledMessage{}).
+		Where("user_id = ? AND status = ?", userID, "pending").
+		Updates(map[string]interface{}{"status": "paused", "error_message": "User suspended"})
+	config.DB.Model(&models.TweetWatch{}).
+		Where("user_id = ? AND status = ?", userID, "active").
+		Updates(map[string]interface{}{"status": "paused", "last_error": "User suspended"})
+	return nil
+}
+
+// UnsuspendUser lets a suspended user back in. What was paused by the
+// suspension stays paused until the user resumes it.
+func UnsuspendUser(userID string) error {
+	return config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"suspended_at": nil, "suspended_reason": ""}).Error
+}
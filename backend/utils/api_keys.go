@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"strings"
+	"time"
+)
+
+// ApiKeyPrefix starts every API key, so the auth layer can tell keys from JWTs and Twitter tokens
+const ApiKeyPrefix = "rk_"
+
+// apiKeyTouchInterval is how often the last-used time of a key is written
+const apiKeyTouchInterval = time.Minute
+
+// GenerateApiKey returns a new random API key, its display prefix and its hash
+func GenerateApiKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = ApiKeyPrefix + hex.EncodeToString(b)
+	return key, key[:len(ApiKeyPrefix)+8], HashApiKey(key), nil
+}
+
+// HashApiKey returns the SHA-256 of a key, as stored
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsApiKey reports whether a bearer token is an API key
+func IsApiKey(token string) bool {
+	return strings.HasPrefix(token, ApiKeyPrefix)
+}
+
+// FindApiKey loads the unrevoked key matching a bearer token, with its user
+func FindApiKey(key string) (*models.ApiKey, error) {
+	var apiKey models.ApiKey
+	if err := config.DB.Preload("User").Where("key_hash = ? AND revoked_at IS NULL", HashApiKey(key)).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// TouchApiKey records that a key was used, at most once per apiKeyTouchInterval
+func TouchApiKey(id string) {
+	now := time.Now()
+	config.DB.Model(&models.ApiKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", now)
+}
@@ -10,9 +10,54 @@ import (
 
 // LogTwitterAPICall logs a Twitter API call to the database
 func LogTwitterAPICall(c *gin.Context, userID string, twitterUsername string, endpoint string, startTime time.Time, success bool, statusCode int, errorMessage string) {
-	responseTime := time.Since(startTime).Milliseconds()
+	log := newAPICallLog(c, userID, twitterUsername, endpoint, startTime, success, statusCode, errorMessage)
 
+	// Save to database asynchronously to not block the response
+	go func() {
+		log.OrganizationID = TwitterAccountOrganization(userID, twitterUsername)
+		if err := config.DB.Create(&log).Error; err != nil {
+			// Log error but don't fail the request
+			println("Failed to log API call:", err.Error())
+		}
+	}()
+}
+
+// LogTwitterWrite logs a write action, or its dry run, to the database. It is
+// saved synchronously because the per-action quotas are counted from these rows.
+func LogTwitterWrite(c *gin.Context, userID string, twitterUsername string, endpoint string, startTime time.Time, success bool, statusCode int, errorMessage string, dryRun bool) {
+	log := newAPICallLog(c, userID, twitterUsername, endpoint, startTime, success, statusCode, errorMessage)
+	log.DryRun = dryRun
+	log.OrganizationID = TwitterAccountOrganization(userID, twitterUsername)
+
+	if err := config.DB.Create(&log).Error; err != nil {
+		println("Failed to log API call:", err.Error())
+	}
+}
+
+// LogScheduledTwitterWrite logs a write made by the scheduler, outside of any
+// request, so that it counts toward the account's action quota
+func LogScheduledTwitterWrite(userID string, twitterUsername string, endpoint string, requestURL string, startTime time.Time, success bool, statusCode int, errorMessage string) {
 	log := models.ApiCallLog{
+		UserID:          userID,
+		TwitterUsername: twitterUsername,
+		Endpoint:        endpoint,
+		Method:          "POST",
+		RequestURL:      requestURL,
+		StatusCode:      statusCode,
+		Success:         success,
+		ErrorMessage:    errorMessage,
+		ResponseTime:    time.Since(startTime).Milliseconds(),
+		UserAgent:       "scheduler",
+		OrganizationID:  TwitterAccountOrganization(userID, twitterUsername),
+	}
+
+	if err := config.DB.Create(&log).Error; err != nil {
+		println("Failed to log API call:", err.Error())
+	}
+}
+
+func newAPICallLog(c *gin.Context, userID string, twitterUsername string, endpoint string, startTime time.Time, success bool, statusCode int, errorMessage string) models.ApiCallLog {
+	return models.ApiCallLog{
 		UserID:          userID,
 		TwitterUsername: twitterUsername,
 		Endpoint:        endpoint,
@@ -21,25 +66,18 @@ func LogTwitterAPICall(c *gin.Context, userID string, twitterUsername string, en
 		StatusCode:      statusCode,
 		Success:         success,
 		ErrorMessage:    errorMessage,
-		ResponseTime:    responseTime,
+		ResponseTime:    time.Since(startTime).Milliseconds(),
 		IPAddress:       c.ClientIP(),
 		UserAgent:       c.Request.UserAgent(),
 	}
-
-	// Save to database asynchronously to not block the response
-	go func() {
-		if err := config.DB.Create(&log).Error; err != nil {
-			// Log error but don't fail the request
-			println("Failed to log API call:", err.Error())
-		}
-	}()
 }
 
-// GetUserAPICallLogs retrieves API call logs for a user
+// GetUserAPICallLogs retrieves API call logs for a user, including those of the
+// Twitter accounts shared with their organizations
 func GetUserAPICallLogs(userID string, limit int) ([]models.ApiCallLog, error) {
 	var logs []models.ApiCallLog
 
-	query := config.DB.Where("user_id = ?", userID).
+	query := config.DB.Scopes(Accessible(userID, models.OrgRoleViewer)).
 		Order("created_at DESC")
 
 	if limit > 0 {
@@ -50,7 +88,8 @@ func GetUserAPICallLogs(userID string, limit int) ([]models.ApiCallLog, error) {
 	return logs, err
 }
 
-// GetUserAPICallStats retrieves statistics for a user's API calls
+// GetUserAPICallStats retrieves statistics for a user's API calls, including
+// those of the Twitter accounts shared with their organizations
 func GetUserAPICallStats(userID string) (map[string]interface{}, error) {
 	var totalCalls int64
 	var successfulCalls int64
@@ -59,14 +98,15 @@ func GetUserAPICallStats(userID string) (map[string]interface{}, error) {
 
 	// Total calls
 	if err := config.DB.Model(&models.ApiCallLog{}).
-		Where("user_id = ?", userID).
+		Scopes(Accessible(userID, models.OrgRoleViewer)).
 		Count(&totalCalls).Error; err != nil {
 		return nil, err
 	}
 
 	// Successful calls
 	if err := config.DB.Model(&models.ApiCallLog{}).
-		Where("user_id = ? AND success = ?", userID, true).
+		Scopes(Accessible(userID, models.OrgRoleViewer)).
+		Where("success = ?", true).
 		Count(&successfulCalls).Error; err != nil {
 		return nil, err
 	}
@@ -76,7 +116,7 @@ func GetUserAPICallStats(userID string) (map[string]interface{}, error) {
 
 	// Average response time
 	if err := config.DB.Model(&models.ApiCallLog{}).
-		Where("user_id = ?", userID).
+		Scopes(Accessible(userID, models.OrgRoleViewer)).
 		Select("AVG(response_time)").
 		Scan(&avgResponseTime).Error; err != nil {
 		return nil, err
@@ -88,7 +128,7 @@ func GetUserAPICallStats(userID string) (map[string]interface{}, error) {
 		Count    int64
 	}
 	if err := config.DB.Model(&models.ApiCallLog{}).
-		Where("user_id = ?", userID).
+		Scopes(Accessible(userID, models.OrgRoleViewer)).
 		Select("endpoint, COUNT(*) as count").
 		Group("endpoint").
 		Order("count DESC").
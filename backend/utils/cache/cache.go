@@ -0,0 +1,116 @@
+package utils_cache
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultMaxEntries = 1000
+
+// store is a cache backend
+type store interface {
+	Get(key string) ([]byte, time.Time, bool)
+	Set(key string, value []byte, expiresAt time.Time)
+	Delete(key string)
+}
+
+var (
+	memory     = newMemoryStore(defaultMaxEntries)
+	persistent store // nil unless the Postgres cache is enabled
+)
+
+// Init configures the cache from the environment. CACHE_MAX_ENTRIES sizes the
+// in-process LRU and CACHE_POSTGRES=true adds the shared Postgres store behind it.
+func Init() {
+	maxEntries := defaultMaxEntries
+	if v, err := strconv.Atoi(os.Getenv("CACHE_MAX_ENTRIES")); err == nil && v > 0 {
+		maxEntries = v
+	}
+	memory = newMemoryStore(maxEntries)
+
+	if os.Getenv("CACHE_POSTGRES") == "true" {
+		if err := config.DB.AutoMigrate(&models.CacheEntry{}); err != nil {
+			log.Printf("⚠️ Postgres response cache disabled: %v", err)
+			return
+		}
+		pg := postgresStore{}
+		persistent = pg
+		go pg.cleanupExpired()
+		log.Printf("✅ Response cache: in-memory LRU (%d entries) + Postgres", maxEntries)
+		return
+	}
+
+	log.Printf("✅ Response cache: in-memory LRU (%d entries)", maxEntries)
+}
+
+// Key builds the cache key of an operation on a tweet, with an optional cursor.
+// Keys are per owning user: what one user's accounts can see, e.g. protected
+// tweets, must not be served to another.
+func Key(userID, operation, tweetID, cursor string) string {
+	parts := []string{userID, operation, tweetID}
+	if cursor != "" {
+		parts = append(parts, cursor)
+	}
+	return strings.Join(parts, ":")
+}
+
+// TTLFromEnv reads a TTL in seconds from the environment, falling back to def
+func TTLFromEnv(key string, def time.Duration) time.Duration {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return time.Duration(v) * time.Second
+	}
+	return def
+}
+
+// Get decodes the cached value of key into v. It reports false on a miss.
+func Get(key string, v interface{}) bool {
+	data, _, ok := memory.Get(key)
+	if !ok && persistent != nil {
+		var expiresAt time.Time
+		data, expiresAt, ok = persistent.Get(key)
+		if ok {
+			memory.Set(key, data, expiresAt)
+		}
+	}
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		memory.Delete(key)
+		return false
+	}
+	return true
+}
+
+// Set stores v under key for ttl. A zero ttl is a no-op.
+func Set(key string, v interface{}, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	memory.Set(key, data, expiresAt)
+	if persistent != nil {
+		persistent.Set(key, data, expiresAt)
+	}
+}
+
+// Delete removes key from every store
+func Delete(key string) {
+	memory.Delete(key)
+	if persistent != nil {
+		persistent.Delete(key)
+	}
+}
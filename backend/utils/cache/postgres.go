@@ -0,0 +1,55 @@
+package utils_cache
+
+import (
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// postgresCleanupInterval is how often expired rows are removed
+const postgresCleanupInterval = 10 * time.Minute
+
+// postgresStore keeps cache entries in the cache_entries table so they
+// survive restarts and are shared between backend replicas
+type postgresStore struct{}
+
+func (postgresStore) Get(key string) ([]byte, time.Time, bool) {
+	var entry models.CacheEntry
+	if err := config.DB.Where("key = ? AND expires_at > ?", key, time.Now()).First(&entry).Error; err != nil {
+		return nil, time.Time{}, false
+	}
+	return entry.Value, entry.ExpiresAt, true
+}
+
+func (postgresStore) Set(key string, value []byte, expiresAt time.Time) {
+	entry := models.CacheEntry{Key: key, Value: value, ExpiresAt: expiresAt}
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to write cache entry %s: %v", key, err)
+	}
+}
+
+func (postgresStore) Delete(key string) {
+	config.DB.Where("key = ?", key).Delete(&models.CacheEntry{})
+}
+
+// cleanupExpired periodically deletes expired rows
+func (postgresStore) cleanupExpired() {
+	ticker := time.NewTicker(postgresCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result := config.DB.Where("expires_at <= ?", time.Now()).Delete(&models.CacheEntry{})
+		if result.Error != nil {
+			log.Printf("⚠️ Failed to clean up cache entries: %v", result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("🧹 Removed %d expired cache entries", result.RowsAffected)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"ripper-backend/config"
+	"ripper-backend/models"
+	utils_testdb "ripper-backend/utils/testdb"
+	"testing"
+	"time"
+)
+
+// testUser creates a user that is deleted with its ledger and recovery codes
+// after the test
+func testUser(t *testing.T) *models.User {
+	t.Helper()
+	utils_testdb.Connect(t, nil)
+
+	user := &models.User{
+		Name:     "Test User",
+		Email:    "test-" + time.Now().Format("150405.000000000") + "@example.com",
+		Password: "not a bcrypt hash",
+	}
+	if err := config.DB.Create(user).Error; err != nil {
+		t.Fatalf("failed to create the test user: %v", err)
+	}
+	t.Cleanup(func() {
+		config.DB.Where("user_id = ?", user.ID).Delete(&models.UsageLedgerEntry{})
+		config.DB.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{})
+		config.DB.Delete(user)
+	})
+	return user
+}
@@ -0,0 +1,267 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	utils_twitter "ripper-backend/utils/twitter"
+	"ripper-backend/websocket"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EngagerDiff is the change of a tweet's engager set between two checkpoints
+type EngagerDiff struct {
+	TweetID    string                    `json:"tweet_id"`
+	Type       string                    `json:"type"`
+	Checkpoint int                       `json:"checkpoint"`
+	Since      int                       `json:"since"`
+	Baseline   bool                      `json:"baseline"` // the first checkpoint only records the set, it has no events
+	Partial    bool                      `json:"partial"`  // the scrape was truncated, so no one was marked removed
+	Total      int                       `json:"total"`
+	Added      []models.EngagerSetMember `json:"added"`
+	Removed    []models.EngagerSetMember `json:"removed"`
+}
+
+// EngagerSetOperation returns the X operation used to fetch an engager set
+func EngagerSetOperation(engagementType string) (string, error) {
+	switch engagementType {
+	case models.EngagementLiked:
+		return utils_twitter.OpFavoriters, nil
+	case models.EngagementRetweeted:
+		return utils_twitter.OpRetweeters, nil
+	case models.EngagementReplied:
+		return utils_twitter.OpTweetDetail, nil
+	}
+	return "", fmt.Errorf("engager type must be liked, retweeted or replied")
+}
+
+// FetchEngagerSet scrapes the current likers, retweeters or reply authors of a
+// tweet and stores them in userID's dataset
+func FetchEngagerSet(session *utils_twitter.Session, userID, tweetID, engagementType string) ([]*utils_twitter.User, error) {
+	switch engagementType {
+	case models.EngagementLiked:
+		users, err := session.GetLikers(tweetID)
+		if err != nil {
+			return nil, err
+		}
+		go SaveEngagers(userID, tweetID, engagementType, users)
+		return users, nil
+	case models.EngagementRetweeted:
+		users, err := session.GetRetweeters(tweetID)
+		if err != nil {
+			return nil, err
+		}
+		go SaveEngagers(userID, tweetID, engagementType, users)
+		return users, nil
+	case models.EngagementReplied:
+		replies, err := session.GetAllTweetReplies(tweetID)
+		if err != nil {
+			return nil, err
+		}
+		go SaveEngagementTweets(userID, tweetID, engagementType, replies)
+		users := make([]*utils_twitter.User, 0, len(replies))
+		for _, r := range replies {
+			if r.AuthorID != "" {
+				users = append(users, &utils_twitter.User{ID: r.AuthorID, Username: r.Username, Name: r.Name})
+			}
+		}
+		return users, nil
+	}
+	return nil, fmt.Errorf("engager type must be liked, retweeted or replied")
+}
+
+// DiffEngagers compares the current engagers of a tweet with the stored set,
+// stores the new set under the next checkpoint and returns what changed. A
+// partial set, from a scrape that stopped before its last page, only adds
+// engagers: those it missed are not marked removed.
+func DiffEngagers(userID, tweetID, engagementType string, current []*utils_twitter.User, partial bool) (*EngagerDiff, error) {
+	diff := &EngagerDiff{
+		TweetID: tweetID,
+		Type:    engagementType,
+		Partial: partial,
+		Added:   []models.EngagerSetMember{},
+		Removed: []models.EngagerSetMember{},
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&models.EngagerCheckpoint{}).
+			Where("user_id = ? AND tweet_id = ? AND type = ?", userID, tweetID, engagementType).
+			Select("COALESCE(MAX(seq), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		seq := last + 1
+		diff.Checkpoint, diff.Since, diff.Baseline = seq, last, last == 0
+
+		var members []models.EngagerSetMember
+		if err := tx.Where("user_id = ? AND tweet_id = ? AND type = ?", userID, tweetID, engagementType).
+			Find(&members).Error; err != nil {
+			return err
+		}
+		known := make(map[string]*models.EngagerSetMember, len(members))
+		for i := range members {
+			known[members[i].TwitterUserID] = &members[i]
+		}
+
+		now := time.Now()
+		seen := make(map[string]bool, len(current))
+		var created []models.EngagerSetMember
+		var readded []string
+		for _, u := range current {
+			if u == nil || u.ID == "" || seen[u.ID] {
+				continue
+			}
+			seen[u.ID] = true
+
+			member, ok := known[u.ID]
+			switch {
+			case !ok:
+				created = append(created, models.EngagerSetMember{
+					UserID:        userID,
+					TweetID:       tweetID,
+					Type:          engagementType,
+					TwitterUserID: u.ID,
+					Username:      u.Username,
+					Name:          u.Name,
+					AddedSeq:      seq,
+					AddedAt:       now,
+				})
+				diff.Added = append(diff.Added, created[len(created)-1])
+			case member.RemovedSeq != nil:
+				readded = append(readded, member.ID)
+				member.AddedSeq, member.AddedAt, member.RemovedSeq, member.RemovedAt = seq, now, nil, nil
+				diff.Added = append(diff.Added, *member)
+			}
+		}
+
+		var removed []string
+		for i := range members {
+			member := &members[i]
+			if member.RemovedSeq != nil {
+				continue
+			}
+			if partial {
+				// Missing from a partial set says nothing, so members stay
+				seen[member.TwitterUserID] = true
+				continue
+			}
+			if !seen[member.TwitterUserID] {
+				removed = append(removed, member.ID)
+				removedSeq := seq
+				member.RemovedSeq, member.RemovedAt = &removedSeq, &now
+				diff.Removed = append(diff.Removed, *member)
+			}
+		}
+		diff.Total = len(seen)
+
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(&created, datasetBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(readded) > 0 {
+			if err := tx.Model(&models.EngagerSetMember{}).Where("id IN ?", readded).Updates(map[string]interface{}{
+				"added_seq": seq, "added_at": now, "removed_seq": nil, "removed_at": nil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Model(&models.EngagerSetMember{}).Where("id IN ?", removed).Updates(map[string]interface{}{
+				"removed_seq": seq, "removed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&models.EngagerCheckpoint{
+			UserID:  userID,
+			TweetID: tweetID,
+			Type:    engagementType,
+			Seq:     seq,
+			Total:   diff.Total,
+			Added:   len(diff.Added),
+			Removed: len(diff.Removed),
+			Partial: partial,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// EngagerChangesSince returns who was added to or removed from a tweet's stored
+// engager set after checkpoint since, without scraping
+func EngagerChangesSince(userID, tweetID, engagementType string, since int) (*EngagerDiff, error) {
+	diff := &EngagerDiff{
+		TweetID: tweetID,
+		Type:    engagementType,
+		Since:   since,
+		Added:   []models.EngagerSetMember{},
+		Removed: []models.EngagerSetMember{},
+	}
+
+	if err := config.DB.Model(&models.EngagerCheckpoint{}).
+		Where("user_id = ? AND tweet_id = ? AND type = ?", userID, tweetID, engagementType).
+		Select("COALESCE(MAX(seq), 0)").Scan(&diff.Checkpoint).Error; err != nil {
+		return nil, err
+	}
+
+	base := config.DB.Model(&models.EngagerSetMember{}).
+		Where("user_id = ? AND tweet_id = ? AND type = ?", userID, tweetID, engagementType).
+		Session(&gorm.Session{})
+	if err := base.
+		Where("removed_seq IS NULL AND added_seq > ?", since).
+		Order("added_seq, username").Find(&diff.Added).Error; err != nil {
+		return nil, err
+	}
+	if err := base.
+		Where("removed_seq > ? AND added_seq <= ?", since, since).
+		Order("removed_seq, username").Find(&diff.Removed).Error; err != nil {
+		return nil, err
+	}
+
+	var total int64
+	base.Where("removed_seq IS NULL").Count(&total)
+	diff.Total = int(total)
+	return diff, nil
+}
+
+// NotifyNewEngagers emits a new_engager event per added engager over the
+// websocket of email and, when set, POSTs all of them to webhookURL as one
+// new_engagers event. Baseline diffs are skipped.
+func NotifyNewEngagers(email, webhookURL string, diff *EngagerDiff) {
+	if diff == nil || diff.Baseline || len(diff.Added) == 0 {
+		return
+	}
+
+	for _, member := range diff.Added {
+		event := map[string]interface{}{
+			"event":      "new_engager",
+			"tweet_id":   diff.TweetID,
+			"type":       diff.Type,
+			"checkpoint": diff.Checkpoint,
+			"engager":    member,
+		}
+		websocket.SendToUser(email, websocket.Message{Type: "engagers", Action: "new_engager", Data: event, Success: true})
+	}
+
+	if webhookURL == "" {
+		return
+	}
+	event := map[string]interface{}{
+		"event":      "new_engagers",
+		"tweet_id":   diff.TweetID,
+		"type":       diff.Type,
+		"checkpoint": diff.Checkpoint,
+		"partial":    diff.Partial,
+		"engagers":   diff.Added,
+	}
+	if err := PostWebhook(webhookURL, event); err != nil {
+		log.Printf("⚠️ Failed to deliver new_engagers webhook for tweet %s: %v", diff.TweetID, err)
+	}
+}
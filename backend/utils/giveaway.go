@@ -0,0 +1,337 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"ripper-backend/models"
+	utils_twitter "ripper-backend/utils/twitter"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxGiveawayLookups caps the profile and follow checks made while drawing winners
+const maxGiveawayLookups = 200
+
+// NewGiveawaySeed returns a random hex seed for a draw
+func NewGiveawaySeed() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// GiveawaySeedHash is the sha256 of a seed, published before the draw so the
+// seed can't be picked once the entrants are known
+func GiveawaySeedHash(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:])
+}
+
+// GiveawayEligibleHash is the sha256 of the sorted, newline-joined eligible user IDs
+func GiveawayEligibleHash(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// GiveawayDrawOrder shuffles the eligible user IDs deterministically. The IDs are
+// sorted, then Fisher-Yates shuffled with a stream of sha256(key || counter)
+// blocks, where key is sha256("seed:tweetID:eligibleHash"). Anyone with the
+// seed and the eligible list can recompute the order.
+func GiveawayDrawOrder(seed, tweetID string, eligibleIDs []string) []string {
+	order := append([]string(nil), eligibleIDs...)
+	sort.Strings(order)
+
+	stream := &drawStream{key: sha256.Sum256([]byte(seed + ":" + tweetID + ":" + GiveawayEligibleHash(order)))}
+	for i := len(order) - 1; i > 0; i-- {
+		j := stream.intn(i + 1)
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// drawStream is a deterministic random stream derived from a key
+type drawStream struct {
+	key     [32]byte
+	counter uint64
+}
+
+func (d *drawStream) uint64() uint64 {
+	block := make([]byte, 40)
+	copy(block, d.key[:])
+	binary.BigEndian.PutUint64(block[32:], d.counter)
+	d.counter++
+	sum := sha256.Sum256(block)
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// intn returns a uniform number in [0, n) using rejection sampling
+func (d *drawStream) intn(n int) int {
+	limit := ^uint64(0) - (^uint64(0) % uint64(n))
+	for {
+		if v := d.uint64(); v < limit {
+			return int(v % uint64(n))
+		}
+	}
+}
+
+// giveawayProfileReason checks the profile rules of a giveaway
+func giveawayProfileReason(g *models.Giveaway, u *utils_twitter.User, now time.Time) string {
+	if g.MinFollowers > 0 && u.Followers < g.MinFollowers {
+		return fmt.Sprintf("has %d followers, needs %d", u.Followers, g.MinFollowers)
+	}
+	if g.MinAccountAgeDays > 0 {
+		if u.CreatedAt.IsZero() {
+			return "account age unknown"
+		}
+		if age := int(now.Sub(u.CreatedAt).Hours() / 24); age < g.MinAccountAgeDays {
+			return fmt.Sprintf("account is %d days old, needs %d", age, g.MinAccountAgeDays)
+		}
+	}
+	if g.ExcludeBots {
+		if q := utils_twitter.ScoreUser(u, now); q.LikelyBot {
+			return "looks like a bot: " + strings.Join(q.Reasons, ", ")
+		}
+	}
+	return ""
+}
+
+// needsProfile reports whether the rules of a giveaway look at profile data
+func needsProfile(g *models.Giveaway) bool {
+	return g.MinFollowers > 0 || g.MinAccountAgeDays > 0 || g.ExcludeBots
+}
+
+// RunGiveaway scrapes the engagers a giveaway requires, applies its rules and
+// draws its winners with g.Seed, which must be fixed beforehand. g's counts,
+// hash and truncation are filled in. It returns every entrant with its outcome.
+func RunGiveaway(session *utils_twitter.Session, g *models.Giveaway) ([]models.GiveawayEntrant, error) {
+	if !g.RequireLike && !g.RequireRetweet && !g.RequireReply {
+		return nil, errors.New("at least one of require_like, require_retweet or require_reply is needed")
+	}
+	if g.Seed == "" {
+		return nil, errors.New("the giveaway has no seed")
+	}
+
+	entrants := map[string]*models.GiveawayEntrant{}
+	profiles := map[string]*utils_twitter.User{} // full profiles, from likers and retweeters
+	missedKeyword := map[string]bool{}
+
+	entrant := func(id, username, name string) *models.GiveawayEntrant {
+		e, ok := entrants[id]
+		if !ok {
+			e = &models.GiveawayEntrant{TwitterUserID: id, Username: username, Name: name}
+			entrants[id] = e
+		}
+		return e
+	}
+	addUsers := func(users []*utils_twitter.User, mark func(*models.GiveawayEntrant)) {
+		for _, u := range users {
+			if u == nil || u.ID == "" {
+				continue
+			}
+			mark(entrant(u.ID, u.Username, u.Name))
+			profiles[u.ID] = u
+		}
+	}
+
+	if g.RequireLike {
+		likers, err := session.GetLikers(g.TweetID)
+		if err != nil {
+			return nil, err
+		}
+		go SaveEngagers(g.UserID, g.TweetID, models.EngagementLiked, likers)
+		addUsers(likers, func(e *models.GiveawayEntrant) { e.Liked = true })
+	}
+
+	if g.RequireRetweet {
+		retweeters, err := session.GetRetweeters(g.TweetID)
+		if err != nil {
+			return nil, err
+		}
+		go SaveEngagers(g.UserID, g.TweetID, models.EngagementRetweeted, retweeters)
+		addUsers(retweeters, func(e *models.GiveawayEntrant) { e.Retweeted = true })
+	}
+
+	if g.RequireReply {
+		replies, err := session.GetAllTweetReplies(g.TweetID)
+		if err != nil {
+			return nil, err
+		}
+		go SaveEngagementTweets(g.UserID, g.TweetID, models.EngagementReplied, replies)
+		keyword := strings.ToLower(g.ReplyContains)
+		for _, r := range replies {
+			if r == nil || r.AuthorID == "" {
+				continue
+			}
+			e := entrant(r.AuthorID, r.Username, r.Name)
+			if keyword == "" || strings.Contains(strings.ToLower(r.Text), keyword) {
+				e.Replied = true
+			} else {
+				missedKeyword[r.AuthorID] = true
+			}
+		}
+	}
+
+	// Rules that can be checked from the scraped data
+	now := time.Now()
+	var eligibleIDs []string
+	for id, e := range entrants {
+		if u, ok := profiles[id]; ok {
+			e.Followers = u.Followers
+			if !u.CreatedAt.IsZero() {
+				createdAt := u.CreatedAt
+				e.AccountCreatedAt = &createdAt
+			}
+		}
+
+		reason := ""
+		switch {
+		case g.RequireLike && !e.Liked:
+			reason = "did not like the tweet"
+		case g.RequireRetweet && !e.Retweeted:
+			reason = "did not retweet the tweet"
+		case g.RequireReply && !e.Replied && missedKeyword[id]:
+			reason = fmt.Sprintf("reply does not contain %q", g.ReplyContains)
+		case g.RequireReply && !e.Replied:
+			reason = "did not reply to the tweet"
+		}
+		if reason == "" && needsProfile(g) {
+			if u, ok := profiles[id]; ok {
+				reason = giveawayProfileReason(g, u, now)
+			}
+		}
+
+		if reason != "" {
+			e.Status, e.Reason = models.EntrantIneligible, reason
+			continue
+		}
+		e.Status = models.EntrantEligible
+		eligibleIDs = append(eligibleIDs, id)
+	}
+
+	g.Truncated = session.Truncated(utils_twitter.OpFavoriters) || session.Truncated(utils_twitter.OpRetweeters) ||
+		session.Truncated(utils_twitter.OpTweetDetail)
+	g.EntrantCount = len(entrants)
+	g.EligibleCount = len(eligibleIDs)
+	g.EligibleHash = GiveawayEligibleHash(eligibleIDs)
+
+	// Draw, checking follows and missing profiles only for the entrants drawn
+	order := GiveawayDrawOrder(g.Seed, g.TweetID, eligibleIDs)
+	winners, lookups := 0, 0
+	for i, id := range order {
+		e := entrants[id]
+		position := i + 1
+		e.Position = &position
+		if winners >= g.WinnerCount || lookups >= maxGiveawayLookups {
+			continue
+		}
+
+		if _, ok := profiles[id]; !ok && needsProfile(g) {
+			lookups++
+			u, err := session.GetUserProfile(id)
+			if errors.Is(err, utils_twitter.ErrNotFound) || errors.Is(err, utils_twitter.ErrSuspended) {
+				e.Status, e.Reason = models.EntrantSkipped, "account not found or suspended"
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			profiles[id] = u
+			e.Followers = u.Followers
+			if !u.CreatedAt.IsZero() {
+				createdAt := u.CreatedAt
+				e.AccountCreatedAt = &createdAt
+			}
+			if reason := giveawayProfileReason(g, u, now); reason != "" {
+				e.Status, e.Reason = models.EntrantSkipped, reason
+				continue
+			}
+		}
+
+		if g.MustFollow != "" {
+			lookups++
+			following, err := session.IsFollowing(id, g.MustFollow)
+			if errors.Is(err, utils_twitter.ErrNotFound) {
+				e.Status, e.Reason = models.EntrantSkipped, "account not found"
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !following {
+				e.Status, e.Reason = models.EntrantSkipped, "does not follow @"+g.MustFollow
+				continue
+			}
+		}
+
+		winners++
+		e.Status, e.WinnerRank = models.EntrantWinner, winners
+	}
+
+	result := make([]models.GiveawayEntrant, 0, len(entrants))
+	for _, e := range entrants {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		pi, pj := result[i].Position, result[j].Position
+		switch {
+		case pi != nil && pj != nil:
+			return *pi < *pj
+		case pi != nil || pj != nil:
+			return pi != nil
+		}
+		return result[i].TwitterUserID < result[j].TwitterUserID
+	})
+	return result, nil
+}
+
+// VerifyGiveaway recomputes the draw of a stored giveaway and reports every
+// mismatch with the stored winners. An empty result means the draw checks out.
+func VerifyGiveaway(g *models.Giveaway, entrants []models.GiveawayEntrant) []string {
+	problems := []string{}
+
+	byID := make(map[string]models.GiveawayEntrant, len(entrants))
+	var eligibleIDs []string
+	for _, e := range entrants {
+		byID[e.TwitterUserID] = e
+		if e.Status != models.EntrantIneligible {
+			eligibleIDs = append(eligibleIDs, e.TwitterUserID)
+		}
+	}
+
+	if g.SeedHash != "" && GiveawaySeedHash(g.Seed) != g.SeedHash {
+		problems = append(problems, "seed does not match the committed seed hash")
+	}
+	if hash := GiveawayEligibleHash(eligibleIDs); hash != g.EligibleHash {
+		problems = append(problems, "eligible list does not match the stored hash")
+	}
+
+	// Winners must be the first entrants in draw order that were not skipped. The
+	// draw may stop early when it runs out of lookups, after which nobody can win.
+	winners, stopped := 0, false
+	for i, id := range GiveawayDrawOrder(g.Seed, g.TweetID, eligibleIDs) {
+		e := byID[id]
+		if e.Position == nil || *e.Position != i+1 {
+			problems = append(problems, fmt.Sprintf("%s is not at draw position %d", id, i+1))
+		}
+		switch e.Status {
+		case models.EntrantWinner:
+			winners++
+			if stopped || winners > g.WinnerCount {
+				problems = append(problems, fmt.Sprintf("%s at position %d should not have won", id, i+1))
+			} else if e.WinnerRank != winners {
+				problems = append(problems, fmt.Sprintf("%s should be winner %d", id, winners))
+			}
+		case models.EntrantEligible:
+			if winners < g.WinnerCount {
+				stopped = true
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"reflect"
+	"ripper-backend/models"
+	"sort"
+	"testing"
+)
+
+// drawnGiveaway returns a giveaway of 2 winners over five eligible entrants and
+// an ineligible one, stored the way RunGiveaway stores it. entrants[i] is at
+// draw position i+1; the ineligible entrant is last.
+func drawnGiveaway() (*models.Giveaway, []models.GiveawayEntrant) {
+	eligible := []string{"101", "102", "103", "104", "105"}
+	g := &models.Giveaway{
+		TweetID:      "1800000000000000000",
+		WinnerCount:  2,
+		Seed:         "5eed",
+		SeedHash:     GiveawaySeedHash("5eed"),
+		EligibleHash: GiveawayEligibleHash(eligible),
+	}
+
+	var entrants []models.GiveawayEntrant
+	for i, id := range GiveawayDrawOrder(g.Seed, g.TweetID, eligible) {
+		position := i + 1
+		e := models.GiveawayEntrant{TwitterUserID: id, Status: models.EntrantEligible, Position: &position}
+		if i < g.WinnerCount {
+			e.Status = models.EntrantWinner
+			e.WinnerRank = i + 1
+		}
+		entrants = append(entrants, e)
+	}
+	entrants = append(entrants, models.GiveawayEntrant{TwitterUserID: "999", Status: models.EntrantIneligible})
+	return g, entrants
+}
+
+func TestGiveawayDrawOrder(t *testing.T) {
+	ids := []string{"3", "1", "5", "2", "4"}
+	order := GiveawayDrawOrder("seed", "42", ids)
+
+	if again := GiveawayDrawOrder("seed", "42", []string{"5", "4", "3", "2", "1"}); !reflect.DeepEqual(order, again) {
+		t.Errorf("draw order depends on the input order: %v and %v", order, again)
+	}
+	sorted := append([]string(nil), order...)
+	sort.Strings(sorted)
+	if !reflect.DeepEqual(sorted, []string{"1", "2", "3", "4", "5"}) {
+		t.Errorf("draw order %v is not a permutation of %v", order, ids)
+	}
+	if !reflect.DeepEqual(ids, []string{"3", "1", "5", "2", "4"}) {
+		t.Errorf("draw order modified its input: %v", ids)
+	}
+}
+
+func TestVerifyGiveaway(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(g *models.Giveaway, entrants []models.GiveawayEntrant)
+		want   func(entrants []models.GiveawayEntrant) []string
+		// anyProblem is set when the exact problems don't matter, only that there are some
+		anyProblem bool
+	}{
+		{
+			name:   "untouched draw",
+			tamper: func(*models.Giveaway, []models.GiveawayEntrant) {},
+			want:   func([]models.GiveawayEntrant) []string { return []string{} },
+		},
+		{
+			name: "drawn entrant skipped",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[1].Status, e[1].WinnerRank = models.EntrantSkipped, 0
+				e[2].Status, e[2].WinnerRank = models.EntrantWinner, 2
+			},
+			want: func([]models.GiveawayEntrant) []string { return []string{} },
+		},
+		{
+			name: "draw stopped before all winners",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[1].Status, e[1].WinnerRank = models.EntrantEligible, 0
+			},
+			want: func([]models.GiveawayEntrant) []string { return []string{} },
+		},
+		{
+			name: "seed changed after the commitment",
+			tamper: func(g *models.Giveaway, _ []models.GiveawayEntrant) {
+				g.Seed = "5eed2"
+			},
+			anyProblem: true,
+		},
+		{
+			name: "eligible hash changed",
+			tamper: func(g *models.Giveaway, _ []models.GiveawayEntrant) {
+				g.EligibleHash = GiveawayEligibleHash([]string{"101", "102"})
+			},
+			want: func([]models.GiveawayEntrant) []string {
+				return []string{"eligible list does not match the stored hash"}
+			},
+		},
+		{
+			name: "ineligible entrant made eligible",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[5].Status = models.EntrantSkipped
+			},
+			anyProblem: true,
+		},
+		{
+			name: "winner past an eligible entrant",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[1].Status, e[1].WinnerRank = models.EntrantEligible, 0
+				e[2].Status, e[2].WinnerRank = models.EntrantWinner, 2
+			},
+			want: func(e []models.GiveawayEntrant) []string {
+				return []string{e[2].TwitterUserID + " at position 3 should not have won"}
+			},
+		},
+		{
+			name: "too many winners",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[2].Status, e[2].WinnerRank = models.EntrantWinner, 3
+			},
+			want: func(e []models.GiveawayEntrant) []string {
+				return []string{e[2].TwitterUserID + " at position 3 should not have won"}
+			},
+		},
+		{
+			name: "winner ranks swapped",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[0].WinnerRank, e[1].WinnerRank = 2, 1
+			},
+			want: func(e []models.GiveawayEntrant) []string {
+				return []string{
+					e[0].TwitterUserID + " should be winner 1",
+					e[1].TwitterUserID + " should be winner 2",
+				}
+			},
+		},
+		{
+			name: "position changed",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				position := 1
+				e[3].Position = &position
+			},
+			want: func(e []models.GiveawayEntrant) []string {
+				return []string{e[3].TwitterUserID + " is not at draw position 4"}
+			},
+		},
+		{
+			name: "position missing",
+			tamper: func(_ *models.Giveaway, e []models.GiveawayEntrant) {
+				e[4].Position = nil
+			},
+			want: func(e []models.GiveawayEntrant) []string {
+				return []string{e[4].TwitterUserID + " is not at draw position 5"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, entrants := drawnGiveaway()
+			tt.tamper(g, entrants)
+
+			got := VerifyGiveaway(g, entrants)
+			if tt.anyProblem {
+				if len(got) == 0 {
+					t.Errorf("VerifyGiveaway() found no problems")
+				}
+				return
+			}
+			if want := tt.want(entrants); !reflect.DeepEqual(got, want) {
+				t.Errorf("VerifyGiveaway() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package utils_mailer
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// Mailer delivers plain-text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// mailer is what Send delivers through, the log-only mailer until Init picks one
+var mailer Mailer = LogMailer{}
+
+// Init picks the mailer from the environment: SMTP when SMTP_HOST is set,
+// otherwise the log-only mailer for local and dev use. SMTP_PORT defaults to
+// 587, SMTP_USERNAME and SMTP_PASSWORD are optional, and SMTP_FROM defaults
+// to SMTP_USERNAME.
+func Init() {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		mailer = LogMailer{}
+		log.Println("✅ Mailer: log only (set SMTP_HOST to send emails)")
+		return
+	}
+
+	smtpMailer := SMTPMailer{
+		Host:     host,
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if smtpMailer.Port == "" {
+		smtpMailer.Port = "587"
+	}
+	if smtpMailer.From == "" {
+		smtpMailer.From = smtpMailer.Username
+	}
+	mailer = smtpMailer
+	log.Printf("✅ Mailer: SMTP via %s:%s", smtpMailer.Host, smtpMailer.Port)
+}
+
+// Use replaces the mailer, for custom delivery
+func Use(m Mailer) {
+	mailer = m
+}
+
+// Send delivers an email through the configured mailer
+func Send(to, subject, body string) error {
+	return mailer.Send(to, subject, body)
+}
+
+// SMTPMailer sends through an SMTP server, with STARTTLS when the server offers it
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m SMTPMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + m.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to, err)
+	}
+	return nil
+}
+
+// LogMailer writes emails to the log instead of sending them
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	log.Printf("📧 Email to %s: %s\n%s", to, subject, body)
+	return nil
+}
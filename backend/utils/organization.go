@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"ripper-backend/config"
+	"ripper-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Accessible scopes a query of accounts, scheduled messages or logs to the
+// rows the user owns and the rows of organizations where they hold at least
+// minRole. Organizations requiring 2FA are left out until the user enables it.
+func Accessible(userID, minRole string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(user_id = ? OR organization_id IN (SELECT m.organization_id FROM organization_members m JOIN organizations o ON o.id = m.organization_id
+			WHERE m.user_id = ? AND m.role IN ? AND (NOT o.require_two_factor OR EXISTS (SELECT 1 FROM users u WHERE u.id = m.user_id AND u.totp_enabled_at IS NOT NULL))))`,
+			userID, userID, models.OrgRolesAtLeast(minRole))
+	}
+}
+
+// CanAccess reports whether a user may act on a row owned by ownerID and shared
+// with organizationID with minRole, and with 2FA if the organization requires it
+func CanAccess(userID, ownerID string, organizationID *string, minRole string) bool {
+	if userID == ownerID {
+		return true
+	}
+	if organizationID == nil {
+		return false
+	}
+	role, ok := OrganizationRole(*organizationID, userID)
+	return ok && models.OrgRoleAtLeast(role, minRole) && !TwoFactorBlocked(*organizationID, userID)
+}
+
+// OrganizationRole returns the role of a user in an organization
+func OrganizationRole(organizationID, userID string) (string, bool) {
+	var member models.OrganizationMember
+	if err := config.DB.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error; err != nil {
+		return "", false
+	}
+	return member.Role, true
+}
+
+// WhatsAppSessionOrganization returns the organization a WhatsApp session is
+// shared with, so the batches and logs it sends are shared with it too
+func WhatsAppSessionOrganization(sessionID string) *string {
+	var account models.WhatsAppAccount
+	if err := config.DB.Select("organization_id").Where("session_id = ?", sessionID).First(&account).Error; err != nil {
+		return nil
+	}
+	return account.OrganizationID
+}
+
+// TwitterAccountOrganization returns the organization a user's Twitter account
+// is shared with, so its API call logs are shared with it too
+func TwitterAccountOrganization(userID, username string) *string {
+	if userID == "" || username == "" {
+		return nil
+	}
+	var account models.TwitterAccount
+	if err := config.DB.Select("organization_id").Where("user_id = ? AND username = ?", userID, username).First(&account).Error; err != nil {
+		return nil
+	}
+	return account.OrganizationID
+}
+
+// ShareTwitterAccount shares a Twitter account with an organization, or makes
+// it personal again when organizationID is nil. Its scheduled tweets and DMs
+// and its API call logs move with it.
+func ShareTwitterAccount(account models.TwitterAccount, organizationID *string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TwitterAccount{}).Where("id = ?", account.ID).Update("organization_id", organizationID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ScheduledMessage{}).Where("twitter_account_id = ?", account.ID).Update("organization_id", organizationID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ApiCallLog{}).
+			Where("user_id = ? AND twitter_username = ?", account.UserID, account.Username).
+			Update("organization_id", organizationID).Error
+	})
+}
+
+// ShareWhatsAppAccount shares a WhatsApp account with an organization, or makes
+// it personal again when organizationID is nil. Its scheduled messages and
+// message logs move with it.
+func ShareWhatsAppAccount(account models.WhatsAppAccount, organizationID *string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WhatsAppAccount{}).Where("id = ?", account.ID).Update("organization_id", organizationID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ScheduledMessage{}).
+			Where("session_id = ? AND channel = ?", account.SessionID, models.ChannelWhatsApp).
+			Update("organization_id", organizationID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.MessageLog{}).Where("session_id = ?", account.SessionID).Update("organization_id", organizationID).Error
+	})
+}
+
+// UnshareOrganization makes what was shared with an organization personal
+// again, owned by whoever added it
+func UnshareOrganization(tx *gorm.DB, organizationID string) error {
+	for _, model := range []interface{}{&models.TwitterAccount{}, &models.WhatsAppAccount{}, &models.ScheduledMessage{}, &models.MessageLog{}, &models.ApiCallLog{}} {
+		if err := tx.Model(model).Where("organization_id = ?", organizationID).Update("organization_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	utils_twitter "ripper-backend/utils/twitter"
+)
+
+// ValidateProxyURL checks that a proxy URL is well formed and, like webhook
+// URLs, points to a public host, so proxies can't be used to probe internal
+// addresses and ports through the health check or Twitter requests
+func ValidateProxyURL(raw string) error {
+	u, err := utils_twitter.ParseProxyURL(raw)
+	if err != nil {
+		return err
+	}
+	return checkPublicHost("proxy", u.Hostname())
+}
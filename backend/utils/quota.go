@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WhatsAppMessageCost is what one sent WhatsApp message takes from the WhatsApp balance
+const WhatsAppMessageCost = 1
+
+// quotaColumns is the users column holding the balance of each quota type
+var quotaColumns = map[string]string{
+	models.QuotaTwitterReads:  "twitter_reqs",
+	models.QuotaTwitterWrites: "twitter_writes",
+	models.QuotaWhatsApp:      "whatsapp_messages",
+}
+
+// quotaNames is how an exhausted balance is described in errors
+var quotaNames = map[string]string{
+	models.QuotaTwitterReads:  "Twitter requests",
+	models.QuotaTwitterWrites: "Twitter write requests",
+	models.QuotaWhatsApp:      "WhatsApp messages",
+}
+
+// IsQuotaType reports whether quotaType names a quota balance
+func IsQuotaType(quotaType string) bool {
+	_, ok := quotaColumns[quotaType]
+	return ok
+}
+
+// QuotaBalance returns a user's balance of a quota type
+func QuotaBalance(user models.User, quotaType string) int {
+	switch quotaType {
+	case models.QuotaTwitterReads:
+		return user.TwitterReqs
+	case models.QuotaTwitterWrites:
+		return user.TwitterWrites
+	case models.QuotaWhatsApp:
+		return user.WhatsAppMessages
+	}
+	return 0
+}
+
+// QuotaReservation is balance taken from a user before a call. It is settled
+// once, with Commit after the call succeeded or Refund after it failed.
+// Reserving up front keeps parallel calls from spending the same balance.
+type QuotaReservation struct {
+	UserID    string
+	QuotaType string
+	Amount    int
+	Reason    string
+	settled   bool
+}
+
+// ReserveQuota atomically takes amount from the user's balance of a quota type
+// and records the debit in the usage ledger. It fails without taking anything
+// when the balance is short.
+func ReserveQuota(userID, quotaType string, amount int, reason string) (*QuotaReservation, error) {
+	column, ok := quotaColumns[quotaType]
+	if !ok {
+		return nil, fmt.Errorf("unknown quota type %q", quotaType)
+	}
+
+	r := &QuotaReservation{UserID: userID, QuotaType: quotaType, Amount: amount, Reason: reason}
+	if amount <= 0 {
+		return r, nil
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND "+column+" >= ?", userID, amount).
+			Update(column, gorm.Expr(column+" - ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("insufficient %s remaining", quotaNames[quotaType])
+		}
+		return recordUsage(tx, userID, quotaType, -amount, models.LedgerReserve, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ReserveWhatsAppMessage reserves one message from the user's WhatsApp balance.
+// See ReserveQuota.
+func ReserveWhatsAppMessage(userID, reason string) (*QuotaReservation, error) {
+	return ReserveQuota(userID, models.QuotaWhatsApp, WhatsAppMessageCost, reason)
+}
+
+// Commit settles the reservation at the actual cost of the call. Whatever was
+// reserved but not used goes back to the balance; a call that cost more, such
+// as a scrape that fetched more pages, takes the rest without going below zero.
+func (r *QuotaReservation) Commit(actual int) error {
+	if r.settled {
+		return nil
+	}
+	r.settled = true
+
+	if actual < 0 {
+		actual = 0
+	}
+	return r.adjust(r.Amount-actual, models.LedgerCommit)
+}
+
+// Refund returns the whole reservation to the balance after a failed call
+func (r *QuotaReservation) Refund() error {
+	if r.settled {
+		return nil
+	}
+	r.settled = true
+
+	return r.adjust(r.Amount, models.LedgerRefund)
+}
+
+// adjust adds delta to the balance, or takes it when negative
+func (r *QuotaReservation) adjust(delta int, kind string) error {
+	if delta == 0 {
+		return nil
+	}
+
+	err := CreditQuota(r.UserID, r.QuotaType, delta, kind, r.Reason)
+	if err != nil {
+		log.Printf("❌ Failed to %s %d %s of user %s: %v", kind, r.Amount, quotaNames[r.QuotaType], r.UserID, err)
+	}
+	return err
+}
+
+// CreditQuota adds amount to the user's balance of a quota type, or takes it
+// without going below zero when negative, and records it in the usage ledger
+func CreditQuota(userID, quotaType string, amount int, kind, reason string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		return creditQuota(tx, userID, quotaType, amount, kind, reason)
+	})
+}
+
+// creditQuota is CreditQuota inside a transaction
+func creditQuota(tx *gorm.DB, userID, quotaType string, amount int, kind, reason string) error {
+	column, ok := quotaColumns[quotaType]
+	if !ok {
+		return fmt.Errorf("unknown quota type %q", quotaType)
+	}
+
+	result := tx.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update(column, gorm.Expr("GREATEST("+column+" + ?, 0)", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return recordUsage(tx, userID, quotaType, amount, kind, reason)
+}
+
+// recordUsage writes a ledger entry with the balance it left, read inside the
+// transaction that changed it
+func recordUsage(tx *gorm.DB, userID, quotaType string, amount int, kind, reason string) error {
+	var balance int
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).
+		Select(quotaColumns[quotaType]).Scan(&balance).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.UsageLedgerEntry{
+		UserID:    userID,
+		QuotaType: quotaType,
+		Amount:    amount,
+		Kind:      kind,
+		Reason:    reason,
+		Balance:   balance,
+	}).Error
+}
+
+// DefaultPlan returns the plan of users that were never assigned one
+func DefaultPlan() (*models.Plan, error) {
+	var plan models.Plan
+	if err := config.DB.Where("name = ?", models.DefaultPlanName).First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// EnsureDefaultPlan creates the default plan when it does not exist yet. Its
+// allowances match what a new user starts with.
+func EnsureDefaultPlan() error {
+	plan := models.Plan{
+		Name:             models.DefaultPlanName,
+		Period:           models.PlanPeriodMonthly,
+		TwitterReads:     100,
+		TwitterWrites:    100,
+		WhatsAppMessages: 100,
+	}
+	return config.DB.Where("name = ?", plan.Name).FirstOrCreate(&plan).Error
+}
+
+// UserPlan returns the plan a user is on
+func UserPlan(user models.User) (*models.Plan, error) {
+	if user.PlanID == nil {
+		return DefaultPlan()
+	}
+
+	var plan models.Plan
+	if err := config.DB.Where("id = ?", *user.PlanID).First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// ResetUserQuotas refills every balance of a user to the allowance of their
+// plan and schedules the next refill one period after now. A balance already
+// above the allowance, such as one topped up by an admin, is kept.
+func ResetUserQuotas(userID string, plan *models.Plan, now time.Time) error {
+	reason := fmt.Sprintf("%s plan %s reset", plan.Name, plan.Period)
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the row so debits made meanwhile are not refilled twice
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		for _, quotaType := range models.AllQuotaTypes {
+			refill := plan.Allowance(quotaType) - QuotaBalance(user, quotaType)
+			if refill <= 0 {
+				continue
+			}
+			if err := creditQuota(tx, userID, quotaType, refill, models.LedgerReset, reason); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("quota_reset_at", plan.NextReset(now)).Error
+	})
+}
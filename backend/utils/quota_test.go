@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"sync"
+	"testing"
+)
+
+// quotaState returns the user's balance of a quota type and its ledger entries,
+// as "kind amount", oldest first
+func quotaState(t *testing.T, userID, quotaType string) (int, []string) {
+	t.Helper()
+
+	var user models.User
+	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		t.Fatalf("failed to load the user: %v", err)
+	}
+	var entries []models.UsageLedgerEntry
+	config.DB.Where("user_id = ? AND quota_type = ?", userID, quotaType).Order("created_at").Find(&entries)
+
+	ledger := []string{}
+	for _, e := range entries {
+		ledger = append(ledger, fmt.Sprintf("%s %d", e.Kind, e.Amount))
+	}
+	return QuotaBalance(user, quotaType), ledger
+}
+
+func TestQuotaReservation(t *testing.T) {
+	commit := func(actual int) func(*QuotaReservation) error {
+		return func(r *QuotaReservation) error { return r.Commit(actual) }
+	}
+	refund := func(r *QuotaReservation) error { return r.Refund() }
+
+	tests := []struct {
+		name        string
+		quotaType   string
+		balance     int
+		reserve     int
+		settle      []func(*QuotaReservation) error
+		wantErr     bool
+		wantBalance int
+		wantLedger  []string
+	}{
+		{
+			name: "commit what was reserved", quotaType: models.QuotaTwitterReads, balance: 100, reserve: 30,
+			settle: []func(*QuotaReservation) error{commit(30)}, wantBalance: 70,
+			wantLedger: []string{"reserve -30"},
+		},
+		{
+			name: "commit less than reserved", quotaType: models.QuotaTwitterReads, balance: 100, reserve: 30,
+			settle: []func(*QuotaReservation) error{commit(10)}, wantBalance: 90,
+			wantLedger: []string{"reserve -30", "commit 20"},
+		},
+		{
+			name: "commit more than reserved", quotaType: models.QuotaTwitterReads, balance: 100, reserve: 30,
+			settle: []func(*QuotaReservation) error{commit(50)}, wantBalance: 50,
+			wantLedger: []string{"reserve -30", "commit -20"},
+		},
+		{
+			name: "commit more than the balance", quotaType: models.QuotaTwitterReads, balance: 40, reserve: 30,
+			settle: []func(*QuotaReservation) error{commit(100)}, wantBalance: 0,
+		},
+		{
+			name: "refund", quotaType: models.QuotaTwitterWrites, balance: 100, reserve: 30,
+			settle: []func(*QuotaReservation) error{refund}, wantBalance: 100,
+			wantLedger: []string{"reserve -30", "refund 30"},
+		},
+		{
+			name: "settled once", quotaType: models.QuotaWhatsApp, balance: 100, reserve: 30,
+			settle: []func(*QuotaReservation) error{commit(10), refund, commit(0)}, wantBalance: 90,
+			wantLedger: []string{"reserve -30", "commit 20"},
+		},
+		{
+			name: "whole balance", quotaType: models.QuotaTwitterReads, balance: 30, reserve: 30,
+			settle: []func(*QuotaReservation) error{commit(30)}, wantBalance: 0,
+			wantLedger: []string{"reserve -30"},
+		},
+		{
+			name: "balance short", quotaType: models.QuotaTwitterReads, balance: 20, reserve: 30,
+			wantErr: true, wantBalance: 20, wantLedger: []string{},
+		},
+		{
+			name: "nothing to reserve", quotaType: models.QuotaTwitterReads, balance: 0, reserve: 0,
+			settle: []func(*QuotaReservation) error{commit(0)}, wantBalance: 0, wantLedger: []string{},
+		},
+		{
+			name: "unknown quota type", quotaType: "sms", balance: 100, reserve: 1, wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := testUser(t)
+			if column, ok := quotaColumns[tt.quotaType]; ok {
+				if err := config.DB.Model(user).Update(column, tt.balance).Error; err != nil {
+					t.Fatalf("failed to set the balance: %v", err)
+				}
+			}
+
+			r, err := ReserveQuota(user.ID, tt.quotaType, tt.reserve, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReserveQuota() error = %v, want error %v", err, tt.wantErr)
+			}
+			for _, settle := range tt.settle {
+				if err := settle(r); err != nil {
+					t.Fatalf("settling failed: %v", err)
+				}
+			}
+			if !IsQuotaType(tt.quotaType) {
+				return
+			}
+
+			balance, ledger := quotaState(t, user.ID, tt.quotaType)
+			if balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", balance, tt.wantBalance)
+			}
+			if tt.wantLedger != nil && !reflect.DeepEqual(ledger, tt.wantLedger) {
+				t.Errorf("ledger = %q, want %q", ledger, tt.wantLedger)
+			}
+		})
+	}
+}
+
+func TestReserveQuotaConcurrent(t *testing.T) {
+	user := testUser(t)
+	if err := config.DB.Model(user).Update("twitter_reqs", 100).Error; err != nil {
+		t.Fatalf("failed to set the balance: %v", err)
+	}
+
+	// Twice as many reservations as the balance covers, all at once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ReserveQuota(user.ID, models.QuotaTwitterReads, 10, "test"); err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	balance, ledger := quotaState(t, user.ID, models.QuotaTwitterReads)
+	if reserved != 10 || balance != 0 || len(ledger) != 10 {
+		t.Errorf("%d reservations succeeded leaving %d with %d ledger entries, want 10 leaving 0 with 10", reserved, balance, len(ledger))
+	}
+}
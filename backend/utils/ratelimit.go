@@ -4,39 +4,63 @@ import (
 	"errors"
 	"ripper-backend/config"
 	"ripper-backend/models"
+	utils_twitter "ripper-backend/utils/twitter"
 )
 
-const TwitterRequestCost = 10
+// DefaultTwitterRequestCost is charged for operations missing from TwitterRequestCosts
+const DefaultTwitterRequestCost = 10
 
-// CheckTwitterRateLimit checks if user has enough requests remaining
-func CheckTwitterRateLimit(userID string) error {
-	var user models.User
-	if err := config.DB.Where("id = ?", userID).First(&user).Error; err != nil {
-		return errors.New("user not found")
-	}
+// TwitterCacheHitCost is charged instead of an operation's cost when a lookup is served from the response cache
+const TwitterCacheHitCost = 2
 
-	if user.TwitterReqs < TwitterRequestCost {
-		return errors.New("insufficient Twitter requests remaining")
-	}
+// TwitterPageCost is charged for every page after the first of a paginated scrape
+const TwitterPageCost = 1
 
-	return nil
+// TwitterRequestCosts is how many Twitter requests one call of each X operation
+// costs. The cost covers the first page; see TwitterScrapeCost.
+var TwitterRequestCosts = map[string]int{
+	utils_twitter.OpTweetResult:    10,
+	utils_twitter.OpFavoriters:     10,
+	utils_twitter.OpRetweeters:     10,
+	utils_twitter.OpSearch:         10,
+	utils_twitter.OpTweetDetail:    10,
+	utils_twitter.OpUserShow:       5,
+	utils_twitter.OpFriendshipShow: 5,
+	utils_twitter.OpDMInbox:        10,
+	utils_twitter.OpDMConversation: 10,
+	utils_twitter.OpCreateTweet:    10,
+	utils_twitter.OpFavoriteTweet:  10,
+	utils_twitter.OpUnfavorite:     10,
+	utils_twitter.OpCreateRetweet:  10,
+	utils_twitter.OpDeleteRetweet:  10,
+	utils_twitter.OpCreateBookmark: 10,
+	utils_twitter.OpDeleteBookmark: 10,
+	utils_twitter.OpFollow:         10,
+	utils_twitter.OpUnfollow:       10,
+	utils_twitter.OpDMSend:         10,
 }
 
-// DeductTwitterRequests deducts requests from user's quota after successful API call
-func DeductTwitterRequests(userID string) error {
-	result := config.DB.Model(&models.User{}).
-		Where("id = ? AND twitter_reqs >= ?", userID, TwitterRequestCost).
-		Update("twitter_reqs", config.DB.Raw("twitter_reqs - ?", TwitterRequestCost))
-
-	if result.Error != nil {
-		return result.Error
+// TwitterRequestCost returns what one call of an X operation costs
+func TwitterRequestCost(operation string) int {
+	if cost, ok := TwitterRequestCosts[operation]; ok {
+		return cost
 	}
+	return DefaultTwitterRequestCost
+}
 
-	if result.RowsAffected == 0 {
-		return errors.New("insufficient requests or user not found")
+// TwitterScrapeCost returns what a scrape of operation that fetched pages pages costs
+func TwitterScrapeCost(operation string, pages int) int {
+	cost := TwitterRequestCost(operation)
+	if pages > 1 {
+		cost += (pages - 1) * TwitterPageCost
 	}
+	return cost
+}
 
-	return nil
+// ReserveTwitterRequests reserves amount from the user's Twitter reads balance
+// for a call of endpoint. See ReserveQuota.
+func ReserveTwitterRequests(userID string, amount int, endpoint string) (*QuotaReservation, error) {
+	return ReserveQuota(userID, models.QuotaTwitterReads, amount, endpoint)
 }
 
 // GetTwitterRequestsRemaining returns the number of Twitter requests remaining for a user
@@ -47,4 +71,4 @@ func GetTwitterRequestsRemaining(userID string) (int, error) {
 	}
 
 	return user.TwitterReqs, nil
-}
\ No newline at end of file
+}
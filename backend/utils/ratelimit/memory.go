@@ -0,0 +1,66 @@
+package utils_ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryCleanupInterval is how often buckets that refilled completely are dropped
+const memoryCleanupInterval = 5 * time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket is full again; it can be dropped after
+}
+
+// memoryStore keeps the buckets in process
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newMemoryStore() *memoryStore {
+	m := &memoryStore{buckets: make(map[string]*bucket)}
+	go m.cleanupFull()
+	return m
+}
+
+func (m *memoryStore) take(key string, limit Limit, now time.Time) (float64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = limit.refill(b.tokens, b.updated, now)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.full = now.Add(secondsOf((float64(limit.Burst) - b.tokens) / limit.rate()))
+
+	return b.tokens, allowed, nil
+}
+
+// cleanupFull periodically drops buckets that are full again, which behave
+// the same as a missing bucket
+func (m *memoryStore) cleanupFull() {
+	ticker := time.NewTicker(memoryCleanupInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		m.mu.Lock()
+		for key, b := range m.buckets {
+			if now.After(b.full) {
+				delete(m.buckets, key)
+			}
+		}
+		m.mu.Unlock()
+	}
+}
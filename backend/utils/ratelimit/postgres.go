@@ -0,0 +1,63 @@
+package utils_ratelimit
+
+import (
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// postgresIdleAfter is how long a bucket goes unused before its row is removed.
+// A removed bucket starts full on its next use.
+const postgresIdleAfter = time.Hour
+
+// postgresStore keeps the buckets in the rate_limit_buckets table so they are
+// shared between backend replicas
+type postgresStore struct{}
+
+func (postgresStore) take(key string, limit Limit, now time.Time) (float64, bool, error) {
+	var tokens float64
+	var allowed bool
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Create the bucket full, then lock it so replicas take turns
+		fresh := models.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&fresh).Error; err != nil {
+			return err
+		}
+
+		var b models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&b).Error; err != nil {
+			return err
+		}
+
+		tokens = limit.refill(b.Tokens, b.UpdatedAt, now)
+		allowed = tokens >= 1
+		if allowed {
+			tokens--
+		}
+
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "updated_at": now}).Error
+	})
+
+	return tokens, allowed, err
+}
+
+// cleanupIdle periodically deletes buckets that have not been used for a while
+func (postgresStore) cleanupIdle() {
+	ticker := time.NewTicker(postgresIdleAfter / 4)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result := config.DB.Where("updated_at <= ?", time.Now().Add(-postgresIdleAfter)).Delete(&models.RateLimitBucket{})
+		if result.Error != nil {
+			log.Printf("⚠️ Failed to clean up rate limit buckets: %v", result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("🧹 Removed %d idle rate limit buckets", result.RowsAffected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package utils_ratelimit
+
+import (
+	"log"
+	"math"
+	"os"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"time"
+)
+
+// Limit is a token bucket: up to Burst requests at once, refilled at PerMinute
+// requests per minute
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// rate returns the tokens the bucket gains per second
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// refill returns the tokens of a bucket that held tokens at last, at now
+func (l Limit) refill(tokens float64, last, now time.Time) float64 {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens += elapsed * l.rate()
+	}
+	return math.Min(tokens, float64(l.Burst))
+}
+
+// Result is the outcome of taking a request from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // requests per minute
+	Remaining  int           // requests that can be made right away
+	RetryAfter time.Duration // until the next request is allowed; zero when allowed
+	ResetAfter time.Duration // until the bucket is full again
+}
+
+// store is a bucket backend. take refills the bucket of key, takes one token
+// when there is one and returns the tokens left.
+type store interface {
+	take(key string, limit Limit, now time.Time) (tokens float64, allowed bool, err error)
+}
+
+var active store = newMemoryStore()
+
+// Init configures the limiter from the environment. RATE_LIMIT_POSTGRES=true
+// keeps the buckets in Postgres so every backend replica shares them.
+func Init() {
+	if os.Getenv("RATE_LIMIT_POSTGRES") == "true" {
+		if err := config.DB.AutoMigrate(&models.RateLimitBucket{}); err != nil {
+			log.Printf("⚠️ Postgres rate limiter disabled: %v", err)
+			return
+		}
+		pg := postgresStore{}
+		active = pg
+		go pg.cleanupIdle()
+		log.Println("✅ Rate limiter: Postgres buckets")
+		return
+	}
+
+	log.Println("✅ Rate limiter: in-memory buckets")
+}
+
+// Take takes one request from the bucket of key. When the store fails the
+// request is allowed, so an outage of Postgres does not take the API down.
+func Take(key string, limit Limit) Result {
+	if limit.PerMinute <= 0 || limit.Burst <= 0 {
+		return Result{Allowed: true, Limit: limit.PerMinute}
+	}
+
+	tokens, allowed, err := active.take(key, limit, time.Now())
+	if err != nil {
+		log.Printf("⚠️ Rate limiter failed for %s: %v", key, err)
+		return Result{Allowed: true, Limit: limit.PerMinute, Remaining: limit.Burst}
+	}
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.PerMinute,
+		Remaining:  int(tokens),
+		ResetAfter: secondsOf((float64(limit.Burst) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		result.RetryAfter = secondsOf((1 - tokens) / limit.rate())
+	}
+	return result
+}
+
+func secondsOf(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,100 @@
+package utils_ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	limit := Limit{PerMinute: 60, Burst: 10}
+	last := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time passed", 3, 0, 3},
+		{"one second", 3, time.Second, 4},
+		{"half a second", 3, 500 * time.Millisecond, 3.5},
+		{"capped at the burst", 3, time.Hour, 10},
+		{"full stays full", 10, time.Second, 10},
+		{"clock went back", 3, -time.Minute, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limit.refill(tt.tokens, last, last.Add(tt.elapsed)); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("refill() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	limit := Limit{PerMinute: 30, Burst: 3} // a token every 2 seconds
+	start := time.Unix(1700000000, 0)
+
+	// The steps run in order against one bucket
+	steps := []struct {
+		name    string
+		key     string
+		at      time.Duration // since start
+		allowed bool
+		tokens  float64
+	}{
+		{"first request", "user:a", 0, true, 2},
+		{"second request", "user:a", 0, true, 1},
+		{"last token", "user:a", 0, true, 0},
+		{"bucket empty", "user:a", 0, false, 0},
+		{"other key has its own bucket", "user:b", 0, true, 2},
+		{"not refilled yet", "user:a", time.Second, false, 0.5},
+		{"refilled one token", "user:a", 2 * time.Second, true, 0},
+		{"refilled to the burst", "user:a", time.Hour, true, 2},
+	}
+
+	store := newMemoryStore()
+	for _, step := range steps {
+		tokens, allowed, err := store.take(step.key, limit, start.Add(step.at))
+		if err != nil {
+			t.Fatalf("%s: take() failed: %v", step.name, err)
+		}
+		if allowed != step.allowed || math.Abs(tokens-step.tokens) > 1e-9 {
+			t.Errorf("%s: take() = %v tokens, allowed %v, want %v tokens, allowed %v",
+				step.name, tokens, allowed, step.tokens, step.allowed)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	limit := Limit{PerMinute: 60, Burst: 2}
+	key := "test:" + time.Now().Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name      string
+		limit     Limit
+		allowed   bool
+		remaining int
+	}{
+		{"unlimited", Limit{}, true, 0},
+		{"first request", limit, true, 1},
+		{"second request", limit, true, 0},
+		{"limited", limit, false, 0},
+	}
+
+	for _, tt := range tests {
+		result := Take(key, tt.limit)
+		if result.Allowed != tt.allowed || result.Remaining != tt.remaining {
+			t.Errorf("%s: Take() = allowed %v, %d remaining, want allowed %v, %d remaining",
+				tt.name, result.Allowed, result.Remaining, tt.allowed, tt.remaining)
+		}
+		if result.Allowed != (result.RetryAfter == 0) {
+			t.Errorf("%s: Take() allowed %v with RetryAfter %v", tt.name, result.Allowed, result.RetryAfter)
+		}
+		if result.RetryAfter > time.Second || result.ResetAfter > 2*time.Second {
+			t.Errorf("%s: Take() RetryAfter %v, ResetAfter %v, longer than the refill", tt.name, result.RetryAfter, result.ResetAfter)
+		}
+	}
+}
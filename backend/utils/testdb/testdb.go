@@ -0,0 +1,40 @@
+// Package utils_testdb connects tests to a scratch Postgres database. It is
+// shared by the test files of every package that needs config.DB.
+package utils_testdb
+
+import (
+	"os"
+	"ripper-backend/config"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	connectOnce sync.Once
+	connectErr  error
+)
+
+// Connect connects config.DB to the database of TEST_DATABASE_URL, and skips
+// the test when it isn't set. The database is migrated like at startup and JWTs
+// are signed with a random key. setup runs once, after the first connection.
+// Test data is left behind, so point it at a scratch database.
+func Connect(t *testing.T, setup func() error) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	connectOnce.Do(func() {
+		os.Setenv("DATABASE_URL", url)
+		config.JWTSecret = []byte(uuid.NewString() + uuid.NewString())
+		config.ConnectDB()
+		if setup != nil {
+			connectErr = setup()
+		}
+	})
+	if connectErr != nil {
+		t.Fatalf("failed to set up the test database: %v", connectErr)
+	}
+}
@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"math"
+	"regexp"
+	utils_twitter "ripper-backend/utils/twitter"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TermCount is how often a keyword, hashtag, mention, emoji or language occurred
+type TermCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// SentimentSummary aggregates the lexicon sentiment of a set of tweets. Scores
+// range from -1 (negative) to 1 (positive).
+type SentimentSummary struct {
+	Average  float64 `json:"average"`
+	Positive int     `json:"positive"`
+	Neutral  int     `json:"neutral"`
+	Negative int     `json:"negative"`
+}
+
+// TweetAnalytics summarises what a set of replies or quotes say
+type TweetAnalytics struct {
+	TweetCount int              `json:"tweet_count"`
+	Keywords   []TermCount      `json:"keywords"`
+	Hashtags   []TermCount      `json:"hashtags"`
+	Mentions   []TermCount      `json:"mentions"`
+	Emoji      []TermCount      `json:"emoji"`
+	Languages  []TermCount      `json:"languages"`
+	Sentiment  SentimentSummary `json:"sentiment"`
+}
+
+var (
+	urlPattern            = regexp.MustCompile(`https?://\S+`)
+	hashtagPattern        = regexp.MustCompile(`#(\w+)`)
+	mentionPattern        = regexp.MustCompile(`@(\w{1,15})`)
+	leadingMentionPattern = regexp.MustCompile(`^(\s*@\w{1,15})+\s*`)
+)
+
+// sentimentThreshold separates positive and negative tweets from neutral ones
+const sentimentThreshold = 0.05
+
+// stopwords are common English words left out of the keywords
+var stopwords = toSet(`a about above after again against all am an and any are aren't as at be because been
+before being below between both but by can can't cannot could couldn't did didn't do does doesn't doing don't
+down during each few for from further get got had hadn't has hasn't have haven't having he he'd he'll he's her
+here here's hers herself him himself his how how's i i'd i'll i'm i've if in into is isn't it it's its itself
+just let's like me more most much my myself no nor not now of off on once only or other ought our ours ourselves
+out over own really same she she'd she'll she's should shouldn't so some such than that that's the their theirs
+them themselves then there there's these they they'd they'll they're they've this those through to too under
+until up us very was wasn't we we'd we'll we're we've were weren't what what's when when's where where's which
+while who who's whom why why's will with won't would wouldn't yeah yes you you'd you'll you're you've your yours
+yourself yourselves amp rt via im u ur lol`)
+
+// sentimentLexicon scores words from -3 (very negative) to 3 (very positive)
+var sentimentLexicon = map[string]float64{
+	"amazing": 3, "awesome": 3, "excellent": 3, "fantastic": 3, "incredible": 3, "outstanding": 3, "perfect": 3,
+	"wonderful": 3, "brilliant": 3, "superb": 3, "love": 3, "loved": 3, "loving": 3, "best": 3, "masterpiece": 3,
+	"great": 2, "good": 2, "nice": 2, "cool": 2, "happy": 2, "glad": 2, "excited": 2, "exciting": 2, "beautiful": 2,
+	"congrats": 2, "congratulations": 2, "thanks": 2, "thank": 2, "impressive": 2, "enjoy": 2, "enjoyed": 2,
+	"fun": 2, "win": 2, "winning": 2, "won": 2, "wow": 2, "yay": 2, "helpful": 2, "recommend": 2, "proud": 2,
+	"fire": 2, "bullish": 2, "legend": 2, "goat": 2, "finally": 1, "like": 1, "liked": 1, "interesting": 1,
+	"agree": 1, "support": 1, "hope": 1, "hopefully": 1, "easy": 1, "fair": 1, "fine": 1, "ok": 1, "okay": 1,
+	"useful": 1, "solid": 1, "smooth": 1, "fast": 1, "welcome": 1, "better": 1, "works": 1, "worth": 1,
+	"terrible": -3, "awful": -3, "horrible": -3, "worst": -3, "hate": -3, "hated": -3, "disgusting": -3,
+	"scam": -3, "fraud": -3, "pathetic": -3, "garbage": -3, "trash": -3, "useless": -3, "disaster": -3,
+	"bad": -2, "sad": -2, "angry": -2, "annoying": -2, "annoyed": -2, "disappointed": -2, "disappointing": -2,
+	"broken": -2, "fail": -2, "failed": -2, "fails": -2, "failure": -2, "wrong": -2, "poor": -2, "lame": -2,
+	"boring": -2, "stupid": -2, "ugly": -2, "fake": -2, "lies": -2, "liar": -2, "ridiculous": -2, "bug": -2,
+	"bugs": -2, "crash": -2, "crashes": -2, "ripoff": -2, "bearish": -2, "rug": -2, "waste": -2, "sucks": -2,
+	"worse": -2, "problem": -1, "problems": -1, "issue": -1, "issues": -1, "slow": -1, "expensive": -1,
+	"confusing": -1, "meh": -1, "doubt": -1, "unfortunately": -1, "sorry": -1, "miss": -1, "missing": -1,
+	"concerned": -1, "worried": -1, "hard": -1, "difficult": -1, "delay": -1, "delayed": -1,
+	"👍": 2, "🔥": 2, "❤": 3, "😍": 3, "🥰": 3, "😊": 2, "😀": 2, "😃": 2, "😄": 2, "😁": 2, "😂": 1, "🤣": 1,
+	"🙌": 2, "👏": 2, "🎉": 2, "🚀": 2, "💯": 2, "✅": 1, "🙏": 1, "💪": 2, "😎": 1,
+	"👎": -2, "😡": -3, "🤬": -3, "😠": -2, "😢": -2, "😭": -2, "😞": -2, "😒": -1, "🙄": -1, "💩": -2,
+	"🤡": -2, "😤": -1, "🤮": -3,
+}
+
+// negators flip the sentiment of the next scored word
+var negators = toSet(`not no never don't doesn't didn't isn't wasn't aren't won't can't cannot couldn't shouldn't wouldn't nothing`)
+
+func toSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// isEmoji reports whether r is a pictographic emoji (not a modifier or joiner)
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F3FB && r <= 0x1F3FF: // skin tone modifiers
+		return false
+	case r >= 0x1F300 && r <= 0x1FAFF,
+		r >= 0x2600 && r <= 0x27BF,
+		r >= 0x1F000 && r <= 0x1F2FF,
+		r == 0x2B50, r == 0x2B55, r == 0x2764:
+		return true
+	}
+	return false
+}
+
+// tokenize lowercases text and splits it into words, dropping URLs, hashtags and mentions
+func tokenize(text string) []string {
+	text = urlPattern.ReplaceAllString(text, " ")
+	text = hashtagPattern.ReplaceAllString(text, " ")
+	text = mentionPattern.ReplaceAllString(text, " ")
+	text = strings.ReplaceAll(strings.ToLower(text), "’", "'")
+
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'')
+	})
+}
+
+// TweetSentiment scores a text from -1 to 1 with the lexicon, including emoji.
+// A negator flips the next scored word within three words.
+func TweetSentiment(text string) float64 {
+	sum := 0.0
+	negateFor := 0
+	for _, word := range tokenize(text) {
+		word = strings.Trim(word, "'")
+		if negators[word] {
+			negateFor = 3
+			continue
+		}
+		if score, ok := sentimentLexicon[word]; ok {
+			if negateFor > 0 {
+				score = -score * 0.75
+				negateFor = 0
+			}
+			sum += score
+		}
+		if negateFor > 0 {
+			negateFor--
+		}
+	}
+	for _, r := range text {
+		if score, ok := sentimentLexicon[string(r)]; ok {
+			sum += score
+		}
+	}
+
+	// Normalise like VADER's compound score
+	return sum / math.Sqrt(sum*sum+15)
+}
+
+// AnalyzeTweets computes keyword, hashtag, mention, emoji, language and sentiment
+// statistics over tweets, keeping the top entries of each list. Leading @mentions
+// that X adds to replies are not counted as mentions.
+func AnalyzeTweets(tweets []*utils_twitter.Tweet, top int) TweetAnalytics {
+	keywords := map[string]int{}
+	hashtags := map[string]int{}
+	mentions := map[string]int{}
+	emoji := map[string]int{}
+	languages := map[string]int{}
+	analytics := TweetAnalytics{}
+	sentimentSum := 0.0
+
+	for _, t := range tweets {
+		if t == nil {
+			continue
+		}
+		analytics.TweetCount++
+		text := leadingMentionPattern.ReplaceAllString(t.Text, "")
+
+		for _, m := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+			hashtags["#"+strings.ToLower(m[1])]++
+		}
+		for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+			mentions["@"+strings.ToLower(m[1])]++
+		}
+		for _, word := range tokenize(text) {
+			word = strings.Trim(word, "'")
+			if len([]rune(word)) < 3 || stopwords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+				continue
+			}
+			keywords[word]++
+		}
+		for _, r := range text {
+			if isEmoji(r) {
+				emoji[string(r)]++
+			}
+		}
+
+		lang := t.Lang
+		if lang == "" {
+			lang = "und"
+		}
+		languages[lang]++
+
+		score := TweetSentiment(text)
+		sentimentSum += score
+		switch {
+		case score > sentimentThreshold:
+			analytics.Sentiment.Positive++
+		case score < -sentimentThreshold:
+			analytics.Sentiment.Negative++
+		default:
+			analytics.Sentiment.Neutral++
+		}
+	}
+
+	if analytics.TweetCount > 0 {
+		analytics.Sentiment.Average = math.Round(sentimentSum/float64(analytics.TweetCount)*1000) / 1000
+	}
+	analytics.Keywords = topTerms(keywords, top)
+	analytics.Hashtags = topTerms(hashtags, top)
+	analytics.Mentions = topTerms(mentions, top)
+	analytics.Emoji = topTerms(emoji, top)
+	analytics.Languages = topTerms(languages, top)
+	return analytics
+}
+
+// topTerms returns the n most frequent terms, ties broken alphabetically
+func topTerms(counts map[string]int, n int) []TermCount {
+	terms := make([]TermCount, 0, len(counts))
+	for term, count := range counts {
+		terms = append(terms, TermCount{Term: term, Count: count})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	utils_twitter "ripper-backend/utils/twitter"
+	"testing"
+)
+
+// compound is the normalised score of a lexicon sum, as TweetSentiment returns it
+func compound(sum float64) float64 {
+	return sum / math.Sqrt(sum*sum+15)
+}
+
+func TestTweetSentiment(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		sum  float64
+	}{
+		{"empty", "", 0},
+		{"no scored words", "the launch is on monday", 0},
+		{"positive word", "This is great", 2},
+		{"negative word", "what a disaster", -3},
+		{"mixed words", "great idea but slow", 1},
+		{"case insensitive", "AMAZING", 3},
+		{"negated", "not great", -1.5},
+		{"negated within three words", "not really very great", -1.5},
+		{"negation runs out", "not one two three great", 2},
+		{"negation flips one word only", "not great, great", 0.5},
+		{"curly apostrophe negator", "don’t like it", -0.75},
+		{"emoji", "🔥🔥", 4},
+		{"words and emoji", "terrible 😡", -6},
+		{"urls and hashtags ignored", "https://example.com/great #great @great", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TweetSentiment(tt.text)
+			if want := compound(tt.sum); math.Abs(got-want) > 1e-9 {
+				t.Errorf("TweetSentiment(%q) = %v, want %v", tt.text, got, want)
+			}
+			if got < -1 || got > 1 {
+				t.Errorf("TweetSentiment(%q) = %v, out of [-1, 1]", tt.text, got)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTweets(t *testing.T) {
+	replies := []*utils_twitter.Tweet{
+		{ID: "1", Text: "@brand @other Love the new #Feature, love it! 🔥🔥", Lang: "en"},
+		{ID: "2", Text: "@brand this is not good, cc @Support #feature"},
+		nil,
+		{ID: "3", Text: "just a reply", Lang: "en"},
+	}
+	average := math.Round((compound(10)+compound(-1.5)+0)/3*1000) / 1000
+
+	tests := []struct {
+		name   string
+		tweets []*utils_twitter.Tweet
+		top    int
+		want   TweetAnalytics
+	}{
+		{
+			name:   "no tweets",
+			tweets: nil,
+			top:    10,
+			want: TweetAnalytics{
+				Keywords:  []TermCount{},
+				Hashtags:  []TermCount{},
+				Mentions:  []TermCount{},
+				Emoji:     []TermCount{},
+				Languages: []TermCount{},
+			},
+		},
+		{
+			name:   "replies",
+			tweets: replies,
+			top:    10,
+			want: TweetAnalytics{
+				TweetCount: 3,
+				Keywords:   []TermCount{{"love", 2}, {"good", 1}, {"new", 1}, {"reply", 1}},
+				Hashtags:   []TermCount{{"#feature", 2}},
+				Mentions:   []TermCount{{"@support", 1}},
+				Emoji:      []TermCount{{"🔥", 2}},
+				Languages:  []TermCount{{"en", 2}, {"und", 1}},
+				Sentiment:  SentimentSummary{Average: average, Positive: 1, Neutral: 1, Negative: 1},
+			},
+		},
+		{
+			name:   "top entries only",
+			tweets: replies,
+			top:    2,
+			want: TweetAnalytics{
+				TweetCount: 3,
+				Keywords:   []TermCount{{"love", 2}, {"good", 1}},
+				Hashtags:   []TermCount{{"#feature", 2}},
+				Mentions:   []TermCount{{"@support", 1}},
+				Emoji:      []TermCount{{"🔥", 2}},
+				Languages:  []TermCount{{"en", 2}, {"und", 1}},
+				Sentiment:  SentimentSummary{Average: average, Positive: 1, Neutral: 1, Negative: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnalyzeTweets(tt.tweets, tt.top); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnalyzeTweets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
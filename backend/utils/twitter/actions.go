@@ -0,0 +1,230 @@
+package utils_twitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Write operations tracked separately for rate limiting
+const (
+	OpCreateTweet    = "CreateTweet"
+	OpFavoriteTweet  = "FavoriteTweet"
+	OpUnfavorite     = "UnfavoriteTweet"
+	OpCreateRetweet  = "CreateRetweet"
+	OpDeleteRetweet  = "DeleteRetweet"
+	OpCreateBookmark = "CreateBookmark"
+	OpDeleteBookmark = "DeleteBookmark"
+	OpFollow         = "friendships/create"
+	OpUnfollow       = "friendships/destroy"
+	OpMediaUpload    = "media/upload"
+)
+
+// graphQLMutationIDs are the query IDs of the web client's write mutations
+var graphQLMutationIDs = map[string]string{
+	OpCreateTweet:    "oB-5XsHNAbjvARJEc8CZFw",
+	OpFavoriteTweet:  "lI07N6Otwv1PhnEgXILM7A",
+	OpUnfavorite:     "ZYKSe-w7KEslx3JhSIk5LA",
+	OpCreateRetweet:  "ojPdsZsimiJrUGLR1sjUtA",
+	OpDeleteRetweet:  "iQtK4dl5hBmXewYZuEOKVw",
+	OpCreateBookmark: "aoDbu3RHznuiSkQ9aNM67Q",
+	OpDeleteBookmark: "Wlmlj2-xzyS1GN3a6cj-mQ",
+}
+
+// createTweetFeatures are the feature flags the web client sends with CreateTweet
+var createTweetFeatures = map[string]interface{}{
+	"premium_content_api_read_enabled":                           false,
+	"communities_web_enable_tweet_community_results_fetch":       true,
+	"c9s_tweet_anatomy_moderator_badge_enabled":                  true,
+	"responsive_web_grok_analyze_button_fetch_trends_enabled":    false,
+	"responsive_web_grok_analyze_post_followups_enabled":         true,
+	"responsive_web_jetfuel_frame":                               true,
+	"responsive_web_grok_share_attachment_enabled":               true,
+	"responsive_web_edit_tweet_api_enabled":                      true,
+	"graphql_is_translatable_rweb_tweet_is_translatable_enabled": true,
+	"view_counts_everywhere_api_enabled":                         true,
+	"longform_notetweets_consumption_enabled":                    true,
+	"responsive_web_twitter_article_tweet_consumption_enabled":   true,
+	"tweet_awards_web_tipping_enabled":                           false,
+	"responsive_web_grok_show_grok_translated_post":              false,
+	"responsive_web_grok_analysis_button_from_backend":           true,
+	"creator_subscriptions_quote_tweet_preview_enabled":          false,
+	"longform_notetweets_rich_text_read_enabled":                 true,
+	"longform_notetweets_inline_media_enabled":                   true,
+	"payments_enabled": false,
+	"profile_label_improvements_pcf_label_in_post_enabled":                    true,
+	"rweb_tipjar_consumption_enabled":                                         true,
+	"verified_phone_label_enabled":                                            true,
+	"articles_preview_enabled":                                                true,
+	"responsive_web_grok_community_note_auto_translation_is_enabled":          false,
+	"responsive_web_graphql_skip_user_profile_image_extensions_enabled":       false,
+	"freedom_of_speech_not_reach_fetch_enabled":                               true,
+	"standardized_nudges_misinfo":                                             true,
+	"tweet_with_visibility_results_prefer_gql_limited_actions_policy_enabled": true,
+	"responsive_web_grok_image_annotation_enabled":                            true,
+	"responsive_web_grok_imagine_annotation_enabled":                          true,
+	"responsive_web_graphql_timeline_navigation_enabled":                      true,
+	"responsive_web_enhance_cards_enabled":                                    false,
+}
+
+// TweetOptions are the optional parts of a new tweet
+type TweetOptions struct {
+	ReplyToID string   // tweet being replied to
+	QuoteURL  string   // tweet being quoted
+	MediaIDs  []string // uploaded with UploadMedia
+}
+
+// graphQLMutation posts a GraphQL mutation of the web client and returns its data.
+// Errors that Twitter reports in a 200 response are classified like HTTP errors.
+func (s *Session) graphQLMutation(operation string, variables, features map[string]interface{}) (map[string]interface{}, error) {
+	if !s.loggedIn {
+		return nil, fmt.Errorf("not logged in")
+	}
+
+	queryID := graphQLMutationIDs[operation]
+	payload := map[string]interface{}{"variables": variables, "queryId": queryID}
+	if features != nil {
+		payload["features"] = features
+	}
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://x.com/i/api/graphql/%s/%s", queryID, operation), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	respBody, err := s.do(operation, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse response: %w", operation, err)
+	}
+
+	if len(result.Errors) > 0 {
+		e := result.Errors[0]
+		return nil, &APIError{Kind: errorCodeKind(e.Code), Operation: operation, StatusCode: http.StatusOK, Status: "200 OK", Message: e.Message}
+	}
+
+	return result.Data, nil
+}
+
+// CreateTweet posts a tweet, reply or quote and returns the new tweet's ID
+func (s *Session) CreateTweet(text string, opts TweetOptions) (string, error) {
+	mediaEntities := []map[string]interface{}{}
+	for _, id := range opts.MediaIDs {
+		mediaEntities = append(mediaEntities, map[string]interface{}{"media_id": id, "tagged_users": []string{}})
+	}
+
+	variables := map[string]interface{}{
+		"tweet_text":              text,
+		"dark_request":            false,
+		"media":                   map[string]interface{}{"media_entities": mediaEntities, "possibly_sensitive": false},
+		"semantic_annotation_ids": []string{},
+	}
+	if opts.ReplyToID != "" {
+		variables["reply"] = map[string]interface{}{"in_reply_to_tweet_id": opts.ReplyToID, "exclude_reply_user_ids": []string{}}
+	}
+	if opts.QuoteURL != "" {
+		variables["attachment_url"] = opts.QuoteURL
+	}
+
+	data, err := s.graphQLMutation(OpCreateTweet, variables, createTweetFeatures)
+	if err != nil {
+		return "", err
+	}
+
+	if createTweet, ok := data["create_tweet"].(map[string]interface{}); ok {
+		if tweetResults, ok := createTweet["tweet_results"].(map[string]interface{}); ok {
+			if result, ok := tweetResults["result"].(map[string]interface{}); ok {
+				if id, ok := result["rest_id"].(string); ok {
+					return id, nil
+				}
+			}
+		}
+	}
+	return "", &APIError{Operation: OpCreateTweet, StatusCode: http.StatusOK, Status: "200 OK", Message: "tweet was not created"}
+}
+
+// tweetMutation runs a like, retweet or bookmark mutation on a tweet
+func (s *Session) tweetMutation(operation, tweetID string) error {
+	variables := map[string]interface{}{"tweet_id": tweetID}
+	switch operation {
+	case OpCreateRetweet:
+		variables["dark_request"] = false
+	case OpDeleteRetweet:
+		variables = map[string]interface{}{"source_tweet_id": tweetID, "dark_request": false}
+	}
+	_, err := s.graphQLMutation(operation, variables, nil)
+	return err
+}
+
+// LikeTweet likes a tweet
+func (s *Session) LikeTweet(tweetID string) error {
+	return s.tweetMutation(OpFavoriteTweet, tweetID)
+}
+
+// UnlikeTweet removes a like
+func (s *Session) UnlikeTweet(tweetID string) error {
+	return s.tweetMutation(OpUnfavorite, tweetID)
+}
+
+// Retweet retweets a tweet
+func (s *Session) Retweet(tweetID string) error {
+	return s.tweetMutation(OpCreateRetweet, tweetID)
+}
+
+// Unretweet removes a retweet
+func (s *Session) Unretweet(tweetID string) error {
+	return s.tweetMutation(OpDeleteRetweet, tweetID)
+}
+
+// BookmarkTweet bookmarks a tweet
+func (s *Session) BookmarkTweet(tweetID string) error {
+	return s.tweetMutation(OpCreateBookmark, tweetID)
+}
+
+// UnbookmarkTweet removes a bookmark
+func (s *Session) UnbookmarkTweet(tweetID string) error {
+	return s.tweetMutation(OpDeleteBookmark, tweetID)
+}
+
+// friendship follows or unfollows a user by username
+func (s *Session) friendship(operation, username string) error {
+	if !s.loggedIn {
+		return fmt.Errorf("not logged in")
+	}
+
+	form := url.Values{}
+	form.Set("screen_name", strings.TrimPrefix(username, "@"))
+	req, err := http.NewRequest("POST", "https://x.com/i/api/1.1/"+operation+".json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	_, err = s.do(operation, req)
+	return err
+}
+
+// Follow follows a user
+func (s *Session) Follow(username string) error {
+	return s.friendship(OpFollow, username)
+}
+
+// Unfollow unfollows a user
+func (s *Session) Unfollow(username string) error {
+	return s.friendship(OpUnfollow, username)
+}
@@ -19,18 +19,52 @@ const (
 	loginURL = "https://api.twitter.com/1.1/onboarding/task.json"
 	// Twitter Web App Bearer Token (updated Nov 2025)
 	bearerToken2 = "AAAAAAAAAAAAAAAAAAAAANRILgAAAAAAnNwIzUejRCOuH5E6I8xnZz4puTs%3D1Zv7ttfk8LF81IUq16cHjhLTvJu4FA33AGWWjCpTnA"
-	userAgent    = "Mozilla/5.0 (Linux; Android 6.0; Nexus 5 Build/MRA58N) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Mobile Safari/537.36"
 	tokensDir    = "tokens/twitter"
 )
 
 var (
-	globalClient     *http.Client
-	globalGuestToken string
-	isLoggedIn       bool
-	loginInProgress  = make(map[string]bool)
-	loginMutex       sync.RWMutex
+	loginInProgress = make(map[string]bool)
+	loginMutex      sync.RWMutex
 )
 
+// Session holds the HTTP client and tokens of one logged-in Twitter account.
+// Each account gets its own Session so several accounts can be used at once.
+type Session struct {
+	UserID           string
+	TwitterAccountID string
+
+	client     *http.Client
+	guestToken string
+	loggedIn   bool
+	profile    *ClientProfile
+
+	pagesMu   sync.Mutex
+	pages     map[string]int  // successful responses per operation, for billing
+	truncated map[string]bool // operations whose scrape stopped before the last page
+}
+
+// newSession creates an empty session whose traffic goes through proxyURL, if set
+func newSession(userID, twitterAccountID, proxyURL string) (*Session, error) {
+	transport, err := sessionTransport(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	jar, _ := cookiejar.New(nil)
+	return &Session{
+		UserID:           userID,
+		TwitterAccountID: twitterAccountID,
+		client:           &http.Client{Jar: jar, Transport: transport, Timeout: 60 * time.Second},
+		profile:          NewClientProfile(twitterAccountID),
+		pages:            map[string]int{},
+		truncated:        map[string]bool{},
+	}, nil
+}
+
+func tokensFileFor(userID, twitterAccountID string) string {
+	return fmt.Sprintf("%s/tokens-%s-%s.json", tokensDir, userID, twitterAccountID)
+}
+
 type flow struct {
 	Errors []struct {
 		Code    int    `json:"code"`
@@ -50,20 +84,22 @@ type SessionData struct {
 	CSRFToken   string                 `json:"csrf_token"`
 	AuthToken   string                 `json:"auth_token"`
 	SessionData map[string]interface{} `json:"session_data"`
+	Profile     *ClientProfile         `json:"profile,omitempty"`
 	LoginTime   time.Time              `json:"login_time"`
 }
 
-func SaveTokensForAccount(userID, twitterAccountID string) error {
-	if globalClient == nil {
+// Save writes the session cookies and tokens to the account's token file
+func (s *Session) Save() error {
+	if s == nil || s.client == nil {
 		return fmt.Errorf("no session to save")
 	}
 
 	cookies := []*http.Cookie{}
 	var csrfToken, authToken string
 
-	if globalClient.Jar != nil {
+	if s.client.Jar != nil {
 		u, _ := url.Parse("https://twitter.com")
-		cookies = globalClient.Jar.Cookies(u)
+		cookies = s.client.Jar.Cookies(u)
 
 		for _, cookie := range cookies {
 			switch cookie.Name {
@@ -76,70 +112,81 @@ func SaveTokensForAccount(userID, twitterAccountID string) error {
 	}
 
 	session := SessionData{
-		GuestToken:  globalGuestToken,
+		GuestToken:  s.guestToken,
 		Cookies:     cookies,
 		BearerToken: bearerToken2,
 		CSRFToken:   csrfToken,
 		AuthToken:   authToken,
+		Profile:     s.profile,
 		LoginTime:   time.Now(),
 		SessionData: map[string]interface{}{
-			"user_agent": userAgent,
+			"user_agent": s.profile.UserAgent,
 			"login_url":  loginURL,
 		},
 	}
 
 	os.MkdirAll(tokensDir, 0755)
-	tokensFile := fmt.Sprintf("%s/tokens-%s-%s.json", tokensDir, userID, twitterAccountID)
 	data, _ := json.MarshalIndent(session, "", "  ")
-	return os.WriteFile(tokensFile, data, 0644)
+	return os.WriteFile(tokensFileFor(s.UserID, s.TwitterAccountID), data, 0644)
 }
 
-func LoadTokensForAccount(userID, twitterAccountID string) error {
-	tokensFile := fmt.Sprintf("%s/tokens-%s-%s.json", tokensDir, userID, twitterAccountID)
-	data, err := os.ReadFile(tokensFile)
+// LoadSession restores the saved session of a Twitter account from its token file.
+// proxyURL is the account's proxy; pass "" to connect directly.
+func LoadSession(userID, twitterAccountID, proxyURL string) (*Session, error) {
+	data, err := os.ReadFile(tokensFileFor(userID, twitterAccountID))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var session SessionData
 	if err := json.Unmarshal(data, &session); err != nil {
-		return err
+		return nil, err
 	}
 
 	if time.Since(session.LoginTime) > 24*time.Hour {
-		return fmt.Errorf("session expired (older than 24 hours)")
+		return nil, fmt.Errorf("session expired (older than 24 hours)")
 	}
 
-	jar, _ := cookiejar.New(nil)
-	globalClient = &http.Client{Jar: jar, Timeout: 60 * time.Second}
+	s, err := newSession(userID, twitterAccountID, proxyURL)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(session.Cookies) > 0 {
 		u, _ := url.Parse("https://twitter.com")
-		globalClient.Jar.SetCookies(u, session.Cookies)
+		s.client.Jar.SetCookies(u, session.Cookies)
 
 		u2, _ := url.Parse("https://x.com")
-		globalClient.Jar.SetCookies(u2, session.Cookies)
+		s.client.Jar.SetCookies(u2, session.Cookies)
 	}
 
-	globalGuestToken = session.GuestToken
-	isLoggedIn = true
+	s.guestToken = session.GuestToken
+	s.loggedIn = true
+	if session.Profile != nil {
+		s.profile = session.Profile
+		// Sessions saved before profiles had a timezone take the one the account
+		// would have been given, which matches its locale
+		if s.profile.Timezone == "" {
+			s.profile.Timezone = NewClientProfile(twitterAccountID).Timezone
+		}
+	}
 
-	fmt.Printf("Loaded session for account %s-%s (saved %v ago)\n", userID, twitterAccountID, time.Since(session.LoginTime).Round(time.Minute))
-	return nil
+	return s, nil
 }
 
-func ValidateSession() bool {
+// Validate checks that the session is still accepted by Twitter
+func (s *Session) Validate() bool {
 	req, _ := http.NewRequest("GET", "https://twitter.com/i/api/graphql/ldqoq5MmFHN1FhMGvzC9Jg/TweetDetail", nil)
 	req.Header.Set("Authorization", "Bearer "+bearerToken2)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("X-Guest-Token", globalGuestToken)
+	req.Header.Set("X-Guest-Token", s.guestToken)
+	s.applyProfile(req)
 
 	query := url.Values{}
 	query.Set("variables", `{"focalTweetId":"1"}`)
 	query.Set("features", `{}`)
 	req.URL.RawQuery = query.Encode()
 
-	resp, err := globalClient.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return false
 	}
@@ -153,11 +200,12 @@ func randomDelay() {
 	time.Sleep(delay)
 }
 
-func getGuestToken(client *http.Client) (string, error) {
+func (s *Session) getGuestToken() (string, error) {
 	req, _ := http.NewRequest("POST", "https://api.twitter.com/1.1/guest/activate.json", nil)
 	req.Header.Set("Authorization", "Bearer "+bearerToken2)
+	s.applyProfile(req)
 
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -170,26 +218,25 @@ func getGuestToken(client *http.Client) (string, error) {
 	return guestToken, nil
 }
 
-func getFlowToken(client *http.Client, guestToken string, data map[string]interface{}) (string, error) {
+func (s *Session) getFlowToken(guestToken string, data map[string]interface{}) (string, error) {
 	jsonData, _ := json.Marshal(data)
 	req, _ := http.NewRequest("POST", loginURL, bytes.NewReader(jsonData))
 
 	req.Header.Set("Authorization", "Bearer "+bearerToken2)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("X-Guest-Token", guestToken)
 	req.Header.Set("X-Twitter-Auth-Type", "OAuth2Client")
 	req.Header.Set("X-Twitter-Active-User", "yes")
-	req.Header.Set("X-Twitter-Client-Language", "en")
+	s.applyProfile(req)
 
-	for _, cookie := range client.Jar.Cookies(req.URL) {
+	for _, cookie := range s.client.Jar.Cookies(req.URL) {
 		if cookie.Name == "ct0" {
 			req.Header.Set("X-CSRF-Token", cookie.Value)
 			break
 		}
 	}
 
-	resp, err := client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -206,18 +253,20 @@ func getFlowToken(client *http.Client, guestToken string, data map[string]interf
 	return info.FlowToken, nil
 }
 
-func LoginAndSaveTokens(username, password, userID, twitterAccountID string) error {
+func LoginAndSaveTokens(username, password, userID, twitterAccountID, proxyURL string) error {
 	fmt.Printf("⚠️  WARNING: Using username/password login. This may trigger account restrictions.\n")
 	fmt.Printf("Starting Twitter login for user: %s\n", username)
 
-	jar, _ := cookiejar.New(nil)
-	globalClient = &http.Client{Jar: jar, Timeout: 60 * time.Second}
+	s, err := newSession(userID, twitterAccountID, proxyURL)
+	if err != nil {
+		return err
+	}
 
-	guestToken, err := getGuestToken(globalClient)
+	guestToken, err := s.getGuestToken()
 	if err != nil {
 		return fmt.Errorf("error getting guest token: %v", err)
 	}
-	globalGuestToken = guestToken
+	s.guestToken = guestToken
 
 	randomDelay()
 
@@ -230,7 +279,7 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 			},
 		},
 	}
-	flowToken, err := getFlowToken(globalClient, guestToken, data)
+	flowToken, err := s.getFlowToken(guestToken, data)
 	if err != nil {
 		return fmt.Errorf("error in flow start: %v", err)
 	}
@@ -246,7 +295,7 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 			},
 		},
 	}
-	flowToken, err = getFlowToken(globalClient, guestToken, data)
+	flowToken, err = s.getFlowToken(guestToken, data)
 	if err != nil {
 		return fmt.Errorf("error in instrumentation: %v", err)
 	}
@@ -270,7 +319,7 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 			},
 		},
 	}
-	flowToken, err = getFlowToken(globalClient, guestToken, data)
+	flowToken, err = s.getFlowToken(guestToken, data)
 	if err != nil {
 		return fmt.Errorf("error submitting username: %v", err)
 	}
@@ -286,7 +335,7 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 			},
 		},
 	}
-	flowToken, err = getFlowToken(globalClient, guestToken, data)
+	flowToken, err = s.getFlowToken(guestToken, data)
 	if err != nil {
 		return fmt.Errorf("error submitting password: %v", err)
 	}
@@ -302,7 +351,7 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 			},
 		},
 	}
-	_, err = getFlowToken(globalClient, guestToken, data)
+	_, err = s.getFlowToken(guestToken, data)
 	if err != nil {
 		if strings.Contains(err.Error(), "LoginAcid") || strings.Contains(err.Error(), "LoginTwoFactorAuthChallenge") {
 			return fmt.Errorf("2FA/Email confirmation required: %v", err)
@@ -311,10 +360,10 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 		}
 	}
 
-	isLoggedIn = true
+	s.loggedIn = true
 	fmt.Println("Login successful!")
 
-	if err := SaveTokensForAccount(userID, twitterAccountID); err != nil {
+	if err := s.Save(); err != nil {
 		fmt.Printf("Warning: Could not save tokens: %v\n", err)
 	} else {
 		fmt.Printf("Session saved for account %s-%s\n", userID, twitterAccountID)
@@ -323,31 +372,46 @@ func LoginAndSaveTokens(username, password, userID, twitterAccountID string) err
 	return nil
 }
 
-func StartLoginAsync(username, password, userID, twitterAccountID string) {
+// StartLoginAsync logs an account in on a background goroutine and calls done,
+// if set, with the outcome. It returns false without starting when a login of
+// the account is already running.
+func StartLoginAsync(username, password, userID, twitterAccountID, proxyURL string, done func(error)) bool {
 	loginMutex.Lock()
-	if loginInProgress[userID] {
+	if loginInProgress[twitterAccountID] {
 		loginMutex.Unlock()
-		return
+		return false
 	}
-	loginInProgress[userID] = true
+	loginInProgress[twitterAccountID] = true
 	loginMutex.Unlock()
 
 	go func() {
-		defer func() {
-			loginMutex.Lock()
-			delete(loginInProgress, userID)
-			loginMutex.Unlock()
-		}()
-
-		err := LoginAndSaveTokens(username, password, userID, twitterAccountID)
+		err := LoginAndSaveTokens(username, password, userID, twitterAccountID, proxyURL)
 		if err != nil {
-			fmt.Printf("Background login failed for user %s: %v\n", userID, err)
+			fmt.Printf("Background login failed for account %s: %v\n", twitterAccountID, err)
+		}
+
+		loginMutex.Lock()
+		delete(loginInProgress, twitterAccountID)
+		loginMutex.Unlock()
+
+		if done != nil {
+			done(err)
 		}
 	}()
+	return true
 }
 
-func IsLoginInProgress(userID string) bool {
+// IsLoginInProgress reports whether a login of the account is running
+func IsLoginInProgress(twitterAccountID string) bool {
 	loginMutex.RLock()
 	defer loginMutex.RUnlock()
-	return loginInProgress[userID]
+	return loginInProgress[twitterAccountID]
+}
+
+// DeleteSession removes the token file of an account. A missing file is not an error.
+func DeleteSession(userID, twitterAccountID string) error {
+	if err := os.Remove(tokensFileFor(userID, twitterAccountID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,267 @@
+package utils_twitter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxRequestRetries is how many times a 429/5xx or network failure is retried
+	maxRequestRetries = 4
+	baseRetryBackoff  = 2 * time.Second
+	maxRetryBackoff   = 60 * time.Second
+	// maxRetryWait is the longest one call spends waiting between attempts in
+	// total, since it runs inside an HTTP handler. A wait that would go past it
+	// returns the last error, ErrRateLimited for a 429, so the account pool can
+	// move on.
+	maxRetryWait = 20 * time.Second
+)
+
+// Errors returned by the request executor. Use errors.Is to check the kind of an *APIError.
+var (
+	ErrRateLimited  = errors.New("rate limited by Twitter")
+	ErrUnauthorized = errors.New("Twitter session is not authorized")
+	ErrNotFound     = errors.New("not found on Twitter")
+	ErrSuspended    = errors.New("Twitter account is suspended or locked")
+	// ErrConflict means a write is already in effect, e.g. liking a liked tweet
+	ErrConflict = errors.New("already done on Twitter")
+)
+
+// Twitter error codes with a kind of their own
+const (
+	twitterCodeSuspended        = 64  // the logged-in account is suspended
+	twitterCodeNoStatus         = 144 // the tweet does not exist
+	twitterCodeAlreadyFavorited = 139
+	twitterCodeDailyLimit       = 185 // daily tweet or action limit reached
+	twitterCodeDuplicate        = 187
+	twitterCodeLocked           = 326 // the logged-in account is locked
+	twitterCodeAlreadyRetweeted = 327
+)
+
+// errorCodeKind maps a Twitter error code to an error kind, or nil
+func errorCodeKind(code int) error {
+	switch code {
+	case twitterCodeSuspended, twitterCodeLocked:
+		return ErrSuspended
+	case twitterCodeDailyLimit:
+		return ErrRateLimited
+	case twitterCodeNoStatus:
+		return ErrNotFound
+	case twitterCodeAlreadyFavorited, twitterCodeAlreadyRetweeted, twitterCodeDuplicate:
+		return ErrConflict
+	}
+	return nil
+}
+
+// APIError is a classified error response from Twitter
+type APIError struct {
+	Kind       error // ErrRateLimited, ErrUnauthorized, ErrNotFound, ErrSuspended, ErrConflict or nil
+	Operation  string
+	StatusCode int
+	Status     string
+	Message    string
+	Reset      time.Time // when a rate limit resets, if known
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s: API error: %s", e.Operation, e.Status)
+	if e.Kind != nil {
+		msg = fmt.Sprintf("%s: %v (%s)", e.Operation, e.Kind, e.Status)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// applyDefaultHeaders sets the headers every authenticated web API call needs,
+// including the browser identity of the session's client profile
+func (s *Session) applyDefaultHeaders(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+bearerToken2)
+	if s.guestToken != "" {
+		req.Header.Set("X-Guest-Token", s.guestToken)
+	}
+	req.Header.Set("X-Twitter-Active-User", "yes")
+	req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
+	s.applyProfile(req)
+
+	for _, cookie := range s.client.Jar.Cookies(req.URL) {
+		if cookie.Name == "ct0" {
+			req.Header.Set("X-CSRF-Token", cookie.Value)
+			break
+		}
+	}
+}
+
+// countPage records a successful response of operation
+func (s *Session) countPage(operation string) {
+	s.pagesMu.Lock()
+	s.pages[operation]++
+	s.pagesMu.Unlock()
+}
+
+// markTruncated records that a paginated scrape of operation stopped with pages
+// left, at its page cap or on a failed page
+func (s *Session) markTruncated(operation string) {
+	s.pagesMu.Lock()
+	s.truncated[operation] = true
+	s.pagesMu.Unlock()
+}
+
+// Truncated reports whether a paginated scrape of operation stopped before its
+// last page, so it may have missed results
+func (s *Session) Truncated(operation string) bool {
+	s.pagesMu.Lock()
+	defer s.pagesMu.Unlock()
+	return s.truncated[operation]
+}
+
+// Pages returns how many responses of operation the session has received, which
+// is the number of pages a paginated scrape fetched
+func (s *Session) Pages(operation string) int {
+	s.pagesMu.Lock()
+	defer s.pagesMu.Unlock()
+	return s.pages[operation]
+}
+
+// do sends a request for the given operation through the session client. It
+// applies the default headers, records the x-rate-limit-* headers, retries
+// 429 and 5xx responses with exponential backoff and jitter (honouring the
+// rate-limit reset time) within maxRetryWait, and turns error responses into
+// *APIError.
+func (s *Session) do(operation string, req *http.Request) ([]byte, error) {
+	deadline := time.Now().Add(maxRetryWait)
+	// wait sleeps before the next attempt, unless that would pass the deadline
+	wait := func(d time.Duration) bool {
+		if time.Now().Add(d).After(deadline) {
+			return false
+		}
+		time.Sleep(d)
+		return true
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRequestRetries; attempt++ {
+		// Headers are reapplied so every attempt carries a fresh transaction ID
+		s.applyDefaultHeaders(req)
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			lastErr = err
+			if attempt < maxRequestRetries && !wait(retryBackoff(attempt)) {
+				return nil, lastErr
+			}
+			continue
+		}
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		s.trackRateLimit(operation, resp)
+
+		if readErr != nil {
+			lastErr = readErr
+			continue
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			s.countPage(operation)
+			return body, nil
+		}
+
+		apiErr := classifyResponse(operation, resp, body)
+		lastErr = apiErr
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			delay := retryBackoff(attempt)
+			if !apiErr.Reset.IsZero() {
+				delay = time.Until(apiErr.Reset) + jitter(time.Second)
+			}
+			if attempt == maxRequestRetries || !wait(delay) {
+				return nil, apiErr
+			}
+		case resp.StatusCode >= 500:
+			if attempt < maxRequestRetries && !wait(retryBackoff(attempt)) {
+				return nil, apiErr
+			}
+		default:
+			return nil, apiErr
+		}
+	}
+
+	return nil, lastErr
+}
+
+// retryBackoff returns the exponential backoff for an attempt, with jitter
+func retryBackoff(attempt int) time.Duration {
+	backoff := baseRetryBackoff << attempt
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff + jitter(backoff/2)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// classifyResponse maps an error response to an *APIError of the right kind
+func classifyResponse(operation string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		Operation:  operation,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	var payload struct {
+		Errors []struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.Unmarshal(body, &payload)
+
+	for _, e := range payload.Errors {
+		if apiErr.Message == "" {
+			apiErr.Message = e.Message
+		}
+		if kind := errorCodeKind(e.Code); kind != nil {
+			apiErr.Kind = kind
+			apiErr.Message = e.Message
+			return apiErr
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		apiErr.Kind = ErrRateLimited
+		if rl, ok := parseRateLimit(resp); ok {
+			apiErr.Reset = rl.Reset
+		}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		apiErr.Kind = ErrUnauthorized
+	case http.StatusNotFound:
+		apiErr.Kind = ErrNotFound
+	}
+
+	return apiErr
+}
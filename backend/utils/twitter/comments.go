@@ -2,11 +2,12 @@ package utils_twitter
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -14,12 +15,16 @@ import (
 type Tweet struct {
 	ID        string    `json:"id"`
 	Text      string    `json:"text"`
+	AuthorID  string    `json:"author_id"`
 	Username  string    `json:"username"`
 	Name      string    `json:"name"`
 	Timestamp time.Time `json:"timestamp"`
 	Likes     int       `json:"likes"`
 	Retweets  int       `json:"retweets"`
 	Replies   int       `json:"replies"`
+	Quotes    int       `json:"quotes"`
+	Views     int       `json:"views"`
+	Lang      string    `json:"lang"`
 }
 
 func ExtractTweetID(tweetURL string) string {
@@ -31,8 +36,20 @@ func ExtractTweetID(tweetURL string) string {
 	return ""
 }
 
-func GetAllTweetReplies(tweetID string) ([]*Tweet, error) {
-	if !isLoggedIn {
+// NormalizeTweetID accepts either a bare tweet ID or a tweet URL and returns the ID
+func NormalizeTweetID(input string) string {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return ""
+	}
+	if _, err := strconv.ParseUint(input, 10, 64); err == nil {
+		return input
+	}
+	return ExtractTweetID(input)
+}
+
+func (s *Session) GetAllTweetReplies(tweetID string) ([]*Tweet, error) {
+	if !s.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
@@ -116,62 +133,25 @@ func GetAllTweetReplies(tweetID string) ([]*Tweet, error) {
 		query.Set("fieldToggles", string(fieldTogglesJSON))
 		req.URL.RawQuery = query.Encode()
 
-		req.Header.Set("Authorization", "Bearer "+bearerToken2)
-		req.Header.Set("User-Agent", userAgent)
 		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-Twitter-Active-User", "yes")
-		req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
-		req.Header.Set("X-Twitter-Client-Language", "en")
 
-		for _, cookie := range globalClient.Jar.Cookies(req.URL) {
-			if cookie.Name == "ct0" {
-				req.Header.Set("X-CSRF-Token", cookie.Value)
-				break
-			}
-		}
-
-		resp, err := globalClient.Do(req)
+		body, err := s.do(OpTweetDetail, req)
 		if err != nil {
+			// Keep what was collected when a later page fails for reasons other than rate limiting
+			if len(allTweets) > 0 && !errors.Is(err, ErrRateLimited) {
+				s.markTruncated(OpTweetDetail)
+				return allTweets, nil
+			}
 			return nil, err
 		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
 
 		var result map[string]interface{}
 		json.Unmarshal(body, &result)
-		entryCount := countEntriesInResponse(result)
-
-		fmt.Printf("\n=== PAGE %d RESPONSE ===\n", pageCount+1)
-		fmt.Printf("Status: %s\n", resp.Status)
-		fmt.Printf("Body Length: %d bytes\n", len(body))
-		fmt.Printf("Total entries found: %d\n", entryCount)
-		fmt.Printf("=== END PAGE %d RESPONSE ===\n\n", pageCount+1)
-
-		if resp.StatusCode == 429 {
-			fmt.Printf("Rate limited (429). Waiting 60 seconds...\n")
-			time.Sleep(60 * time.Second)
-			continue
-		}
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: Status %s\n", resp.Status)
-			return allTweets, nil
-		}
 
 		pageTweets, nextCursor := parseTwitterResponse(result)
 		allTweets = append(allTweets, pageTweets...)
 
-		fmt.Printf("Page %d: Found %d tweets (Total: %d)\n", pageCount+1, len(pageTweets), len(allTweets))
-		if len(pageTweets) > 0 {
-			lastTweet := pageTweets[len(pageTweets)-1]
-			fmt.Printf("Last tweet: @%s: %s\n", lastTweet.Username, lastTweet.Text[:min(50, len(lastTweet.Text))])
-		}
-		fmt.Printf("Current cursor: %s\n", cursor)
-		fmt.Printf("Next cursor: %s\n", nextCursor)
-
 		if nextCursor == "" || len(pageTweets) == 0 || nextCursor == cursor {
-			fmt.Printf("No more pages available (cursor: %s, tweets: %d)\n", nextCursor, len(pageTweets))
 			break
 		}
 
@@ -179,6 +159,9 @@ func GetAllTweetReplies(tweetID string) ([]*Tweet, error) {
 		pageCount++
 		time.Sleep(2 * time.Second)
 	}
+	if pageCount == maxPages {
+		s.markTruncated(OpTweetDetail)
+	}
 
 	return allTweets, nil
 }
@@ -228,7 +211,6 @@ func parseTwitterResponse(result map[string]interface{}) ([]*Tweet, string) {
 												if content, ok := e["content"].(map[string]interface{}); ok {
 													if value, ok := content["value"].(string); ok {
 														nextCursor = value
-														fmt.Printf("Extracted cursor (%s): %s...\n", entryId, nextCursor[:50])
 													}
 												}
 											}
@@ -305,6 +287,12 @@ func parseTweetFromResult(result map[string]interface{}) *Tweet {
 						if text, ok := legacy["full_text"].(string); ok {
 							tweet.Text = text
 						}
+						if lang, ok := legacy["lang"].(string); ok {
+							tweet.Lang = lang
+						}
+						if authorID, ok := userResult["rest_id"].(string); ok {
+							tweet.AuthorID = authorID
+						}
 						if username, ok := userLegacy["screen_name"].(string); ok {
 							tweet.Username = username
 						}
@@ -320,6 +308,11 @@ func parseTweetFromResult(result map[string]interface{}) *Tweet {
 						if replies, ok := legacy["reply_count"].(float64); ok {
 							tweet.Replies = int(replies)
 						}
+						if createdAt, ok := legacy["created_at"].(string); ok {
+							if parsedTime, err := time.Parse("Mon Jan 02 15:04:05 -0700 2006", createdAt); err == nil {
+								tweet.Timestamp = parsedTime
+							}
+						}
 
 						return tweet
 					}
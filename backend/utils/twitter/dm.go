@@ -0,0 +1,355 @@
+package utils_twitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Direct message operations tracked separately for rate limiting
+const (
+	OpDMInbox        = "dm/inbox"
+	OpDMConversation = "dm/conversation"
+	OpDMSend         = "dm/new2"
+)
+
+// dmTimelineMore is the status of a DM timeline with older entries
+const dmTimelineMore = "HAS_MORE"
+
+// DMParticipant is a member of a DM conversation
+type DMParticipant struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+// DirectMessage is one message of a DM conversation
+type DirectMessage struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	SenderID       string    `json:"sender_id"`
+	SenderUsername string    `json:"sender_username"`
+	Text           string    `json:"text"`
+	MediaURLs      []string  `json:"media_urls,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// DMConversation is a conversation of the DM inbox
+type DMConversation struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"` // ONE_TO_ONE or GROUP_DM
+	Name         string          `json:"name,omitempty"`
+	Participants []DMParticipant `json:"participants"`
+	LastMessage  *DirectMessage  `json:"last_message,omitempty"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// dmTimeline is the shape shared by the inbox and conversation responses
+type dmTimeline struct {
+	Status     string `json:"status"`
+	MinEntryID string `json:"min_entry_id"`
+	// The inbox's initial state reports its cursor here instead
+	InboxTimelines struct {
+		Trusted struct {
+			Status     string `json:"status"`
+			MinEntryID string `json:"min_entry_id"`
+		} `json:"trusted"`
+	} `json:"inbox_timelines"`
+	Entries []struct {
+		Message *struct {
+			ConversationID string `json:"conversation_id"`
+			MessageData    struct {
+				ID         string `json:"id"`
+				Time       string `json:"time"`
+				SenderID   string `json:"sender_id"`
+				Text       string `json:"text"`
+				Attachment *struct {
+					Photo *struct {
+						MediaURLHTTPS string `json:"media_url_https"`
+					} `json:"photo"`
+					AnimatedGIF *struct {
+						MediaURLHTTPS string `json:"media_url_https"`
+					} `json:"animated_gif"`
+					Video *struct {
+						MediaURLHTTPS string `json:"media_url_https"`
+					} `json:"video"`
+				} `json:"attachment"`
+			} `json:"message_data"`
+		} `json:"message"`
+	} `json:"entries"`
+	Users map[string]struct {
+		ScreenName string `json:"screen_name"`
+		Name       string `json:"name"`
+	} `json:"users"`
+	Conversations map[string]struct {
+		ConversationID string `json:"conversation_id"`
+		Type           string `json:"type"`
+		Name           string `json:"name"`
+		SortTimestamp  string `json:"sort_timestamp"`
+		Participants   []struct {
+			UserID string `json:"user_id"`
+		} `json:"participants"`
+	} `json:"conversations"`
+}
+
+// messages converts the timeline's entries, newest first
+func (t *dmTimeline) messages() []*DirectMessage {
+	messages := []*DirectMessage{}
+	for _, entry := range t.Entries {
+		if entry.Message == nil {
+			continue
+		}
+		data := entry.Message.MessageData
+		msg := &DirectMessage{
+			ID:             data.ID,
+			ConversationID: entry.Message.ConversationID,
+			SenderID:       data.SenderID,
+			SenderUsername: t.Users[data.SenderID].ScreenName,
+			Text:           data.Text,
+			CreatedAt:      parseMillis(data.Time),
+		}
+		if a := data.Attachment; a != nil {
+			switch {
+			case a.Photo != nil:
+				msg.MediaURLs = []string{a.Photo.MediaURLHTTPS}
+			case a.AnimatedGIF != nil:
+				msg.MediaURLs = []string{a.AnimatedGIF.MediaURLHTTPS}
+			case a.Video != nil:
+				msg.MediaURLs = []string{a.Video.MediaURLHTTPS}
+			}
+		}
+		messages = append(messages, msg)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].CreatedAt.After(messages[j].CreatedAt) })
+	return messages
+}
+
+// nextCursor returns the cursor of the older entries, or "" at the end
+func (t *dmTimeline) nextCursor() string {
+	switch {
+	case t.Status == dmTimelineMore:
+		return t.MinEntryID
+	case t.InboxTimelines.Trusted.Status == dmTimelineMore:
+		return t.InboxTimelines.Trusted.MinEntryID
+	}
+	return ""
+}
+
+func parseMillis(ms string) time.Time {
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
+
+// dmQuery holds the parameters the web client sends with every DM request
+func dmQuery() url.Values {
+	query := url.Values{}
+	query.Set("include_profile_interstitial_type", "1")
+	query.Set("include_blocking", "1")
+	query.Set("include_blocked_by", "1")
+	query.Set("dm_users", "true")
+	query.Set("include_groups", "true")
+	query.Set("include_inbox_timelines", "true")
+	query.Set("include_ext_media_color", "true")
+	query.Set("supports_reactions", "true")
+	query.Set("ext", "mediaColor,altText,mediaStats,highlightedLabel")
+	return query
+}
+
+func (s *Session) getDMTimeline(operation, endpoint string, query url.Values, field string) (*dmTimeline, error) {
+	if !s.loggedIn {
+		return nil, fmt.Errorf("not logged in")
+	}
+
+	req, err := http.NewRequest("GET", "https://x.com/i/api/1.1/dm/"+endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.do(operation, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse response: %w", operation, err)
+	}
+	timeline := &dmTimeline{}
+	if raw, ok := result[field]; ok {
+		if err := json.Unmarshal(raw, timeline); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse response: %w", operation, err)
+		}
+	}
+	return timeline, nil
+}
+
+// GetDMInbox lists the conversations of the DM inbox, most recent first. Pass
+// the returned cursor to get older conversations; it is "" at the end.
+func (s *Session) GetDMInbox(cursor string) ([]*DMConversation, string, error) {
+	query := dmQuery()
+	endpoint, field := "inbox_initial_state.json", "inbox_initial_state"
+	if cursor != "" {
+		query.Set("max_id", cursor)
+		endpoint, field = "inbox_timeline/trusted.json", "inbox_timeline"
+	}
+
+	timeline, err := s.getDMTimeline(OpDMInbox, endpoint, query, field)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The newest message of each conversation is its preview
+	lastMessages := map[string]*DirectMessage{}
+	for _, msg := range timeline.messages() {
+		if _, ok := lastMessages[msg.ConversationID]; !ok {
+			lastMessages[msg.ConversationID] = msg
+		}
+	}
+
+	conversations := make([]*DMConversation, 0, len(timeline.Conversations))
+	for id, c := range timeline.Conversations {
+		conversation := &DMConversation{
+			ID:          id,
+			Type:        c.Type,
+			Name:        c.Name,
+			LastMessage: lastMessages[id],
+			UpdatedAt:   parseMillis(c.SortTimestamp),
+		}
+		for _, p := range c.Participants {
+			user := timeline.Users[p.UserID]
+			conversation.Participants = append(conversation.Participants, DMParticipant{UserID: p.UserID, Username: user.ScreenName, Name: user.Name})
+		}
+		conversations = append(conversations, conversation)
+	}
+	sort.Slice(conversations, func(i, j int) bool { return conversations[i].UpdatedAt.After(conversations[j].UpdatedAt) })
+
+	return conversations, timeline.nextCursor(), nil
+}
+
+// GetDMConversation returns a page of a conversation's messages, newest first.
+// Pass the returned cursor to get older messages; it is "" at the end.
+func (s *Session) GetDMConversation(conversationID, cursor string) ([]*DirectMessage, string, error) {
+	query := dmQuery()
+	query.Set("context", "FETCH_DM_CONVERSATION")
+	if cursor != "" {
+		query.Set("max_id", cursor)
+	}
+
+	timeline, err := s.getDMTimeline(OpDMConversation, "conversation/"+url.PathEscape(conversationID)+".json", query, "conversation_timeline")
+	if err != nil {
+		return nil, "", err
+	}
+	return timeline.messages(), timeline.nextCursor(), nil
+}
+
+// accountID returns the X user ID of the logged-in account from its twid cookie
+func (s *Session) accountID() string {
+	u, _ := url.Parse("https://x.com")
+	for _, cookie := range s.client.Jar.Cookies(u) {
+		if cookie.Name == "twid" {
+			value, _ := url.QueryUnescape(cookie.Value)
+			return strings.TrimPrefix(strings.Trim(value, `"`), "u=")
+		}
+	}
+	return ""
+}
+
+// OneToOneConversationID returns the ID of the DM conversation between the
+// logged-in account and userID, which X derives from both user IDs
+func (s *Session) OneToOneConversationID(userID string) (string, error) {
+	self := s.accountID()
+	if self == "" {
+		return "", fmt.Errorf("logged-in account ID not found in session")
+	}
+
+	a, _ := strconv.ParseUint(self, 10, 64)
+	b, _ := strconv.ParseUint(userID, 10, 64)
+	if a > b {
+		self, userID = userID, self
+	}
+	return self + "-" + userID, nil
+}
+
+// ResolveDMConversation returns the conversation a DM to recipient goes to.
+// recipient is a conversation ID, or a username for a one-to-one conversation.
+func (s *Session) ResolveDMConversation(recipient string) (string, error) {
+	if IsDMConversationID(recipient) {
+		return recipient, nil
+	}
+
+	user, err := s.GetUserProfileByUsername(strings.TrimPrefix(recipient, "@"))
+	if err != nil {
+		return "", err
+	}
+	return s.OneToOneConversationID(user.ID)
+}
+
+// IsDMConversationID reports whether id looks like a DM conversation ID: two user
+// IDs joined by a dash for one-to-one conversations, or a number for groups
+func IsDMConversationID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, part := range strings.Split(id, "-") {
+		if _, err := strconv.ParseUint(part, 10, 64); err != nil {
+			return false
+		}
+	}
+	return strings.Count(id, "-") <= 1
+}
+
+// SendDM sends a message to a conversation, optionally with media uploaded by
+// UploadDMMedia, and returns the new message's ID
+func (s *Session) SendDM(conversationID, text, mediaID string) (string, error) {
+	if !s.loggedIn {
+		return "", fmt.Errorf("not logged in")
+	}
+
+	payload := map[string]interface{}{
+		"conversation_id":     conversationID,
+		"recipient_ids":       false,
+		"request_id":          uuid.New().String(),
+		"text":                text,
+		"cards_platform":      "Web-12",
+		"include_cards":       1,
+		"include_quote_count": true,
+		"dm_users":            false,
+	}
+	if mediaID != "" {
+		payload["media_id"] = mediaID
+	}
+	body, _ := json.Marshal(payload)
+
+	query := dmQuery()
+	req, err := http.NewRequest("POST", "https://x.com/i/api/1.1/dm/new2.json?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	respBody, err := s.do(OpDMSend, req)
+	if err != nil {
+		return "", err
+	}
+
+	var timeline dmTimeline
+	if err := json.Unmarshal(respBody, &timeline); err != nil {
+		return "", fmt.Errorf("%s: failed to parse response: %w", OpDMSend, err)
+	}
+	messages := timeline.messages()
+	if len(messages) == 0 {
+		return "", &APIError{Operation: OpDMSend, StatusCode: http.StatusOK, Status: "200 OK", Message: "message was not sent"}
+	}
+	return messages[0].ID, nil
+}
@@ -0,0 +1,105 @@
+package utils_twitter
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	mathrand "math/rand"
+	"net/http"
+)
+
+// ClientProfile is the browser identity one Twitter account presents to X.
+// It is generated once per account, saved with the session and applied to
+// every request so that the user agent, client hints and language agree.
+type ClientProfile struct {
+	UserAgent       string `json:"user_agent"`
+	SecCHUA         string `json:"sec_ch_ua"`
+	SecCHUAMobile   string `json:"sec_ch_ua_mobile"`
+	SecCHUAPlatform string `json:"sec_ch_ua_platform"`
+	AcceptLanguage  string `json:"accept_language"`
+	ClientLanguage  string `json:"client_language"`
+	Timezone        string `json:"timezone"` // IANA zone matching the locale
+}
+
+type platformProfile struct {
+	uaPlatform string // platform part of the user agent
+	chPlatform string // sec-ch-ua-platform value
+}
+
+type localeProfile struct {
+	acceptLanguage string
+	clientLanguage string
+	timezones      []string
+}
+
+var (
+	chromeVersions = []int{138, 139, 140, 141}
+
+	platformProfiles = []platformProfile{
+		{"Windows NT 10.0; Win64; x64", `"Windows"`},
+		{"Macintosh; Intel Mac OS X 10_15_7", `"macOS"`},
+		{"X11; Linux x86_64", `"Linux"`},
+	}
+
+	localeProfiles = []localeProfile{
+		{"en-US,en;q=0.9", "en", []string{"America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles"}},
+		{"en-GB,en;q=0.9", "en", []string{"Europe/London"}},
+		{"en-CA,en;q=0.9", "en", []string{"America/Toronto", "America/Vancouver"}},
+		{"en-AU,en;q=0.9", "en", []string{"Australia/Sydney", "Australia/Melbourne"}},
+	}
+)
+
+// NewClientProfile builds a desktop Chrome profile for an account. The choice is
+// seeded from the account ID, so an account keeps the same identity even if its
+// session file is lost and it logs in again.
+func NewClientProfile(twitterAccountID string) *ClientProfile {
+	h := fnv.New64a()
+	h.Write([]byte(twitterAccountID))
+	rng := mathrand.New(mathrand.NewSource(int64(h.Sum64())))
+
+	version := chromeVersions[rng.Intn(len(chromeVersions))]
+	platform := platformProfiles[rng.Intn(len(platformProfiles))]
+	locale := localeProfiles[rng.Intn(len(localeProfiles))]
+
+	return &ClientProfile{
+		UserAgent: fmt.Sprintf(
+			"Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36",
+			platform.uaPlatform, version,
+		),
+		SecCHUA:         fmt.Sprintf(`"Google Chrome";v="%d", "Chromium";v="%d", "Not/A)Brand";v="24"`, version, version),
+		SecCHUAMobile:   "?0",
+		SecCHUAPlatform: platform.chPlatform,
+		AcceptLanguage:  locale.acceptLanguage,
+		ClientLanguage:  locale.clientLanguage,
+		Timezone:        locale.timezones[rng.Intn(len(locale.timezones))],
+	}
+}
+
+// applyProfile sets the browser identity headers of the session's profile
+func (s *Session) applyProfile(req *http.Request) {
+	p := s.profile
+	if p == nil {
+		p = NewClientProfile(s.TwitterAccountID)
+		s.profile = p
+	}
+
+	req.Header.Set("User-Agent", p.UserAgent)
+	req.Header.Set("Sec-CH-UA", p.SecCHUA)
+	req.Header.Set("Sec-CH-UA-Mobile", p.SecCHUAMobile)
+	req.Header.Set("Sec-CH-UA-Platform", p.SecCHUAPlatform)
+	req.Header.Set("Accept-Language", p.AcceptLanguage)
+	req.Header.Set("X-Twitter-Client-Language", p.ClientLanguage)
+	req.Header.Set("X-Twitter-Client-Timezone", p.Timezone)
+	req.Header.Set("X-Client-Transaction-Id", newTransactionID())
+}
+
+// newTransactionID returns a random value for the x-client-transaction-id
+// header. It only has the length and encoding of the web client's header: the
+// real one is derived from the X home page and the request path and method,
+// which this does not do, so X can tell it apart.
+func newTransactionID() string {
+	b := make([]byte, 70)
+	rand.Read(b)
+	return base64.RawStdEncoding.EncodeToString(b)
+}
@@ -3,15 +3,14 @@ package utils_twitter
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 )
 
-func GetLikers(tweetID string) ([]*User, error) {
-	if !isLoggedIn {
+func (s *Session) GetLikers(tweetID string) ([]*User, error) {
+	if !s.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
@@ -78,61 +77,20 @@ func GetLikers(tweetID string) ([]*User, error) {
 		query.Set("features", string(featuresJSON))
 		req.URL.RawQuery = query.Encode()
 
-		req.Header.Set("Authorization", "Bearer "+bearerToken2)
-		req.Header.Set("User-Agent", userAgent)
-		req.Header.Set("X-Guest-Token", globalGuestToken)
-		req.Header.Set("X-Twitter-Active-User", "yes")
-		req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
-		req.Header.Set("X-Twitter-Client-Language", "en")
 		req.Header.Set("Referer", fmt.Sprintf("https://x.com/Kiyotaka1232384/status/%s/likes", tweetID))
 
-		for _, cookie := range globalClient.Jar.Cookies(req.URL) {
-			if cookie.Name == "ct0" {
-				req.Header.Set("X-CSRF-Token", cookie.Value)
-				break
-			}
-		}
-
-		resp, err := globalClient.Do(req)
+		body, err := s.do(OpFavoriters, req)
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-
-		fmt.Printf("\n=== PAGE %d RESPONSE ===\n", pageCount+1)
-		fmt.Printf("Status: %s\n", resp.Status)
-		fmt.Printf("Body Length: %d bytes\n", len(body))
-		fmt.Printf("=== END PAGE %d RESPONSE ===\n\n", pageCount+1)
 
 		var result map[string]interface{}
 		json.Unmarshal(body, &result)
 
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: Status %s\n", resp.Status)
-			return nil, fmt.Errorf("API error: %s", resp.Status)
-		}
-
 		pageUsers, nextCursor := parseLikersResponse(result)
 		allUsers = append(allUsers, pageUsers...)
 
-		fmt.Printf("Page %d: Found %d users (Total: %d)\n", pageCount+1, len(pageUsers), len(allUsers))
-		fmt.Printf("Current cursor: %s\n", cursor)
-		fmt.Printf("Next cursor: %s\n", nextCursor)
-		fmt.Printf("Cursor same as previous: %t\n", nextCursor == cursor)
-		fmt.Printf("Next cursor empty: %t\n", nextCursor == "")
-		fmt.Printf("No users found: %t\n", len(pageUsers) == 0)
-
 		if nextCursor == "" || len(pageUsers) == 0 || nextCursor == cursor {
-			fmt.Printf("Stopping pagination - Reason: ")
-			if nextCursor == "" {
-				fmt.Printf("No next cursor\n")
-			} else if len(pageUsers) == 0 {
-				fmt.Printf("No users found\n")
-			} else if nextCursor == cursor {
-				fmt.Printf("Cursor unchanged\n")
-			}
 			break
 		}
 
@@ -140,6 +98,9 @@ func GetLikers(tweetID string) ([]*User, error) {
 		pageCount++
 		time.Sleep(2 * time.Second)
 	}
+	if pageCount == maxPages {
+		s.markTruncated(OpFavoriters)
+	}
 
 	return allUsers, nil
 }
@@ -0,0 +1,207 @@
+package utils_twitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mediaUploadURL = "https://upload.x.com/i/media/upload.json"
+	// mediaChunkSize is the size of each APPEND segment
+	mediaChunkSize = 1 << 20
+	// maxMediaProcessingWait bounds how long we poll a video or GIF being processed
+	maxMediaProcessingWait = 2 * time.Minute
+)
+
+// Upload limits of the web client per media category
+var mediaSizeLimits = map[string]int{
+	"tweet_image": 5 << 20,
+	"tweet_gif":   15 << 20,
+	"tweet_video": 512 << 20,
+}
+
+// MediaCategory returns the upload category of a MIME type, or "" when
+// Twitter does not accept it as tweet media
+func MediaCategory(mimeType string) string {
+	switch {
+	case mimeType == "image/gif":
+		return "tweet_gif"
+	case mimeType == "image/jpeg", mimeType == "image/png", mimeType == "image/webp":
+		return "tweet_image"
+	case mimeType == "video/mp4", mimeType == "video/quicktime":
+		return "tweet_video"
+	}
+	return ""
+}
+
+// ValidateMedia checks that data of the given MIME type can be attached to a tweet
+func ValidateMedia(data []byte, mimeType string) error {
+	category := MediaCategory(mimeType)
+	if category == "" {
+		return fmt.Errorf("unsupported media type %q", mimeType)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("media is empty")
+	}
+	if len(data) > mediaSizeLimits[category] {
+		return fmt.Errorf("media is larger than %d MB", mediaSizeLimits[category]>>20)
+	}
+	return nil
+}
+
+// mediaUploadResponse is the reply to the INIT, FINALIZE and STATUS commands
+type mediaUploadResponse struct {
+	MediaIDString  string `json:"media_id_string"`
+	ProcessingInfo *struct {
+		State          string `json:"state"` // pending, in_progress, succeeded, failed
+		CheckAfterSecs int    `json:"check_after_secs"`
+		Error          *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"processing_info"`
+}
+
+// UploadMedia uploads an image, GIF or video with the chunked INIT/APPEND/FINALIZE
+// flow and waits for server-side processing. It returns the media ID to pass
+// in TweetOptions.MediaIDs.
+func (s *Session) UploadMedia(data []byte, mimeType string) (string, error) {
+	return s.uploadMedia(data, mimeType, MediaCategory(mimeType))
+}
+
+// UploadDMMedia uploads media for a direct message, which X keeps in the dm_* categories
+func (s *Session) UploadDMMedia(data []byte, mimeType string) (string, error) {
+	return s.uploadMedia(data, mimeType, strings.Replace(MediaCategory(mimeType), "tweet_", "dm_", 1))
+}
+
+func (s *Session) uploadMedia(data []byte, mimeType, category string) (string, error) {
+	if !s.loggedIn {
+		return "", fmt.Errorf("not logged in")
+	}
+	if err := ValidateMedia(data, mimeType); err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("command", "INIT")
+	form.Set("total_bytes", strconv.Itoa(len(data)))
+	form.Set("media_type", mimeType)
+	form.Set("media_category", category)
+	init, err := s.mediaCommand(form)
+	if err != nil {
+		return "", err
+	}
+	mediaID := init.MediaIDString
+
+	for segment := 0; segment*mediaChunkSize < len(data); segment++ {
+		end := (segment + 1) * mediaChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := s.appendMediaChunk(mediaID, segment, data[segment*mediaChunkSize:end]); err != nil {
+			return "", err
+		}
+	}
+
+	form = url.Values{}
+	form.Set("command", "FINALIZE")
+	form.Set("media_id", mediaID)
+	status, err := s.mediaCommand(form)
+	if err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(maxMediaProcessingWait)
+	for status.ProcessingInfo != nil {
+		switch status.ProcessingInfo.State {
+		case "succeeded":
+			return mediaID, nil
+		case "failed":
+			msg := "media processing failed"
+			if status.ProcessingInfo.Error != nil {
+				msg = status.ProcessingInfo.Error.Message
+			}
+			return "", &APIError{Operation: OpMediaUpload, StatusCode: http.StatusOK, Status: "200 OK", Message: msg}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("media %s is still processing", mediaID)
+		}
+
+		wait := status.ProcessingInfo.CheckAfterSecs
+		if wait <= 0 {
+			wait = 1
+		}
+		time.Sleep(time.Duration(wait) * time.Second)
+
+		if status, err = s.mediaStatus(mediaID); err != nil {
+			return "", err
+		}
+	}
+
+	return mediaID, nil
+}
+
+// mediaCommand posts a form-encoded INIT or FINALIZE command
+func (s *Session) mediaCommand(form url.Values) (*mediaUploadResponse, error) {
+	req, err := http.NewRequest("POST", mediaUploadURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.doMedia(req)
+}
+
+// mediaStatus polls the processing state of an uploaded video or GIF
+func (s *Session) mediaStatus(mediaID string) (*mediaUploadResponse, error) {
+	req, err := http.NewRequest("GET", mediaUploadURL+"?command=STATUS&media_id="+mediaID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.doMedia(req)
+}
+
+// appendMediaChunk uploads one segment of the media as multipart form data
+func (s *Session) appendMediaChunk(mediaID string, segment int, chunk []byte) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("command", "APPEND")
+	writer.WriteField("media_id", mediaID)
+	writer.WriteField("segment_index", strconv.Itoa(segment))
+	part, err := writer.CreateFormFile("media", "blob")
+	if err != nil {
+		return err
+	}
+	part.Write(chunk)
+	writer.Close()
+
+	req, err := http.NewRequest("POST", mediaUploadURL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, err = s.do(OpMediaUpload, req)
+	return err
+}
+
+func (s *Session) doMedia(req *http.Request) (*mediaUploadResponse, error) {
+	body, err := s.do(OpMediaUpload, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result mediaUploadResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse response: %w", OpMediaUpload, err)
+	}
+	if result.MediaIDString == "" {
+		return nil, &APIError{Operation: OpMediaUpload, StatusCode: http.StatusOK, Status: "200 OK", Message: "no media ID returned"}
+	}
+	return &result, nil
+}
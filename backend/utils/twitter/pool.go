@@ -0,0 +1,234 @@
+package utils_twitter
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// GraphQL operations tracked separately for rate limiting
+const (
+	OpTweetResult = "TweetResultByRestId"
+	OpFavoriters  = "Favoriters"
+	OpRetweeters  = "Retweeters"
+	OpSearch      = "SearchTimeline"
+	OpTweetDetail = "TweetDetail"
+)
+
+// PoolStrategy decides the order in which a user's accounts are tried
+type PoolStrategy string
+
+const (
+	PoolRoundRobin        PoolStrategy = "round_robin"
+	PoolLeastRecentlyUsed PoolStrategy = "lru"
+)
+
+const (
+	// defaultRateLimitPause is used when a 429 comes without a reset header
+	defaultRateLimitPause = 15 * time.Minute
+	// unhealthyAccountPause keeps accounts with broken sessions out of rotation
+	unhealthyAccountPause = 5 * time.Minute
+)
+
+// RateLimit is the last known x-rate-limit-* state for one account and operation
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// AccountPoolStatus describes the pool state of a single account
+type AccountPoolStatus struct {
+	TwitterAccountID string               `json:"twitter_account_id"`
+	LastUsedAt       *time.Time           `json:"last_used_at,omitempty"`
+	CooldownUntil    *time.Time           `json:"cooldown_until,omitempty"`
+	CooldownReason   string               `json:"cooldown_reason,omitempty"`
+	RateLimits       map[string]RateLimit `json:"rate_limits"`
+}
+
+type accountState struct {
+	lastUsed       time.Time
+	cooldownUntil  time.Time
+	cooldownReason string
+	limits         map[string]RateLimit
+}
+
+var (
+	accountStates   = make(map[string]*accountState) // keyed by Twitter account ID
+	poolCursors     = make(map[string]int)           // round-robin position keyed by user ID
+	accountStatesMu sync.Mutex
+)
+
+func stateFor(twitterAccountID string) *accountState {
+	state, ok := accountStates[twitterAccountID]
+	if !ok {
+		state = &accountState{limits: make(map[string]RateLimit)}
+		accountStates[twitterAccountID] = state
+	}
+	return state
+}
+
+// parseRateLimit reads the x-rate-limit-* headers of a response
+func parseRateLimit(resp *http.Response) (RateLimit, bool) {
+	limit := resp.Header.Get("x-rate-limit-limit")
+	remaining := resp.Header.Get("x-rate-limit-remaining")
+	reset := resp.Header.Get("x-rate-limit-reset")
+	if remaining == "" && reset == "" {
+		return RateLimit{}, false
+	}
+
+	rl := RateLimit{Remaining: -1}
+	if v, err := strconv.Atoi(limit); err == nil {
+		rl.Limit = v
+	}
+	if v, err := strconv.Atoi(remaining); err == nil {
+		rl.Remaining = v
+	}
+	if v, err := strconv.ParseInt(reset, 10, 64); err == nil {
+		rl.Reset = time.Unix(v, 0)
+	}
+	return rl, true
+}
+
+// trackRateLimit records the rate-limit headers of a response for this session's account.
+// A 429 puts the account on cooldown until the reset time, and a later success
+// on the same operation lifts that cooldown.
+func (s *Session) trackRateLimit(operation string, resp *http.Response) {
+	if s == nil || s.TwitterAccountID == "" || resp == nil {
+		return
+	}
+
+	rl, ok := parseRateLimit(resp)
+
+	accountStatesMu.Lock()
+	defer accountStatesMu.Unlock()
+
+	state := stateFor(s.TwitterAccountID)
+	if ok {
+		state.limits[operation] = rl
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		until := time.Now().Add(defaultRateLimitPause)
+		if ok && rl.Reset.After(time.Now()) {
+			until = rl.Reset
+		}
+		state.cooldownUntil = until
+		state.cooldownReason = rateLimitedReason(operation)
+	} else if resp.StatusCode >= 200 && resp.StatusCode < 300 && state.cooldownReason == rateLimitedReason(operation) {
+		state.cooldownUntil = time.Time{}
+		state.cooldownReason = ""
+	}
+}
+
+// rateLimitedReason is the cooldown reason of a 429 on operation
+func rateLimitedReason(operation string) string {
+	return "rate limited on " + operation
+}
+
+// MarkAccountUsed records that an account has just served a request
+func MarkAccountUsed(twitterAccountID string) {
+	accountStatesMu.Lock()
+	defer accountStatesMu.Unlock()
+	stateFor(twitterAccountID).lastUsed = time.Now()
+}
+
+// MarkAccountUnhealthy takes an account out of rotation for a short while,
+// e.g. when its saved session could not be loaded or validated
+func MarkAccountUnhealthy(twitterAccountID, reason string) {
+	accountStatesMu.Lock()
+	defer accountStatesMu.Unlock()
+	state := stateFor(twitterAccountID)
+	state.cooldownUntil = time.Now().Add(unhealthyAccountPause)
+	state.cooldownReason = reason
+}
+
+// accountAvailable reports whether an account can serve the operation right now
+func accountAvailable(state *accountState, operation string, now time.Time) bool {
+	if now.Before(state.cooldownUntil) {
+		return false
+	}
+	if rl, ok := state.limits[operation]; ok && rl.Remaining == 0 && now.Before(rl.Reset) {
+		return false
+	}
+	return true
+}
+
+// OrderPoolAccounts returns the accounts able to serve the operation, in the
+// order the given strategy says they should be tried. Accounts on cooldown or
+// without remaining rate limit for the operation are left out.
+func OrderPoolAccounts(userID string, twitterAccountIDs []string, operation string, strategy PoolStrategy) []string {
+	accountStatesMu.Lock()
+	defer accountStatesMu.Unlock()
+
+	now := time.Now()
+	available := []string{}
+	for _, id := range twitterAccountIDs {
+		if accountAvailable(stateFor(id), operation, now) {
+			available = append(available, id)
+		}
+	}
+
+	if len(available) == 0 {
+		return available
+	}
+
+	switch strategy {
+	case PoolLeastRecentlyUsed:
+		sort.SliceStable(available, func(i, j int) bool {
+			return accountStates[available[i]].lastUsed.Before(accountStates[available[j]].lastUsed)
+		})
+	default:
+		start := poolCursors[userID] % len(available)
+		poolCursors[userID] = start + 1
+		available = append(available[start:], available[:start]...)
+	}
+
+	return available
+}
+
+// NextPoolReset returns the earliest time one of the accounts becomes available again
+func NextPoolReset(twitterAccountIDs []string, operation string) time.Time {
+	accountStatesMu.Lock()
+	defer accountStatesMu.Unlock()
+
+	var next time.Time
+	for _, id := range twitterAccountIDs {
+		state := stateFor(id)
+		until := state.cooldownUntil
+		if rl, ok := state.limits[operation]; ok && rl.Remaining == 0 && rl.Reset.After(until) {
+			until = rl.Reset
+		}
+		if next.IsZero() || until.Before(next) {
+			next = until
+		}
+	}
+	return next
+}
+
+// GetAccountPoolStatus returns the tracked pool state of an account
+func GetAccountPoolStatus(twitterAccountID string) AccountPoolStatus {
+	accountStatesMu.Lock()
+	defer accountStatesMu.Unlock()
+
+	state := stateFor(twitterAccountID)
+	status := AccountPoolStatus{
+		TwitterAccountID: twitterAccountID,
+		RateLimits:       make(map[string]RateLimit, len(state.limits)),
+	}
+	if !state.lastUsed.IsZero() {
+		lastUsed := state.lastUsed
+		status.LastUsedAt = &lastUsed
+	}
+	if time.Now().Before(state.cooldownUntil) {
+		until := state.cooldownUntil
+		status.CooldownUntil = &until
+		status.CooldownReason = state.cooldownReason
+	}
+	for op, rl := range state.limits {
+		status.RateLimits[op] = rl
+	}
+	return status
+}
@@ -0,0 +1,121 @@
+package utils_twitter
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+)
+
+// LikelyBotScore is the quality score below which a user is flagged as a likely bot
+const LikelyBotScore = 40
+
+// Quality is the outcome of scoring a profile: 100 looks like a real, active
+// person, 0 looks like spam. Reasons lists every penalty that applied.
+type Quality struct {
+	Score     int      `json:"score"`
+	Reasons   []string `json:"reasons"`
+	LikelyBot bool     `json:"likely_bot"`
+}
+
+// ScoreUser scores a profile from its avatar, account age, follower/following
+// ratio, handle and tweet rate. Fields X did not return are not penalised.
+func ScoreUser(u *User, now time.Time) Quality {
+	score := 100
+	reasons := []string{}
+	penalise := func(points int, reason string) {
+		score -= points
+		reasons = append(reasons, reason)
+	}
+
+	if u.DefaultProfileImage {
+		penalise(25, "default profile image")
+	}
+
+	ageDays := -1
+	if !u.CreatedAt.IsZero() {
+		ageDays = int(now.Sub(u.CreatedAt).Hours() / 24)
+		switch {
+		case ageDays < 30:
+			penalise(25, fmt.Sprintf("account is %d days old", ageDays))
+		case ageDays < 180:
+			penalise(10, fmt.Sprintf("account is %d days old", ageDays))
+		}
+	}
+
+	switch {
+	case u.Followers == 0 && u.Following > 0:
+		penalise(20, "no followers")
+	case u.Following > 200 && u.Following > u.Followers*20:
+		penalise(20, fmt.Sprintf("follows %d accounts but has %d followers", u.Following, u.Followers))
+	case u.Following > 100 && u.Following > u.Followers*5:
+		penalise(10, fmt.Sprintf("follows %d accounts but has %d followers", u.Following, u.Followers))
+	}
+
+	if digits := countDigits(u.Username); digits >= 5 || (len(u.Username) > 0 && digits*2 >= len(u.Username)) {
+		penalise(15, "digit-heavy handle")
+	}
+
+	if ageDays >= 0 {
+		switch {
+		case u.Tweets == 0:
+			penalise(10, "has never tweeted")
+		case ageDays > 0 && u.Tweets/ageDays > 100:
+			penalise(20, fmt.Sprintf("posts %d tweets a day", u.Tweets/ageDays))
+		}
+	}
+
+	if u.Description == "" {
+		penalise(5, "empty bio")
+	}
+
+	if u.Verified || u.BlueVerified {
+		score += 10
+	}
+	if score > 100 {
+		score = 100
+	}
+	if score < 0 {
+		score = 0
+	}
+
+	return Quality{Score: score, Reasons: reasons, LikelyBot: score < LikelyBotScore}
+}
+
+// ScoreUsers sets QualityScore, QualityReasons and LikelyBot on every user
+func ScoreUsers(users []*User) {
+	now := time.Now()
+	for _, u := range users {
+		if u == nil {
+			continue
+		}
+		q := ScoreUser(u, now)
+		score := q.Score
+		u.QualityScore, u.QualityReasons, u.LikelyBot = &score, q.Reasons, q.LikelyBot
+	}
+}
+
+// FilterUsersByQuality keeps the users scoring at least minScore, and drops likely
+// bots when excludeBots is set. Users must have been scored with ScoreUsers.
+func FilterUsersByQuality(users []*User, minScore int, excludeBots bool) []*User {
+	kept := make([]*User, 0, len(users))
+	for _, u := range users {
+		if u == nil || u.QualityScore == nil {
+			continue
+		}
+		if *u.QualityScore < minScore || (excludeBots && u.LikelyBot) {
+			continue
+		}
+		kept = append(kept, u)
+	}
+	return kept
+}
+
+func countDigits(s string) int {
+	digits := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits
+}
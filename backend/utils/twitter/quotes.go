@@ -2,16 +2,16 @@ package utils_twitter
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 )
 
-func SearchQuotedTweets(quotedTweetID string) ([]*Tweet, error) {
-	if !isLoggedIn {
+func (s *Session) SearchQuotedTweets(quotedTweetID string) ([]*Tweet, error) {
+	if !s.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
@@ -80,75 +80,24 @@ func SearchQuotedTweets(quotedTweetID string) ([]*Tweet, error) {
 		query.Set("features", string(featuresJSON))
 		req.URL.RawQuery = query.Encode()
 
-		req.Header.Set("Authorization", "Bearer "+bearerToken2)
-		req.Header.Set("User-Agent", userAgent)
-		req.Header.Set("X-Guest-Token", globalGuestToken)
-		req.Header.Set("X-Twitter-Active-User", "yes")
-		req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
-		req.Header.Set("X-Twitter-Client-Language", "en")
 		req.Header.Set("Referer", "https://x.com/CubaneSpace/status/1955622870077309307/quotes")
 
-		for _, cookie := range globalClient.Jar.Cookies(req.URL) {
-			if cookie.Name == "ct0" {
-				req.Header.Set("X-CSRF-Token", cookie.Value)
-				break
-			}
-		}
-
-		resp, err := globalClient.Do(req)
+		body, err := s.do(OpSearch, req)
 		if err != nil {
+			// Keep what was collected when a later page fails for reasons other than rate limiting
+			if len(allTweets) > 0 && !errors.Is(err, ErrRateLimited) {
+				return allTweets, nil
+			}
 			return nil, err
 		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-
-		fmt.Printf("\n=== PAGE %d RESPONSE ===\n", pageCount+1)
-		fmt.Printf("Status: %s\n", resp.Status)
-		fmt.Printf("Body Length: %d bytes\n", len(body))
-		fmt.Printf("=== END PAGE %d RESPONSE ===\n\n", pageCount+1)
 
 		var result map[string]interface{}
 		json.Unmarshal(body, &result)
 
-		if resp.StatusCode == 429 {
-			fmt.Printf("Rate limited (429). Waiting 60 seconds...\n")
-			time.Sleep(60 * time.Second)
-			continue
-		}
-
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: Status %s\n", resp.Status)
-			return allTweets, nil
-		}
-
 		pageTweets, nextCursor := parseSearchResponse(result)
 		allTweets = append(allTweets, pageTweets...)
 
-		fmt.Printf("Page %d: Found %d tweets (Total: %d)\n", pageCount+1, len(pageTweets), len(allTweets))
-		if len(pageTweets) > 0 {
-			lastTweet := pageTweets[len(pageTweets)-1]
-			fmt.Printf("Last tweet: @%s: %s\n", lastTweet.Username, lastTweet.Text[:min(50, len(lastTweet.Text))])
-		}
-		fmt.Printf("Current cursor: %s\n", cursor)
-		fmt.Printf("Next cursor: %s\n", nextCursor)
-		fmt.Printf("Cursor same as previous: %t\n", nextCursor == cursor)
-		fmt.Printf("Next cursor empty: %t\n", nextCursor == "")
-		fmt.Printf("No tweets found: %t\n", len(pageTweets) == 0)
-
-		if nextCursor == "" {
-			fmt.Printf("DEBUG: No cursor found in response\n")
-		}
-
 		if nextCursor == "" || len(pageTweets) == 0 || nextCursor == cursor {
-			fmt.Printf("Stopping pagination - Reason: ")
-			if nextCursor == "" {
-				fmt.Printf("No next cursor\n")
-			} else if len(pageTweets) == 0 {
-				fmt.Printf("No tweets found\n")
-			} else if nextCursor == cursor {
-				fmt.Printf("Cursor unchanged\n")
-			}
 			break
 		}
 
@@ -200,7 +149,6 @@ func parseSearchResponse(result map[string]interface{}) ([]*Tweet, string) {
 													if content, ok := entry["content"].(map[string]interface{}); ok {
 														if value, ok := content["value"].(string); ok {
 															nextCursor = value
-															fmt.Printf("DEBUG: Found cursor in TimelineReplaceEntry for bottom: %s\n", value)
 														}
 													}
 												}
@@ -253,6 +201,9 @@ func parseSearchTweetResult(result map[string]interface{}) *Tweet {
 		if text, ok := legacy["full_text"].(string); ok {
 			tweet.Text = text
 		}
+		if lang, ok := legacy["lang"].(string); ok {
+			tweet.Lang = lang
+		}
 		if likes, ok := legacy["favorite_count"].(float64); ok {
 			tweet.Likes = int(likes)
 		}
@@ -262,11 +213,19 @@ func parseSearchTweetResult(result map[string]interface{}) *Tweet {
 		if replies, ok := legacy["reply_count"].(float64); ok {
 			tweet.Replies = int(replies)
 		}
+		if createdAt, ok := legacy["created_at"].(string); ok {
+			if parsedTime, err := time.Parse("Mon Jan 02 15:04:05 -0700 2006", createdAt); err == nil {
+				tweet.Timestamp = parsedTime
+			}
+		}
 	}
 
 	if core, ok := result["core"].(map[string]interface{}); ok {
 		if userResults, ok := core["user_results"].(map[string]interface{}); ok {
 			if userResult, ok := userResults["result"].(map[string]interface{}); ok {
+				if authorID, ok := userResult["rest_id"].(string); ok {
+					tweet.AuthorID = authorID
+				}
 				if userCore, ok := userResult["core"].(map[string]interface{}); ok {
 					if username, ok := userCore["screen_name"].(string); ok {
 						tweet.Username = username
@@ -3,15 +3,14 @@ package utils_twitter
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 )
 
-func GetRetweeters(tweetID string) ([]*User, error) {
-	if !isLoggedIn {
+func (s *Session) GetRetweeters(tweetID string) ([]*User, error) {
+	if !s.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
@@ -78,61 +77,20 @@ func GetRetweeters(tweetID string) ([]*User, error) {
 		query.Set("features", string(featuresJSON))
 		req.URL.RawQuery = query.Encode()
 
-		req.Header.Set("Authorization", "Bearer "+bearerToken2)
-		req.Header.Set("User-Agent", userAgent)
-		req.Header.Set("X-Guest-Token", globalGuestToken)
-		req.Header.Set("X-Twitter-Active-User", "yes")
-		req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
-		req.Header.Set("X-Twitter-Client-Language", "en")
 		req.Header.Set("Referer", fmt.Sprintf("https://x.com/elonmusk/status/%s/retweets", tweetID))
 
-		for _, cookie := range globalClient.Jar.Cookies(req.URL) {
-			if cookie.Name == "ct0" {
-				req.Header.Set("X-CSRF-Token", cookie.Value)
-				break
-			}
-		}
-
-		resp, err := globalClient.Do(req)
+		body, err := s.do(OpRetweeters, req)
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-
-		fmt.Printf("\n=== PAGE %d RESPONSE ===\n", pageCount+1)
-		fmt.Printf("Status: %s\n", resp.Status)
-		fmt.Printf("Body Length: %d bytes\n", len(body))
-		fmt.Printf("=== END PAGE %d RESPONSE ===\n\n", pageCount+1)
 
 		var result map[string]interface{}
 		json.Unmarshal(body, &result)
 
-		if resp.StatusCode != 200 {
-			fmt.Printf("Error: Status %s\n", resp.Status)
-			return nil, fmt.Errorf("API error: %s", resp.Status)
-		}
-
 		pageUsers, nextCursor := parseRetweetersResponse(result)
 		allUsers = append(allUsers, pageUsers...)
 
-		fmt.Printf("Page %d: Found %d users (Total: %d)\n", pageCount+1, len(pageUsers), len(allUsers))
-		fmt.Printf("Current cursor: %s\n", cursor)
-		fmt.Printf("Next cursor: %s\n", nextCursor)
-		fmt.Printf("Cursor same as previous: %t\n", nextCursor == cursor)
-		fmt.Printf("Next cursor empty: %t\n", nextCursor == "")
-		fmt.Printf("No users found: %t\n", len(pageUsers) == 0)
-
 		if nextCursor == "" || len(pageUsers) == 0 || nextCursor == cursor {
-			fmt.Printf("Stopping pagination - Reason: ")
-			if nextCursor == "" {
-				fmt.Printf("No next cursor\n")
-			} else if len(pageUsers) == 0 {
-				fmt.Printf("No users found\n")
-			} else if nextCursor == cursor {
-				fmt.Printf("Cursor unchanged\n")
-			}
 			break
 		}
 
@@ -140,19 +98,32 @@ func GetRetweeters(tweetID string) ([]*User, error) {
 		pageCount++
 		time.Sleep(2 * time.Second)
 	}
+	if pageCount == maxPages {
+		s.markTruncated(OpRetweeters)
+	}
 
 	return allUsers, nil
 }
 
 type User struct {
-	ID           string `json:"id"`
-	Username     string `json:"username"`
-	Name         string `json:"name"`
-	Description  string `json:"description"`
-	Followers    int    `json:"followers"`
-	Following    int    `json:"following"`
-	Verified     bool   `json:"verified"`
-	BlueVerified bool   `json:"blue_verified"`
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Followers    int       `json:"followers"`
+	Following    int       `json:"following"`
+	Verified     bool      `json:"verified"`
+	BlueVerified bool      `json:"blue_verified"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	ProfileImage        string `json:"profile_image"`
+	DefaultProfileImage bool   `json:"default_profile_image"`
+	Tweets              int    `json:"tweets"`
+
+	// Set by ScoreUsers
+	QualityScore   *int     `json:"quality_score,omitempty"`
+	QualityReasons []string `json:"quality_reasons,omitempty"`
+	LikelyBot      bool     `json:"likely_bot,omitempty"`
 }
 
 func parseRetweetersResponse(result map[string]interface{}) ([]*User, string) {
@@ -225,31 +196,24 @@ func parseUserResult(result map[string]interface{}) *User {
 		}
 	}
 
-	if legacy, ok := result["legacy"].(map[string]interface{}); ok {
-		if user.Username == "" {
-			if username, ok := legacy["screen_name"].(string); ok {
-				user.Username = username
-			}
-		}
-		if user.Name == "" {
-			if name, ok := legacy["name"].(string); ok {
-				user.Name = name
+	if core, ok := result["core"].(map[string]interface{}); ok {
+		if createdAt, ok := core["created_at"].(string); ok {
+			if parsedTime, err := time.Parse(twitterTimeLayout, createdAt); err == nil {
+				user.CreatedAt = parsedTime
 			}
 		}
-		if description, ok := legacy["description"].(string); ok {
-			user.Description = description
-		}
-		if followers, ok := legacy["followers_count"].(float64); ok {
-			user.Followers = int(followers)
-		}
-		if following, ok := legacy["friends_count"].(float64); ok {
-			user.Following = int(following)
-		}
-		if verified, ok := legacy["verified"].(bool); ok {
-			user.Verified = verified
+	}
+
+	if avatar, ok := result["avatar"].(map[string]interface{}); ok {
+		if imageURL, ok := avatar["image_url"].(string); ok {
+			user.ProfileImage = imageURL
 		}
 	}
 
+	if legacy, ok := result["legacy"].(map[string]interface{}); ok {
+		applyLegacyUser(user, legacy)
+	}
+
 	if blueVerified, ok := result["is_blue_verified"].(bool); ok {
 		user.BlueVerified = blueVerified
 	}
@@ -259,3 +223,55 @@ func parseUserResult(result map[string]interface{}) *User {
 	}
 	return nil
 }
+
+// applyLegacyUser fills the fields of user that are still empty from a v1.1-style user object
+func applyLegacyUser(user *User, legacy map[string]interface{}) {
+	if user.ID == "" {
+		if id, ok := legacy["id_str"].(string); ok {
+			user.ID = id
+		}
+	}
+	if user.Username == "" {
+		if username, ok := legacy["screen_name"].(string); ok {
+			user.Username = username
+		}
+	}
+	if user.Name == "" {
+		if name, ok := legacy["name"].(string); ok {
+			user.Name = name
+		}
+	}
+	if description, ok := legacy["description"].(string); ok {
+		user.Description = description
+	}
+	if followers, ok := legacy["followers_count"].(float64); ok {
+		user.Followers = int(followers)
+	}
+	if following, ok := legacy["friends_count"].(float64); ok {
+		user.Following = int(following)
+	}
+	if verified, ok := legacy["verified"].(bool); ok {
+		user.Verified = verified
+	}
+	if tweets, ok := legacy["statuses_count"].(float64); ok {
+		user.Tweets = int(tweets)
+	}
+	if user.ProfileImage == "" {
+		if imageURL, ok := legacy["profile_image_url_https"].(string); ok {
+			user.ProfileImage = imageURL
+		}
+	}
+	if defaultImage, ok := legacy["default_profile_image"].(bool); ok {
+		user.DefaultProfileImage = defaultImage
+	}
+	if strings.Contains(user.ProfileImage, "default_profile_images") {
+		user.DefaultProfileImage = true
+	}
+	if user.CreatedAt.IsZero() {
+		if createdAt, ok := legacy["created_at"].(string); ok {
+			if parsedTime, err := time.Parse(twitterTimeLayout, createdAt); err == nil {
+				user.CreatedAt = parsedTime
+			}
+		}
+	}
+}
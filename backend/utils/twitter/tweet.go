@@ -3,9 +3,9 @@ package utils_twitter
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -159,12 +159,12 @@ func GetEngagementStats(tweet *Tweet) (int, int, int) {
 }
 
 // GetTweetData fetches and extracts complete tweet data by ID
-func GetTweetData(tweetID string) (*TweetData, error) {
-	if !isLoggedIn {
+func (s *Session) GetTweetData(tweetID string) (*TweetData, error) {
+	if !s.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
-	tweet, err := fetchSingleTweet(tweetID)
+	tweet, err := s.fetchSingleTweet(tweetID)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +178,7 @@ func GetTweetData(tweetID string) (*TweetData, error) {
 }
 
 // fetchSingleTweet fetches a single tweet by ID using Twitter API
-func fetchSingleTweet(tweetID string) (*Tweet, error) {
+func (s *Session) fetchSingleTweet(tweetID string) (*Tweet, error) {
 	req, err := http.NewRequest("GET", "https://x.com/i/api/graphql/wqi5M7wZ7tW-X9S2t-Mqcg/TweetResultByRestId", nil)
 	if err != nil {
 		return nil, err
@@ -243,38 +243,21 @@ func fetchSingleTweet(tweetID string) (*Tweet, error) {
 	query.Set("fieldToggles", string(fieldTogglesJSON))
 	req.URL.RawQuery = query.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+bearerToken2)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("X-Guest-Token", globalGuestToken)
-	req.Header.Set("X-Twitter-Active-User", "yes")
-	req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
-	req.Header.Set("X-Twitter-Client-Language", "en")
 	req.Header.Set("Content-Type", "application/json")
 
-	for _, cookie := range globalClient.Jar.Cookies(req.URL) {
-		if cookie.Name == "ct0" {
-			req.Header.Set("X-CSRF-Token", cookie.Value)
-			break
-		}
-	}
-
-	resp, err := globalClient.Do(req)
+	body, err := s.do(OpTweetResult, req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
 
 	var result map[string]interface{}
 	json.Unmarshal(body, &result)
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
-	}
-
 	// Parse the tweet from the response
 	tweet := parseTweetResultByRestId(result)
+	if tweet == nil || tweet.ID == "" {
+		return nil, fmt.Errorf("tweet %s: %w", tweetID, ErrNotFound)
+	}
 	return tweet, nil
 }
 
@@ -313,6 +296,9 @@ func parseTweetFromTweetResult(result map[string]interface{}) *Tweet {
 		if fullText, ok := legacy["full_text"].(string); ok {
 			tweet.Text = fullText
 		}
+		if lang, ok := legacy["lang"].(string); ok {
+			tweet.Lang = lang
+		}
 		if likes, ok := legacy["favorite_count"].(float64); ok {
 			tweet.Likes = int(likes)
 		}
@@ -322,17 +308,24 @@ func parseTweetFromTweetResult(result map[string]interface{}) *Tweet {
 		if replies, ok := legacy["reply_count"].(float64); ok {
 			tweet.Replies = int(replies)
 		}
+		if quotes, ok := legacy["quote_count"].(float64); ok {
+			tweet.Quotes = int(quotes)
+		}
 		if createdAt, ok := legacy["created_at"].(string); ok {
 			if parsedTime, err := time.Parse("Mon Jan 02 15:04:05 -0700 2006", createdAt); err == nil {
 				tweet.Timestamp = parsedTime
 			}
 		}
 	}
+	tweet.Views = parseViewCount(result)
 
 	// Get user info from core
 	if core, ok := result["core"].(map[string]interface{}); ok {
 		if userResults, ok := core["user_results"].(map[string]interface{}); ok {
 			if userResult, ok := userResults["result"].(map[string]interface{}); ok {
+				if authorID, ok := userResult["rest_id"].(string); ok {
+					tweet.AuthorID = authorID
+				}
 				if userCore, ok := userResult["core"].(map[string]interface{}); ok {
 					if name, ok := userCore["name"].(string); ok {
 						tweet.Name = name
@@ -349,8 +342,8 @@ func parseTweetFromTweetResult(result map[string]interface{}) *Tweet {
 }
 
 // GetTweetWithMedia fetches tweet with media and avatar information
-func GetTweetWithMedia(tweetID string) (*TweetWithMedia, error) {
-	if !isLoggedIn {
+func (s *Session) GetTweetWithMedia(tweetID string) (*TweetWithMedia, error) {
+	if !s.loggedIn {
 		return nil, fmt.Errorf("not logged in")
 	}
 
@@ -418,38 +411,21 @@ func GetTweetWithMedia(tweetID string) (*TweetWithMedia, error) {
 	query.Set("fieldToggles", string(fieldTogglesJSON))
 	req.URL.RawQuery = query.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+bearerToken2)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("X-Guest-Token", globalGuestToken)
-	req.Header.Set("X-Twitter-Active-User", "yes")
-	req.Header.Set("X-Twitter-Auth-Type", "OAuth2Session")
-	req.Header.Set("X-Twitter-Client-Language", "en")
 	req.Header.Set("Content-Type", "application/json")
 
-	for _, cookie := range globalClient.Jar.Cookies(req.URL) {
-		if cookie.Name == "ct0" {
-			req.Header.Set("X-CSRF-Token", cookie.Value)
-			break
-		}
-	}
-
-	resp, err := globalClient.Do(req)
+	body, err := s.do(OpTweetResult, req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
 
 	var result map[string]interface{}
 	json.Unmarshal(body, &result)
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
-	}
-
 	// Parse the full tweet with media
 	tweetWithMedia := parseFullTweetResult(result)
+	if tweetWithMedia.Tweet.ID == "" {
+		return nil, fmt.Errorf("tweet %s: %w", tweetID, ErrNotFound)
+	}
 	return tweetWithMedia, nil
 }
 
@@ -470,11 +446,16 @@ func parseFullTweetResult(result map[string]interface{}) *TweetWithMedia {
 					tweetWithMedia.Tweet.ID = restId
 				}
 
+				tweetWithMedia.Tweet.Views = parseViewCount(tweetData)
+
 				// Parse legacy data
 				if legacy, ok := tweetData["legacy"].(map[string]interface{}); ok {
 					if fullText, ok := legacy["full_text"].(string); ok {
 						tweetWithMedia.Tweet.Text = fullText
 					}
+					if lang, ok := legacy["lang"].(string); ok {
+						tweetWithMedia.Tweet.Lang = lang
+					}
 					if likes, ok := legacy["favorite_count"].(float64); ok {
 						tweetWithMedia.Tweet.Likes = int(likes)
 					}
@@ -484,6 +465,9 @@ func parseFullTweetResult(result map[string]interface{}) *TweetWithMedia {
 					if replies, ok := legacy["reply_count"].(float64); ok {
 						tweetWithMedia.Tweet.Replies = int(replies)
 					}
+					if quotes, ok := legacy["quote_count"].(float64); ok {
+						tweetWithMedia.Tweet.Quotes = int(quotes)
+					}
 					if createdAt, ok := legacy["created_at"].(string); ok {
 						if parsedTime, err := time.Parse("Mon Jan 02 15:04:05 -0700 2006", createdAt); err == nil {
 							tweetWithMedia.Tweet.Timestamp = parsedTime
@@ -541,6 +525,9 @@ func parseFullTweetResult(result map[string]interface{}) *TweetWithMedia {
 				if core, ok := tweetData["core"].(map[string]interface{}); ok {
 					if userResults, ok := core["user_results"].(map[string]interface{}); ok {
 						if userResult, ok := userResults["result"].(map[string]interface{}); ok {
+							if authorID, ok := userResult["rest_id"].(string); ok {
+								tweetWithMedia.Tweet.AuthorID = authorID
+							}
 							if userCore, ok := userResult["core"].(map[string]interface{}); ok {
 								if name, ok := userCore["name"].(string); ok {
 									tweetWithMedia.Tweet.Name = name
@@ -564,3 +551,15 @@ func parseFullTweetResult(result map[string]interface{}) *TweetWithMedia {
 
 	return tweetWithMedia
 }
+
+// parseViewCount reads the view count of a tweet result, which X sends as a string
+func parseViewCount(result map[string]interface{}) int {
+	if views, ok := result["views"].(map[string]interface{}); ok {
+		if count, ok := views["count"].(string); ok {
+			if n, err := strconv.Atoi(count); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
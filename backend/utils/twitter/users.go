@@ -0,0 +1,91 @@
+package utils_twitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// twitterTimeLayout is the format of created_at fields returned by X
+const twitterTimeLayout = "Mon Jan 02 15:04:05 -0700 2006"
+
+// REST operations tracked separately for rate limiting
+const (
+	OpUserShow       = "users/show"
+	OpFriendshipShow = "friendships/show"
+)
+
+// GetUserProfile fetches the full profile of a user by ID
+func (s *Session) GetUserProfile(userID string) (*User, error) {
+	query := url.Values{}
+	query.Set("user_id", userID)
+	return s.getUserProfile(query, userID)
+}
+
+// GetUserProfileByUsername fetches the full profile of a user by username
+func (s *Session) GetUserProfileByUsername(username string) (*User, error) {
+	query := url.Values{}
+	query.Set("screen_name", username)
+	return s.getUserProfile(query, "@"+username)
+}
+
+func (s *Session) getUserProfile(query url.Values, label string) (*User, error) {
+	if !s.loggedIn {
+		return nil, fmt.Errorf("not logged in")
+	}
+
+	req, err := http.NewRequest("GET", "https://x.com/i/api/1.1/users/show.json?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.do(OpUserShow, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var legacy map[string]interface{}
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse user %s: %w", label, err)
+	}
+
+	user := &User{}
+	applyLegacyUser(user, legacy)
+	if user.ID == "" {
+		return nil, fmt.Errorf("user %s: %w", label, ErrNotFound)
+	}
+	return user, nil
+}
+
+// IsFollowing reports whether the user sourceID follows the account targetUsername
+func (s *Session) IsFollowing(sourceID, targetUsername string) (bool, error) {
+	if !s.loggedIn {
+		return false, fmt.Errorf("not logged in")
+	}
+
+	query := url.Values{}
+	query.Set("source_id", sourceID)
+	query.Set("target_screen_name", targetUsername)
+	req, err := http.NewRequest("GET", "https://x.com/i/api/1.1/friendships/show.json?"+query.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	body, err := s.do(OpFriendshipShow, req)
+	if err != nil {
+		return false, err
+	}
+
+	var result struct {
+		Relationship struct {
+			Source struct {
+				Following bool `json:"following"`
+			} `json:"source"`
+		} `json:"relationship"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("failed to parse friendship of %s: %w", sourceID, err)
+	}
+	return result.Relationship.Source.Following, nil
+}
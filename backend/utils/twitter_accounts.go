@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	utils_twitter "ripper-backend/utils/twitter"
+	"time"
+)
+
+// StartTwitterLogin logs a Twitter account in on the background and records
+// the outcome in its health fields. It returns false when a login of the
+// account is already running.
+func StartTwitterLogin(account models.TwitterAccount) bool {
+	started := utils_twitter.StartLoginAsync(account.Username, account.Password, account.UserID, account.ID, account.ProxyURL, func(err error) {
+		now := time.Now()
+		updates := map[string]interface{}{"status": models.TwitterAccountActive, "last_error": "", "last_login_at": now, "last_validated_at": now}
+		if err != nil {
+			updates = map[string]interface{}{"status": models.TwitterAccountFailed, "last_error": err.Error()}
+		}
+		if dbErr := config.DB.Model(&models.TwitterAccount{}).Where("id = ?", account.ID).Updates(updates).Error; dbErr != nil {
+			log.Printf("❌ Failed to record login of @%s: %v", account.Username, dbErr)
+		}
+	})
+	if !started {
+		return false
+	}
+
+	config.DB.Model(&models.TwitterAccount{}).Where("id = ?", account.ID).
+		Updates(map[string]interface{}{"status": models.TwitterAccountLoggingIn, "last_error": ""})
+	return true
+}
+
+// CheckTwitterSession loads and validates the saved session of an account and
+// records the result in its health fields. It returns the updated account.
+func CheckTwitterSession(account models.TwitterAccount) models.TwitterAccount {
+	if utils_twitter.IsLoginInProgress(account.ID) {
+		account.Status = models.TwitterAccountLoggingIn
+		return account
+	}
+
+	now := time.Now()
+	account.LastValidatedAt = &now
+	account.Status, account.LastError = models.TwitterAccountActive, ""
+
+	session, err := utils_twitter.LoadSession(account.UserID, account.ID, account.ProxyURL)
+	if err != nil {
+		account.Status, account.LastError = models.TwitterAccountExpired, "No valid Twitter session found"
+	} else if !session.Validate() {
+		account.Status, account.LastError = models.TwitterAccountExpired, "Twitter session expired"
+	}
+
+	config.DB.Model(&models.TwitterAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+		"status":            account.Status,
+		"last_error":        account.LastError,
+		"last_validated_at": now,
+	})
+	return account
+}
+
+// MarkTwitterSessionExpired records that a request found the session of an
+// account missing or rejected
+func MarkTwitterSessionExpired(twitterAccountID, reason string) {
+	config.DB.Model(&models.TwitterAccount{}).
+		Where("id = ? AND status <> ?", twitterAccountID, models.TwitterAccountLoggingIn).
+		Updates(map[string]interface{}{"status": models.TwitterAccountExpired, "last_error": reason, "last_validated_at": time.Now()})
+}
+
+// DeleteTwitterAccount removes an account with its token file. Its pending
+// scheduled tweets and DMs and its running tweet watches are cancelled, since
+// nothing else can publish or snapshot for them.
+func DeleteTwitterAccount(account models.TwitterAccount) error {
+	if err := config.DB.Delete(&account).Error; err != nil {
+		return err
+	}
+
+	if err := utils_twitter.DeleteSession(account.UserID, account.ID); err != nil {
+		log.Printf("⚠️ Failed to delete the token file of @%s: %v", account.Username, err)
+	}
+
+	config.DB.Model(&models.ScheduledMessage{}).
+		Where("twitter_account_id = ? AND status IN ?", account.ID, []string{"pending", "paused"}).
+		Updates(map[string]interface{}{"status": "cancelled", "error_message": "Twitter account deleted"})
+	config.DB.Model(&models.TweetWatch{}).
+		Where("twitter_account_id = ? AND status IN ?", account.ID, []string{"active", "paused"}).
+		Updates(map[string]interface{}{"status": "cancelled", "last_error": "Twitter account deleted"})
+
+	log.Printf("🗑️ Deleted Twitter account @%s", account.Username)
+	return nil
+}
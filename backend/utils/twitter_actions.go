@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	utils_twitter "ripper-backend/utils/twitter"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TwitterActionWindow is the rolling window the per-action quotas apply to
+const TwitterActionWindow = 24 * time.Hour
+
+// Write actions with a quota of their own. Undoing an action counts toward the
+// same quota, so like/unlike churn cannot dodge it.
+const (
+	TwitterActionTweet    = "tweet"
+	TwitterActionReply    = "reply"
+	TwitterActionQuote    = "quote"
+	TwitterActionLike     = "like"
+	TwitterActionRetweet  = "retweet"
+	TwitterActionBookmark = "bookmark"
+	TwitterActionFollow   = "follow"
+	TwitterActionDM       = "dm"
+)
+
+// TwitterActionQuotas is how many of each action one Twitter account may perform
+// per TwitterActionWindow. Each can be overridden through TWITTER_QUOTA_<ACTION>.
+var TwitterActionQuotas = map[string]int{
+	TwitterActionTweet:    quotaFromEnv(TwitterActionTweet, 50),
+	TwitterActionReply:    quotaFromEnv(TwitterActionReply, 100),
+	TwitterActionQuote:    quotaFromEnv(TwitterActionQuote, 50),
+	TwitterActionLike:     quotaFromEnv(TwitterActionLike, 300),
+	TwitterActionRetweet:  quotaFromEnv(TwitterActionRetweet, 100),
+	TwitterActionBookmark: quotaFromEnv(TwitterActionBookmark, 300),
+	TwitterActionFollow:   quotaFromEnv(TwitterActionFollow, 100),
+	TwitterActionDM:       quotaFromEnv(TwitterActionDM, 200),
+}
+
+// twitterActionOps is the X operation each action is charged as
+var twitterActionOps = map[string]string{
+	TwitterActionTweet:    utils_twitter.OpCreateTweet,
+	TwitterActionReply:    utils_twitter.OpCreateTweet,
+	TwitterActionQuote:    utils_twitter.OpCreateTweet,
+	TwitterActionLike:     utils_twitter.OpFavoriteTweet,
+	TwitterActionRetweet:  utils_twitter.OpCreateRetweet,
+	TwitterActionBookmark: utils_twitter.OpCreateBookmark,
+	TwitterActionFollow:   utils_twitter.OpFollow,
+	TwitterActionDM:       utils_twitter.OpDMSend,
+}
+
+// TwitterActionCost returns what one write of an action costs from the writes balance
+func TwitterActionCost(action string) int {
+	return TwitterRequestCost(twitterActionOps[action])
+}
+
+// ReserveTwitterWrite reserves the cost of one write of an action from the
+// user's Twitter writes balance. See ReserveQuota.
+func ReserveTwitterWrite(userID, action, reason string) (*QuotaReservation, error) {
+	return ReserveQuota(userID, models.QuotaTwitterWrites, TwitterActionCost(action), reason)
+}
+
+// TwitterDMInterval is the minimum time between two DMs from the same Twitter
+// account. It can be overridden in seconds through TWITTER_DM_INTERVAL.
+var TwitterDMInterval = dmIntervalFromEnv(30 * time.Second)
+
+// TwitterActionEndpoint is the API endpoint writes of an action are logged under
+func TwitterActionEndpoint(action string) string {
+	return "/twitter/actions/" + action
+}
+
+// TwitterActionUsage is an account's use of one action's quota
+type TwitterActionUsage struct {
+	Action    string `json:"action"`
+	Limit     int    `json:"limit"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"`
+}
+
+func quotaFromEnv(action string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv("TWITTER_QUOTA_" + strings.ToUpper(action))); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+func dmIntervalFromEnv(def time.Duration) time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("TWITTER_DM_INTERVAL")); err == nil && v >= 0 {
+		return time.Duration(v) * time.Second
+	}
+	return def
+}
+
+// TwitterActionLimitError is returned when an account has used up an action's
+// quota, or must wait before its next DM. RetryAt is when it may write again.
+type TwitterActionLimitError struct {
+	Message string
+	RetryAt time.Time
+}
+
+func (e *TwitterActionLimitError) Error() string {
+	return e.Message
+}
+
+// TwitterActionReservation is a slot of an action's quota held by a Twitter
+// account while its write is sent. A write that failed gives it back with Release.
+type TwitterActionReservation struct {
+	slotID   string
+	released bool
+}
+
+// ReserveTwitterAction takes a slot of an action's quota for a Twitter account
+// before the write is sent, and for DMs checks the account's pacing. Writes of
+// an account are serialized on its row, so concurrent ones can't pass the
+// checks together. It fails with a *TwitterActionLimitError when over the limit.
+func ReserveTwitterAction(account *models.TwitterAccount, action string) (*TwitterActionReservation, error) {
+	var slot models.TwitterActionSlot
+	var limitErr error
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			Where("id = ?", account.ID).First(&models.TwitterAccount{}).Error; err != nil {
+			return err
+		}
+
+		// Slots out of the window no longer count for anything
+		since := time.Now().Add(-TwitterActionWindow)
+		if err := tx.Where("twitter_account_id = ? AND created_at <= ?", account.ID, since).Delete(&models.TwitterActionSlot{}).Error; err != nil {
+			return err
+		}
+
+		usage, oldest, err := twitterActionUsage(tx, account.ID, action)
+		if err != nil {
+			return err
+		}
+		if usage.Remaining <= 0 {
+			limitErr = &TwitterActionLimitError{
+				Message: fmt.Sprintf("daily %s quota of %d reached for @%s", action, usage.Limit, account.Username),
+				RetryAt: oldest.Add(TwitterActionWindow),
+			}
+			return nil
+		}
+		if action == TwitterActionDM {
+			if next := nextTwitterDMAt(tx, account.ID); next.After(time.Now()) {
+				limitErr = twitterDMPacingError(account, next)
+				return nil
+			}
+		}
+
+		slot = models.TwitterActionSlot{TwitterAccountID: account.ID, Action: action}
+		return tx.Create(&slot).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve a %s of @%s", action, account.Username)
+	}
+	if limitErr != nil {
+		return nil, limitErr
+	}
+	return &TwitterActionReservation{slotID: slot.ID}, nil
+}
+
+// Release gives the slot back after the write failed
+func (r *TwitterActionReservation) Release() {
+	if r.released {
+		return
+	}
+	r.released = true
+
+	if err := config.DB.Where("id = ?", r.slotID).Delete(&models.TwitterActionSlot{}).Error; err != nil {
+		log.Printf("❌ Failed to release Twitter action slot %s: %v", r.slotID, err)
+	}
+}
+
+// GetTwitterActionUsage counts the slots of an action a Twitter account holds
+// within the window: its sent writes and the ones being sent
+func GetTwitterActionUsage(twitterAccountID, action string) (TwitterActionUsage, error) {
+	usage, _, err := twitterActionUsage(config.DB, twitterAccountID, action)
+	return usage, err
+}
+
+// twitterActionUsage counts the slots of an action within the window, and
+// returns when the oldest of them was taken
+func twitterActionUsage(db *gorm.DB, twitterAccountID, action string) (TwitterActionUsage, time.Time, error) {
+	var slots []models.TwitterActionSlot
+	err := db.Select("created_at").
+		Where("twitter_account_id = ? AND action = ? AND created_at > ?", twitterAccountID, action, time.Now().Add(-TwitterActionWindow)).
+		Order("created_at").
+		Find(&slots).Error
+	if err != nil {
+		return TwitterActionUsage{}, time.Time{}, err
+	}
+
+	var oldest time.Time
+	if len(slots) > 0 {
+		oldest = slots[0].CreatedAt
+	}
+	limit := TwitterActionQuotas[action]
+	remaining := limit - len(slots)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return TwitterActionUsage{Action: action, Limit: limit, Used: len(slots), Remaining: remaining}, oldest, nil
+}
+
+// CheckTwitterActionQuota returns an error when the account has used up an
+// action's quota. It only looks; writes take their slot with ReserveTwitterAction.
+func CheckTwitterActionQuota(account *models.TwitterAccount, action string) error {
+	usage, err := GetTwitterActionUsage(account.ID, action)
+	if err != nil {
+		return fmt.Errorf("failed to check %s quota", action)
+	}
+	if usage.Remaining <= 0 {
+		return fmt.Errorf("daily %s quota of %d reached for @%s", action, usage.Limit, account.Username)
+	}
+	return nil
+}
+
+// NextTwitterDMAt returns when a Twitter account may send its next DM, which
+// is in the past when it may send one now
+func NextTwitterDMAt(twitterAccountID string) time.Time {
+	return nextTwitterDMAt(config.DB, twitterAccountID)
+}
+
+func nextTwitterDMAt(db *gorm.DB, twitterAccountID string) time.Time {
+	var last models.TwitterActionSlot
+	err := db.Where("twitter_account_id = ? AND action = ?", twitterAccountID, TwitterActionDM).
+		Order("created_at DESC").
+		First(&last).Error
+	if err != nil {
+		return time.Time{}
+	}
+	return last.CreatedAt.Add(TwitterDMInterval)
+}
+
+// CheckTwitterDMPacing returns an error when a Twitter account must wait before
+// its next DM. It only looks; DMs take their slot with ReserveTwitterAction.
+func CheckTwitterDMPacing(account *models.TwitterAccount) *TwitterActionLimitError {
+	if next := NextTwitterDMAt(account.ID); next.After(time.Now()) {
+		return twitterDMPacingError(account, next)
+	}
+	return nil
+}
+
+// twitterDMPacingError is the error of a DM sent before the account's next DM time
+func twitterDMPacingError(account *models.TwitterAccount, next time.Time) *TwitterActionLimitError {
+	return &TwitterActionLimitError{
+		Message: fmt.Sprintf("@%s may send its next DM in %ds", account.Username, int(time.Until(next).Seconds())+1),
+		RetryAt: next,
+	}
+}
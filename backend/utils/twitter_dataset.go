@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"log"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	utils_twitter "ripper-backend/utils/twitter"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// datasetBatchSize is how many rows are upserted per statement
+const datasetBatchSize = 200
+
+// SaveScrapedTweet upserts a looked-up tweet and its author into the dataset
+func SaveScrapedTweet(tweet *utils_twitter.Tweet) {
+	if tweet == nil || tweet.ID == "" {
+		return
+	}
+
+	if err := upsertTweets([]*utils_twitter.Tweet{tweet}); err != nil {
+		log.Printf("⚠️ Failed to store tweet %s: %v", tweet.ID, err)
+	}
+}
+
+// SaveEngagers upserts the users that liked or retweeted tweetID, and their
+// engagement edges, into the dataset of userID
+func SaveEngagers(userID, tweetID, engagementType string, users []*utils_twitter.User) {
+	if len(users) == 0 {
+		return
+	}
+
+	rows := make([]models.TwitterUser, 0, len(users))
+	seen := make(map[string]bool, len(users))
+	for _, u := range users {
+		if u == nil || u.ID == "" || seen[u.ID] {
+			continue
+		}
+		seen[u.ID] = true
+		rows = append(rows, models.TwitterUser{
+			ID:           u.ID,
+			Username:     u.Username,
+			Name:         u.Name,
+			Description:  u.Description,
+			Followers:    u.Followers,
+			Following:    u.Following,
+			Verified:     u.Verified,
+			BlueVerified: u.BlueVerified,
+		})
+	}
+
+	err := config.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"username", "name", "description", "followers", "following", "verified", "blue_verified", "updated_at",
+		}),
+	}).CreateInBatches(&rows, datasetBatchSize).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to store %s users of tweet %s: %v", engagementType, tweetID, err)
+		return
+	}
+
+	edges := make([]models.TwitterEngagement, 0, len(rows))
+	for _, row := range rows {
+		edges = append(edges, models.TwitterEngagement{
+			UserID:        userID,
+			TweetID:       tweetID,
+			TwitterUserID: row.ID,
+			Type:          engagementType,
+		})
+	}
+	if err := upsertEngagements(edges); err != nil {
+		log.Printf("⚠️ Failed to store %s engagements of tweet %s: %v", engagementType, tweetID, err)
+	}
+}
+
+// SaveEngagementTweets upserts the quotes or replies of tweetID, their authors
+// and the engagement edges into the dataset of userID
+func SaveEngagementTweets(userID, tweetID, engagementType string, tweets []*utils_twitter.Tweet) {
+	if len(tweets) == 0 {
+		return
+	}
+
+	if err := upsertTweets(tweets); err != nil {
+		log.Printf("⚠️ Failed to store %s tweets of tweet %s: %v", engagementType, tweetID, err)
+		return
+	}
+
+	edges := make([]models.TwitterEngagement, 0, len(tweets))
+	for _, t := range tweets {
+		if t == nil || t.ID == "" || t.AuthorID == "" {
+			continue
+		}
+		edges = append(edges, models.TwitterEngagement{
+			UserID:        userID,
+			TweetID:       tweetID,
+			TwitterUserID: t.AuthorID,
+			Type:          engagementType,
+			SourceTweetID: t.ID,
+		})
+	}
+	if err := upsertEngagements(edges); err != nil {
+		log.Printf("⚠️ Failed to store %s engagements of tweet %s: %v", engagementType, tweetID, err)
+	}
+}
+
+// upsertTweets stores tweets and a minimal profile of their authors. Author
+// rows only update username and name, so follower counts seen elsewhere are kept.
+func upsertTweets(tweets []*utils_twitter.Tweet) error {
+	tweetRows := make([]models.TwitterTweet, 0, len(tweets))
+	authorRows := []models.TwitterUser{}
+	seenTweets := make(map[string]bool, len(tweets))
+	seenAuthors := make(map[string]bool)
+
+	for _, t := range tweets {
+		if t == nil || t.ID == "" || seenTweets[t.ID] {
+			continue
+		}
+		seenTweets[t.ID] = true
+
+		row := models.TwitterTweet{
+			ID:             t.ID,
+			AuthorID:       t.AuthorID,
+			AuthorUsername: t.Username,
+			AuthorName:     t.Name,
+			Text:           t.Text,
+			Likes:          t.Likes,
+			Retweets:       t.Retweets,
+			Replies:        t.Replies,
+			Quotes:         t.Quotes,
+			Views:          t.Views,
+		}
+		if !t.Timestamp.IsZero() {
+			postedAt := t.Timestamp
+			row.PostedAt = &postedAt
+		}
+		tweetRows = append(tweetRows, row)
+
+		if t.AuthorID != "" && !seenAuthors[t.AuthorID] {
+			seenAuthors[t.AuthorID] = true
+			authorRows = append(authorRows, models.TwitterUser{ID: t.AuthorID, Username: t.Username, Name: t.Name})
+		}
+	}
+
+	if len(authorRows) > 0 {
+		err := config.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"username", "name", "updated_at"}),
+		}).CreateInBatches(&authorRows, datasetBatchSize).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(tweetRows) == 0 {
+		return nil
+	}
+
+	return config.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"author_id", "author_username", "author_name", "text", "likes", "retweets", "replies", "quotes", "views", "posted_at", "updated_at",
+		}),
+	}).CreateInBatches(&tweetRows, datasetBatchSize).Error
+}
+
+// upsertEngagements stores engagement edges, refreshing last_seen_at of known ones
+func upsertEngagements(edges []models.TwitterEngagement) error {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	unique := make([]models.TwitterEngagement, 0, len(edges))
+	seen := make(map[string]bool, len(edges))
+	for _, e := range edges {
+		key := e.TwitterUserID + "|" + e.Type + "|" + e.SourceTweetID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		e.LastSeenAt = now
+		unique = append(unique, e)
+	}
+
+	return config.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "user_id"}, {Name: "tweet_id"}, {Name: "twitter_user_id"}, {Name: "type"}, {Name: "source_tweet_id"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).CreateInBatches(&unique, datasetBatchSize).Error
+}
+
+// EngagerStat is a Twitter user together with how many of the queried tweets they engaged with
+type EngagerStat struct {
+	models.TwitterUser
+	EngagedTweets int `json:"engaged_tweets"`
+}
+
+// InDataset scopes a query of stored tweets to the dataset of userID: the
+// tweets they scraped engagements of, and the quotes and replies they scraped.
+// Tweets are stored once for everyone, so this keeps users from reading each other's scrapes.
+func InDataset(userID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`(id IN (SELECT tweet_id FROM twitter_engagements WHERE user_id = ?)
+			OR id IN (SELECT source_tweet_id FROM twitter_engagements WHERE user_id = ? AND source_tweet_id <> ''))`,
+			userID, userID)
+	}
+}
+
+// LatestTweetIDsByAuthor returns the IDs of the newest tweets of an author in userID's dataset.
+// Tweet IDs are time ordered, so longer and then larger IDs are newer.
+func LatestTweetIDsByAuthor(userID, username string, n int) ([]string, error) {
+	var ids []string
+	err := config.DB.Model(&models.TwitterTweet{}).
+		Scopes(InDataset(userID)).
+		Where("LOWER(author_username) = LOWER(?)", username).
+		Order("LENGTH(id) DESC, id DESC").
+		Limit(n).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// TopEngagers returns the users in userID's dataset that engaged with at least
+// minTweets of tweetIDs, most engaged first. An empty engagementType matches any type.
+func TopEngagers(userID string, tweetIDs []string, engagementType string, minTweets, limit int) ([]EngagerStat, error) {
+	stats := []EngagerStat{}
+	if len(tweetIDs) == 0 {
+		return stats, nil
+	}
+
+	query := config.DB.Table("twitter_engagements AS e").
+		Select("u.*, COUNT(DISTINCT e.tweet_id) AS engaged_tweets").
+		Joins("JOIN twitter_users u ON u.id = e.twitter_user_id").
+		Where("e.user_id = ? AND e.tweet_id IN ?", userID, tweetIDs)
+	if engagementType != "" {
+		query = query.Where("e.type = ?", engagementType)
+	}
+
+	err := query.
+		Group("u.id").
+		Having("COUNT(DISTINCT e.tweet_id) >= ?", minTweets).
+		Order("engaged_tweets DESC, u.followers DESC").
+		Limit(limit).
+		Scan(&stats).Error
+	return stats, err
+}
+
+// TweetEngagers returns the users in userID's dataset that engaged with a tweet
+func TweetEngagers(userID, tweetID, engagementType string, limit int) ([]models.TwitterUser, error) {
+	users := []models.TwitterUser{}
+	query := config.DB.Table("twitter_users AS u").
+		Select("DISTINCT u.*").
+		Joins("JOIN twitter_engagements e ON e.twitter_user_id = u.id").
+		Where("e.user_id = ? AND e.tweet_id = ?", userID, tweetID)
+	if engagementType != "" {
+		query = query.Where("e.type = ?", engagementType)
+	}
+	err := query.Order("u.followers DESC").Limit(limit).Scan(&users).Error
+	return users, err
+}
+
+// TwitterUserEngagements returns the engagements of one Twitter user in userID's dataset, newest first
+func TwitterUserEngagements(userID, twitterUserID string, limit int) ([]models.TwitterEngagement, error) {
+	var engagements []models.TwitterEngagement
+	err := config.DB.Where("user_id = ? AND twitter_user_id = ?", userID, twitterUserID).
+		Order("first_seen_at DESC").
+		Limit(limit).
+		Find(&engagements).Error
+	return engagements, err
+}
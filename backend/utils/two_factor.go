@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"ripper-backend/config"
+	"ripper-backend/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TOTP parameters (RFC 6238), the defaults every authenticator app supports
+const (
+	totpIssuer = "Ripper"
+	totpPeriod = 30 // seconds per code
+	totpDigits = 6
+	totpSkew   = 1 // codes of the previous and next period are accepted too, for clock drift
+)
+
+// recoveryCodeCount is how many recovery codes a user gets at a time
+const recoveryCodeCount = 10
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random TOTP secret, base32 encoded
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI an authenticator app scans,
+// as a QR code, to enrol a secret
+func TOTPProvisioningURI(accountName, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(totpIssuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpCode returns the code of a secret for a time step
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// CheckTOTP returns the time step a code matches for a secret, trying the
+// steps around now. It doesn't guard against replay, see ValidateTOTP.
+func CheckTOTP(secret, code string) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// ValidateTOTP checks a code against the user's enabled secret and records
+// its time step, so the same code can't be used twice
+func ValidateTOTP(user *models.User, code string) bool {
+	step, ok := CheckTOTP(user.TOTPSecret, code)
+	if !ok {
+		return false
+	}
+
+	result := config.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	user.TOTPLastStep = step
+	return true
+}
+
+// EnableTwoFactor turns 2FA on with the secret set up earlier, recording the
+// step of the code that confirmed it, and issues the first recovery codes
+func EnableTwoFactor(user *models.User, step int64) ([]string, error) {
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(user).Updates(map[string]interface{}{"totp_enabled_at": now, "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// DisableTwoFactor turns 2FA off and drops the secret and recovery codes
+func DisableTwoFactor(userID string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_secret": "", "totp_enabled_at": nil, "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes with new ones
+func RegenerateRecoveryCodes(userID string) ([]string, error) {
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and creates new ones,
+// returning them in clear text for the only time
+func replaceRecoveryCodes(tx *gorm.DB, userID string) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(b)) // 10 characters
+		codes[i] = code[:5] + "-" + code[5:]
+		rows[i] = models.RecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode returns the SHA-256 of a recovery code, ignoring case,
+// spaces and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// UseRecoveryCode spends one of the user's unused recovery codes
+func UseRecoveryCode(userID, code string) bool {
+	result := config.DB.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// RecoveryCodesLeft counts the user's unused recovery codes
+func RecoveryCodesLeft(userID string) int64 {
+	var count int64
+	config.DB.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count)
+	return count
+}
+
+// VerifyTwoFactorCode accepts a TOTP code or, failing that, a recovery code
+func VerifyTwoFactorCode(user *models.User, code string) bool {
+	code = strings.TrimSpace(code)
+	if ValidateTOTP(user, code) {
+		return true
+	}
+	return UseRecoveryCode(user.ID, code)
+}
+
+// TwoFactorBlocked reports whether an organization requires 2FA that the user
+// hasn't enabled, keeping them from what is shared with it
+func TwoFactorBlocked(organizationID, userID string) bool {
+	var count int64
+	config.DB.Model(&models.Organization{}).
+		Where("id = ? AND require_two_factor", organizationID).
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.id = ? AND users.totp_enabled_at IS NOT NULL)", userID).
+		Count(&count)
+	return count > 0
+}
+
+// TwoFactorOrganizations returns the names of the user's organizations that require 2FA
+func TwoFactorOrganizations(userID string) []string {
+	var names []string
+	config.DB.Model(&models.Organization{}).
+		Where("require_two_factor AND id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)", userID).
+		Order("name").
+		Pluck("name", &names)
+	return names
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"net/url"
+	"ripper-backend/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// The SHA-1 vectors of RFC 6238, appendix B, truncated to 6 digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() failed: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("GenerateTOTPSecret() = %q, not 20 base32 bytes", secret)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		code   func(now int64) string
+		offset int64 // of the step the code should match
+		ok     bool
+	}{
+		{"current code", secret, func(now int64) string { return totpCode(key, now) }, 0, true},
+		{"previous code", secret, func(now int64) string { return totpCode(key, now-1) }, -1, true},
+		{"next code", secret, func(now int64) string { return totpCode(key, now+1) }, 1, true},
+		{"lowercase secret", strings.ToLower(secret), func(now int64) string { return totpCode(key, now) }, 0, true},
+		{"too old", secret, func(now int64) string { return totpCode(key, now-2) }, 0, false},
+		{"too new", secret, func(now int64) string { return totpCode(key, now+2) }, 0, false},
+		{"short code", secret, func(now int64) string { return totpCode(key, now)[1:] }, 0, false},
+		{"empty code", secret, func(int64) string { return "" }, 0, false},
+		{"invalid secret", "not base32!", func(now int64) string { return totpCode(key, now) }, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Retry once when the period turned over during the check
+			for attempt := 0; ; attempt++ {
+				now := time.Now().Unix() / totpPeriod
+				step, ok := CheckTOTP(tt.secret, tt.code(now))
+				if time.Now().Unix()/totpPeriod != now && attempt == 0 {
+					continue
+				}
+				if ok != tt.ok {
+					t.Fatalf("CheckTOTP() ok = %v, want %v", ok, tt.ok)
+				}
+				if ok && step != now+tt.offset {
+					t.Errorf("CheckTOTP() step = %d, want %d", step, now+tt.offset)
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("jane+ops@example.com", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatalf("TOTPProvisioningURI() is not a URL: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Ripper:jane+ops@example.com" {
+		t.Errorf("TOTPProvisioningURI() = %s, want otpauth://totp/Ripper:<account>", uri)
+	}
+
+	want := map[string]string{
+		"secret":    "JBSWY3DPEHPK3PXP",
+		"issuer":    "Ripper",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for param, value := range want {
+		if got := uri.Query().Get(param); got != value {
+			t.Errorf("TOTPProvisioningURI() %s = %q, want %q", param, got, value)
+		}
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := hashRecoveryCode("abcde-fghij")
+	tests := []struct {
+		code string
+		same bool
+	}{
+		{"abcde-fghij", true},
+		{"ABCDE-FGHIJ", true},
+		{"abcdefghij", true},
+		{"abcde fghij", true},
+		{" abcde-fghij ", true},
+		{"abcde-fghik", false},
+		{"abcde-fghi", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := hashRecoveryCode(tt.code) == want; got != tt.same {
+			t.Errorf("hashRecoveryCode(%q) matches = %v, want %v", tt.code, got, tt.same)
+		}
+	}
+}
+
+func TestVerifyTwoFactorCode(t *testing.T) {
+	user := testUser(t)
+
+	secret, _ := GenerateTOTPSecret()
+	key, _ := totpEncoding.DecodeString(secret)
+	if err := config.DB.Model(user).Update("totp_secret", secret).Error; err != nil {
+		t.Fatalf("failed to set the secret: %v", err)
+	}
+	user.TOTPSecret = secret
+
+	// Enable with a code of the previous period, leaving the current one unused
+	now := time.Now().Unix() / totpPeriod
+	codes, err := EnableTwoFactor(user, now-1)
+	if err != nil {
+		t.Fatalf("EnableTwoFactor() failed: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("EnableTwoFactor() returned %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	// The steps run in order: a code accepted once is refused afterwards
+	steps := []struct {
+		name string
+		code string
+		ok   bool
+		left int64
+	}{
+		{"code of the enabling period", totpCode(key, now-1), false, 10},
+		{"current code", " " + totpCode(key, now+1) + " ", true, 10},
+		{"current code replayed", totpCode(key, now+1), false, 10},
+		{"older code after a newer one", totpCode(key, now), false, 10},
+		{"wrong code", "000000", false, 10},
+		{"recovery code", codes[0], true, 9},
+		{"recovery code reused", codes[0], false, 9},
+		{"recovery code without dash, uppercase", strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")), true, 8},
+		{"unknown recovery code", "aaaaa-bbbbb", false, 8},
+	}
+
+	for _, step := range steps {
+		if ok := VerifyTwoFactorCode(user, step.code); ok != step.ok {
+			t.Errorf("%s: VerifyTwoFactorCode() = %v, want %v", step.name, ok, step.ok)
+		}
+		if left := RecoveryCodesLeft(user.ID); left != step.left {
+			t.Errorf("%s: RecoveryCodesLeft() = %d, want %d", step.name, left, step.left)
+		}
+	}
+
+	if err := DisableTwoFactor(user.ID); err != nil {
+		t.Fatalf("DisableTwoFactor() failed: %v", err)
+	}
+	if left := RecoveryCodesLeft(user.ID); left != 0 {
+		t.Errorf("RecoveryCodesLeft() after disabling = %d, want 0", left)
+	}
+	if UseRecoveryCode(user.ID, codes[2]) {
+		t.Errorf("UseRecoveryCode() accepted a code after disabling")
+	}
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// webhookClient never follows redirects, and its dialer refuses internal
+// addresses whatever the URL's host resolves to at send time
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil, // a proxy would connect on our behalf, past the dialer's check
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || isInternalIP(ip) {
+					return fmt.Errorf("webhook address %s is not public", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// internalNetworks are the ranges webhooks may not reach besides loopback,
+// private and link-local ones: carrier-grade NAT, often used for cluster pod
+// and service networks, and IPv4 this-network
+var internalNetworks = mustParseCIDRs("100.64.0.0/10", "0.0.0.0/8", "192.0.0.0/24", "198.18.0.0/15")
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// isInternalIP reports whether ip is loopback, private, link-local, multicast,
+// unspecified or in internalNetworks, so a webhook must not reach it
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// internalHostSuffixes are names that only resolve inside a cluster or host
+var internalHostSuffixes = []string{"localhost", ".local", ".internal", ".cluster.local", ".svc"}
+
+// ValidateWebhookURL checks that a webhook URL is an absolute http(s) URL whose
+// host resolves only to public addresses
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("webhook_url must be an http or https URL")
+	}
+
+	return checkPublicHost("webhook_url", u.Hostname())
+}
+
+// checkPublicHost checks that host is not an internal name and resolves only
+// to public addresses. field names the checked value in the errors.
+func checkPublicHost(field, host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, suffix := range internalHostSuffixes {
+		if host == strings.TrimPrefix(suffix, ".") || strings.HasSuffix(host, suffix) {
+			return fmt.Errorf("%s must point to a public host", field)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%s host %s could not be resolved", field, host)
+	}
+	for _, addr := range addrs {
+		if isInternalIP(addr.IP) {
+			return fmt.Errorf("%s must point to a public host", field)
+		}
+	}
+	return nil
+}
+
+// PostWebhook sends payload as JSON to a webhook URL. When WEBHOOK_SECRET is set
+// the body is signed with HMAC-SHA256 in the X-Webhook-Signature header. The URL
+// is checked again before sending, since its host may resolve differently now,
+// and redirects are not followed.
+func PostWebhook(webhookURL string, payload interface{}) error {
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
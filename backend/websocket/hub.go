@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"ripper-backend/config"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -116,9 +117,8 @@ func HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	jwtSecret := []byte("your-secret-key")
 	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
+		return config.JWTSecret, nil
 	})
 
 	if err != nil || !parsedToken.Valid {